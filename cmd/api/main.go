@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"os"
 
+	"github.com/manuelbeos/code-branch-todo-test/internal/cli"
+	"github.com/manuelbeos/code-branch-todo-test/internal/config"
 	"github.com/manuelbeos/code-branch-todo-test/internal/server"
 )
 
@@ -13,8 +19,24 @@ func main() {
 		}
 	}()
 
-	myServer := server.NewServer()
-	if err := myServer.Run(); err != nil {
+	if len(os.Args) > 1 && os.Args[1] == cli.ChecklistCommand {
+		err := cli.Checklist(context.Background(), os.Args[2:], os.Getenv, os.Stdin, os.Stdout, os.Stderr)
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	myServer := server.NewServer(cfg)
+	if err := myServer.Run(context.Background()); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,88 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+)
+
+type EventType string
+
+const (
+	TaskCreated EventType = "task.created"
+	TaskUpdated EventType = "task.updated"
+	TaskDeleted EventType = "task.deleted"
+)
+
+// TaskEvent describes a change made to a task. Origin identifies who made the
+// change (e.g. a websocket client) so it can be skipped when broadcasting.
+// Only clients of Tenant receive the event, and among them the users listed
+// in Audience; an empty user stands for anonymous clients when
+// authentication is disabled.
+type TaskEvent struct {
+	Type     EventType    `json:"type"`
+	TaskID   uuid.UUID    `json:"task_id"`
+	Task     *entity.Task `json:"task,omitempty"`
+	Tenant   string       `json:"-"`
+	Audience []string     `json:"-"`
+	Origin   string       `json:"-"`
+}
+
+// Reaches reports whether user is in the audience of the event.
+func (e TaskEvent) Reaches(user string) bool {
+	for _, member := range e.Audience {
+		if member == user {
+			return true
+		}
+	}
+	return false
+}
+
+type originKey struct{}
+
+func WithOrigin(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+func OriginFromContext(ctx context.Context) string {
+	origin, _ := ctx.Value(originKey{}).(string)
+	return origin
+}
+
+// Bus is a synchronous in-process publisher of task events. Subscribers must
+// not block, they are called from the goroutine that publishes the event.
+type Bus struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers map[int]func(TaskEvent)
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[int]func(TaskEvent))}
+}
+
+func (b *Bus) Subscribe(fn func(TaskEvent)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = fn
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+	}
+}
+
+func (b *Bus) Publish(event TaskEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, fn := range b.subscribers {
+		fn(event)
+	}
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+)
+
+// APIKeyPrefix starts every generated key so they are easy to spot in logs
+// and by secret scanners.
+const APIKeyPrefix = "tdk_"
+
+// EnsuredKeyPrefix is the prefix listed for keys stored with EnsureAPIKey.
+// Their value comes from the configuration and may be short, so no part of
+// it is kept.
+const EnsuredKeyPrefix = "configured"
+
+type APIKeyService struct {
+	repository repository.APIKeyRepository
+	// ensuredKeySecret keys the hashes of the keys given to EnsureAPIKey, it
+	// is nil until the first one.
+	ensuredKeySecret []byte
+}
+
+func NewAPIKeyService(repository repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repository: repository}
+}
+
+// HashAPIKey returns the value stored for a plain text key.
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIKey() (plain string, prefix string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	plain = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return plain, plain[:len(APIKeyPrefix)+6], nil
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return domain.ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !auth.ValidScope(scope) {
+			return domain.ErrInvalidScope
+		}
+	}
+	return nil
+}
+
+// CreateAPIKey returns the new key and its plain text value, which is not
+// stored and can't be recovered later.
+func (aks *APIKeyService) CreateAPIKey(ctx context.Context, name string, owner string, tenant string, scopes []string) (*entity.APIKey, string, error) {
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+	if tenant != "" && !identity.ValidTenant(tenant) {
+		return nil, "", domain.ErrInvalidTenant
+	}
+	if auth.ReservedSubject(owner) {
+		return nil, "", domain.ErrInvalidOwner
+	}
+
+	plain, prefix, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := entity.NewAPIKey(name, owner, scopes, prefix, HashAPIKey(plain))
+	key.Tenant = tenant
+
+	created, err := aks.repository.CreateAPIKey(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return created, plain, nil
+}
+
+// EnsureAPIKey stores a key whose plain text value is already known, it is
+// used to bootstrap the first admin key from the configuration. The value may
+// be weak, so it is hashed with a secret of the service that is never stored,
+// and the key named name gets the new hash and tenant at every startup. A
+// revoked key stays revoked.
+func (aks *APIKeyService) EnsureAPIKey(ctx context.Context, name string, plain string, tenant string, scopes []string) error {
+	if tenant != "" && !identity.ValidTenant(tenant) {
+		return domain.ErrInvalidTenant
+	}
+	if aks.ensuredKeySecret == nil {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		aks.ensuredKeySecret = secret
+	}
+	hash := aks.hashEnsuredKey(plain)
+
+	keys, err := aks.repository.GetAllAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if key.Prefix != EnsuredKeyPrefix || key.Name != name {
+			continue
+		}
+		if key.IsRevoked() || (key.Hash == hash && key.Tenant == tenant) {
+			return nil
+		}
+		key.Hash = hash
+		key.Tenant = tenant
+		_, err = aks.repository.UpdateAPIKey(ctx, key)
+		return err
+	}
+
+	key := entity.NewAPIKey(name, "", scopes, EnsuredKeyPrefix, hash)
+	key.Tenant = tenant
+	_, err = aks.repository.CreateAPIKey(ctx, key)
+	return err
+}
+
+// hashEnsuredKey returns the value stored for a key given to EnsureAPIKey.
+func (aks *APIKeyService) hashEnsuredKey(plain string) string {
+	mac := hmac.New(sha256.New, aks.ensuredKeySecret)
+	mac.Write([]byte(plain))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (aks *APIKeyService) GetAllAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	return aks.repository.GetAllAPIKeys(ctx)
+}
+
+func (aks *APIKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	key, err := aks.repository.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key.IsRevoked() {
+		return nil
+	}
+
+	key.Revoke()
+	_, err = aks.repository.UpdateAPIKey(ctx, key)
+	return err
+}
+
+// RotateAPIKey replaces the secret of a key, the old value stops working
+// immediately.
+func (aks *APIKeyService) RotateAPIKey(ctx context.Context, id uuid.UUID) (*entity.APIKey, string, error) {
+	key, err := aks.repository.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if key.IsRevoked() {
+		return nil, "", domain.ErrAPIKeyRevoked
+	}
+
+	plain, prefix, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key.Rotate(prefix, HashAPIKey(plain))
+	updated, err := aks.repository.UpdateAPIKey(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return updated, plain, nil
+}
+
+// Authenticate resolves a plain text key to the principal it identifies.
+func (aks *APIKeyService) Authenticate(ctx context.Context, plain string) (*auth.Principal, error) {
+	plain = strings.TrimSpace(plain)
+	if plain == "" {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := aks.repository.GetAPIKeyByHash(ctx, HashAPIKey(plain))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) && aks.ensuredKeySecret != nil {
+		key, err = aks.repository.GetAPIKeyByHash(ctx, aks.hashEnsuredKey(plain))
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return nil, domain.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if key.IsRevoked() {
+		return nil, domain.ErrAPIKeyRevoked
+	}
+
+	subject := key.Owner
+	if subject == "" {
+		subject = auth.APIKeySubjectPrefix + key.Id.String()
+	}
+
+	return &auth.Principal{
+		Subject: subject,
+		Method:  "api_key",
+		Scopes:  key.Scopes,
+		Tenant:  key.Tenant,
+	}, nil
+}
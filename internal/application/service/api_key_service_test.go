@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/infrastructure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyService_CreateAPIKey_Success(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	service := NewAPIKeyService(infrastructure.NewMemoryAPIKeyRepository())
+
+	key, plain, err := service.CreateAPIKey(ctx, "ci", "", "", []string{auth.ScopeTasksRead})
+
+	asserts.Nil(err)
+	asserts.True(strings.HasPrefix(plain, APIKeyPrefix))
+	asserts.True(strings.HasPrefix(plain, key.Prefix))
+	asserts.Equal(HashAPIKey(plain), key.Hash)
+	asserts.NotContains(key.Hash, plain)
+
+	principal, err := service.Authenticate(ctx, plain)
+
+	asserts.Nil(err)
+	asserts.Equal("apikey:"+key.Id.String(), principal.Subject)
+	asserts.True(principal.HasScope(auth.ScopeTasksRead))
+	asserts.False(principal.HasScope(auth.ScopeTasksWrite))
+}
+
+func TestAPIKeyService_Authenticate_Owner(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	service := NewAPIKeyService(infrastructure.NewMemoryAPIKeyRepository())
+	_, plain, _ := service.CreateAPIKey(ctx, "alice laptop", "alice", "acme", []string{auth.ScopeTasksRead})
+
+	principal, err := service.Authenticate(ctx, plain)
+
+	asserts.Nil(err)
+	asserts.Equal("alice", principal.Subject)
+	asserts.Equal("acme", principal.Tenant)
+}
+
+func TestAPIKeyService_CreateAPIKey_Invalid_Scopes(t *testing.T) {
+	asserts := assert.New(t)
+	service := NewAPIKeyService(infrastructure.NewMemoryAPIKeyRepository())
+
+	tests := []struct {
+		name   string
+		scopes []string
+	}{
+		{name: "CreateAPIKey - No scopes", scopes: nil},
+		{name: "CreateAPIKey - Unknown scope", scopes: []string{auth.ScopeTasksRead, "tasks:everything"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := service.CreateAPIKey(context.Background(), "ci", "", "", tt.scopes)
+
+			asserts.ErrorIs(err, domain.ErrInvalidScope)
+		})
+	}
+}
+
+func TestAPIKeyService_CreateAPIKey_Reserved_Owner(t *testing.T) {
+	asserts := assert.New(t)
+	service := NewAPIKeyService(infrastructure.NewMemoryAPIKeyRepository())
+
+	_, _, err := service.CreateAPIKey(context.Background(), "ci", auth.APIKeySubjectPrefix+"ci", "", []string{auth.ScopeTasksRead})
+
+	asserts.ErrorIs(err, domain.ErrInvalidOwner)
+}
+
+func TestAPIKeyService_RevokeAPIKey(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	service := NewAPIKeyService(infrastructure.NewMemoryAPIKeyRepository())
+	key, plain, _ := service.CreateAPIKey(ctx, "ci", "", "", []string{auth.ScopeTasksRead})
+
+	asserts.Nil(service.RevokeAPIKey(ctx, key.Id))
+
+	_, err := service.Authenticate(ctx, plain)
+	asserts.ErrorIs(err, domain.ErrAPIKeyRevoked)
+
+	_, _, err = service.RotateAPIKey(ctx, key.Id)
+	asserts.ErrorIs(err, domain.ErrAPIKeyRevoked)
+
+	asserts.ErrorIs(service.RevokeAPIKey(ctx, uuid.New()), domain.ErrAPIKeyNotFound)
+}
+
+func TestAPIKeyService_RotateAPIKey(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	service := NewAPIKeyService(infrastructure.NewMemoryAPIKeyRepository())
+	key, oldPlain, _ := service.CreateAPIKey(ctx, "ci", "", "", []string{auth.ScopeTasksWrite})
+
+	rotated, newPlain, err := service.RotateAPIKey(ctx, key.Id)
+
+	asserts.Nil(err)
+	asserts.Equal(key.Id, rotated.Id)
+	asserts.NotEqual(oldPlain, newPlain)
+	asserts.NotNil(rotated.RotatedAt)
+
+	_, err = service.Authenticate(ctx, oldPlain)
+	asserts.ErrorIs(err, domain.ErrInvalidAPIKey)
+
+	principal, err := service.Authenticate(ctx, newPlain)
+	asserts.Nil(err)
+	asserts.True(principal.HasScope(auth.ScopeTasksWrite))
+}
+
+func TestAPIKeyService_EnsureAPIKey(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	repository := infrastructure.NewMemoryAPIKeyRepository()
+	service := NewAPIKeyService(repository)
+
+	asserts.Nil(service.EnsureAPIKey(ctx, "bootstrap", "s3cret-bootstrap-key", "", auth.Scopes))
+	asserts.Nil(service.EnsureAPIKey(ctx, "bootstrap", "s3cret-bootstrap-key", "", auth.Scopes))
+
+	keys, _ := service.GetAllAPIKeys(ctx)
+	asserts.Len(keys, 1)
+	asserts.Equal(EnsuredKeyPrefix, keys[0].Prefix)
+	asserts.NotEqual(HashAPIKey("s3cret-bootstrap-key"), keys[0].Hash)
+
+	principal, err := service.Authenticate(ctx, "s3cret-bootstrap-key")
+	asserts.Nil(err)
+	asserts.True(principal.HasScope(auth.ScopeAdminKeys))
+
+	// a restart hashes the key with another secret
+	restarted := NewAPIKeyService(repository)
+	asserts.Nil(restarted.EnsureAPIKey(ctx, "bootstrap", "s3cret-bootstrap-key", "", auth.Scopes))
+	keys, _ = restarted.GetAllAPIKeys(ctx)
+	asserts.Len(keys, 1)
+	_, err = restarted.Authenticate(ctx, "s3cret-bootstrap-key")
+	asserts.Nil(err)
+	_, err = restarted.Authenticate(ctx, "another-key")
+	asserts.ErrorIs(err, domain.ErrInvalidAPIKey)
+}
+
+func TestAPIKeyService_EnsureAPIKey_Tenant(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	repository := infrastructure.NewMemoryAPIKeyRepository()
+	service := NewAPIKeyService(repository)
+
+	asserts.ErrorIs(service.EnsureAPIKey(ctx, "bootstrap", "s3cret-bootstrap-key", "Not A Tenant", auth.Scopes), domain.ErrInvalidTenant)
+
+	asserts.Nil(service.EnsureAPIKey(ctx, "bootstrap", "s3cret-bootstrap-key", "acme", auth.Scopes))
+	principal, err := service.Authenticate(ctx, "s3cret-bootstrap-key")
+	asserts.Nil(err)
+	asserts.Equal("acme", principal.Tenant)
+
+	// a restart with another tenant moves the key
+	restarted := NewAPIKeyService(repository)
+	asserts.Nil(restarted.EnsureAPIKey(ctx, "bootstrap", "s3cret-bootstrap-key", "globex", auth.Scopes))
+	keys, _ := restarted.GetAllAPIKeys(ctx)
+	asserts.Len(keys, 1)
+	principal, err = restarted.Authenticate(ctx, "s3cret-bootstrap-key")
+	asserts.Nil(err)
+	asserts.Equal("globex", principal.Tenant)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+	"github.com/manuelbeos/code-branch-todo-test/internal/tracing"
+)
+
+// StatsPeriod is the length of the periods task counts are grouped by.
+type StatsPeriod string
+
+const (
+	StatsPeriodDay   StatsPeriod = "day"
+	StatsPeriodWeek  StatsPeriod = "week"
+	StatsPeriodMonth StatsPeriod = "month"
+)
+
+// StatsQuery selects the periods reported, From and To are optional and
+// include the periods they fall in.
+type StatsQuery struct {
+	GroupBy StatsPeriod
+	From    time.Time
+	To      time.Time
+}
+
+// PeriodStats counts the tasks created and completed in the period starting
+// at Start.
+type PeriodStats struct {
+	Start     time.Time
+	Created   int
+	Completed int
+}
+
+type TaskStats struct {
+	Total          int
+	Completed      int
+	Pending        int
+	CompletionRate float64
+	// AverageTimeToComplete is zero until a task is completed.
+	AverageTimeToComplete time.Duration
+	GroupBy               StatsPeriod
+	// Periods only lists the periods with some activity, oldest first.
+	Periods    []PeriodStats
+	ByPriority map[entity.Priority]entity.TaskCount
+	ByTag      map[string]entity.TaskCount
+}
+
+// TaskStatsService reports on the tasks the caller in the context owns, or on
+// the whole tenant when authentication is disabled, from the aggregates the
+// repository keeps, never scanning the tasks.
+type TaskStatsService struct {
+	aggregator repository.TaskAggregator
+}
+
+func NewTaskStatsService(aggregator repository.TaskAggregator) *TaskStatsService {
+	return &TaskStatsService{aggregator: aggregator}
+}
+
+func (tss *TaskStatsService) GetStats(ctx context.Context, query StatsQuery) (*TaskStats, error) {
+	ctx, span := tracing.Start(ctx, "TaskStatsService.GetStats")
+	defer span.End()
+
+	aggregate, err := tss.aggregator.AggregateTasks(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if query.GroupBy == "" {
+		query.GroupBy = StatsPeriodWeek
+	}
+
+	stats := &TaskStats{
+		Total:      aggregate.Tasks.Total,
+		Completed:  aggregate.Tasks.Completed,
+		Pending:    aggregate.Tasks.Total - aggregate.Tasks.Completed,
+		GroupBy:    query.GroupBy,
+		ByPriority: aggregate.ByPriority,
+		ByTag:      aggregate.ByTag,
+	}
+	if stats.Total > 0 {
+		stats.CompletionRate = float64(stats.Completed) / float64(stats.Total)
+	}
+	if aggregate.CompletionTimeCount > 0 {
+		stats.AverageTimeToComplete = aggregate.CompletionTime / time.Duration(aggregate.CompletionTimeCount)
+	}
+	stats.Periods = groupByPeriod(aggregate, query)
+
+	return stats, nil
+}
+
+func groupByPeriod(aggregate *entity.TaskAggregate, query StatsQuery) []PeriodStats {
+	var from, to time.Time
+	if !query.From.IsZero() {
+		from = periodStart(entity.Day(query.From), query.GroupBy)
+	}
+	if !query.To.IsZero() {
+		to = periodStart(entity.Day(query.To), query.GroupBy)
+	}
+
+	periods := map[time.Time]*PeriodStats{}
+	count := func(day time.Time) *PeriodStats {
+		start := periodStart(day, query.GroupBy)
+		if (!from.IsZero() && start.Before(from)) || (!to.IsZero() && start.After(to)) {
+			return nil
+		}
+		period, ok := periods[start]
+		if !ok {
+			period = &PeriodStats{Start: start}
+			periods[start] = period
+		}
+		return period
+	}
+	for day, created := range aggregate.CreatedByDay {
+		if period := count(day); period != nil {
+			period.Created += created
+		}
+	}
+	for day, completed := range aggregate.CompletedByDay {
+		if period := count(day); period != nil {
+			period.Completed += completed
+		}
+	}
+
+	grouped := make([]PeriodStats, 0, len(periods))
+	for _, period := range periods {
+		grouped = append(grouped, *period)
+	}
+	slices.SortFunc(grouped, func(a, b PeriodStats) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return grouped
+}
+
+// periodStart returns the first day of the period day is in, weeks start on
+// Monday.
+func periodStart(day time.Time, period StatsPeriod) time.Time {
+	switch period {
+	case StatsPeriodWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case StatsPeriodMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
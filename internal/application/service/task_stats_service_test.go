@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+	"github.com/manuelbeos/code-branch-todo-test/internal/infrastructure"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(day string) time.Time {
+	parsed, _ := time.Parse("2006-01-02 15:04", day)
+	return parsed
+}
+
+func newStatsService() *TaskStatsService {
+	completedAt := date("2026-10-08 12:00")
+	tasks := []entity.Task{
+		{Id: uuid.New(), Title: "Monday", Priority: entity.PriorityHigh, CreatedAt: date("2026-10-05 09:00")},
+		{Id: uuid.New(), Title: "Wednesday", Tags: []string{"work"}, IsCompleted: true, CompletedAt: &completedAt, CreatedAt: date("2026-10-07 12:00")},
+		{Id: uuid.New(), Title: "Next week", Tags: []string{"work"}, CreatedAt: date("2026-10-13 09:00")},
+		{Id: uuid.New(), Title: "Next month", CreatedAt: date("2026-11-02 09:00")},
+	}
+	memory := map[uuid.UUID]entity.Task{}
+	for _, task := range tasks {
+		memory[task.Id] = task
+	}
+
+	repo := infrastructure.NewMemoryStorageTodoListRepository(memory)
+	return NewTaskStatsService(repo.(repository.TaskAggregator))
+}
+
+func TestTaskStatsService_GetStats(t *testing.T) {
+	asserts := assert.New(t)
+
+	stats, err := newStatsService().GetStats(identity.WithoutAuthentication(context.Background()), StatsQuery{})
+
+	asserts.Nil(err)
+	asserts.Equal(4, stats.Total)
+	asserts.Equal(1, stats.Completed)
+	asserts.Equal(3, stats.Pending)
+	asserts.Equal(0.25, stats.CompletionRate)
+	asserts.Equal(24*time.Hour, stats.AverageTimeToComplete)
+	asserts.Equal(StatsPeriodWeek, stats.GroupBy)
+	asserts.Equal([]PeriodStats{
+		{Start: date("2026-10-05 00:00"), Created: 2, Completed: 1},
+		{Start: date("2026-10-12 00:00"), Created: 1},
+		{Start: date("2026-11-02 00:00"), Created: 1},
+	}, stats.Periods)
+	asserts.Equal(map[entity.Priority]entity.TaskCount{entity.PriorityHigh: {Total: 1}, entity.PriorityNormal: {Total: 3, Completed: 1}}, stats.ByPriority)
+	asserts.Equal(map[string]entity.TaskCount{"work": {Total: 2, Completed: 1}}, stats.ByTag)
+}
+
+func TestTaskStatsService_GetStats_Periods(t *testing.T) {
+	asserts := assert.New(t)
+	service := newStatsService()
+
+	tests := []struct {
+		name     string
+		query    StatsQuery
+		expected []PeriodStats
+	}{
+		{name: "GetStats - By month", query: StatsQuery{GroupBy: StatsPeriodMonth}, expected: []PeriodStats{
+			{Start: date("2026-10-01 00:00"), Created: 3, Completed: 1},
+			{Start: date("2026-11-01 00:00"), Created: 1},
+		}},
+		{name: "GetStats - By day in range", query: StatsQuery{GroupBy: StatsPeriodDay, From: date("2026-10-06 00:00"), To: date("2026-10-08 00:00")}, expected: []PeriodStats{
+			{Start: date("2026-10-07 00:00"), Created: 1},
+			{Start: date("2026-10-08 00:00"), Completed: 1},
+		}},
+		{name: "GetStats - From inside a week", query: StatsQuery{From: date("2026-10-14 00:00")}, expected: []PeriodStats{
+			{Start: date("2026-10-12 00:00"), Created: 1},
+			{Start: date("2026-11-02 00:00"), Created: 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := service.GetStats(identity.WithoutAuthentication(context.Background()), tt.query)
+
+			asserts.Nil(err)
+			asserts.Equal(tt.expected, stats.Periods)
+			asserts.Equal(4, stats.Total)
+		})
+	}
+}
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/events"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/tracing"
+)
+
+// ImportRow is a task read from an import, Row is its position in the
+// import, from 1.
+type ImportRow struct {
+	Row  int
+	Task entity.Task
+}
+
+// ImportError tells why a row was not imported.
+type ImportError struct {
+	Row    int
+	Id     uuid.UUID
+	Field  string
+	Reason string
+}
+
+// ImportResult tells what an import did, or would do on a dry run.
+type ImportResult struct {
+	Imported   int
+	Duplicates int
+	Failed     int
+	Errors     []ImportError
+}
+
+// ImportTasks stores the valid rows as tasks of the caller, keeping their
+// ids and timestamps. Rows whose id is already taken, by a previous row or a
+// task the caller can see, are skipped as duplicates. Subtasks fail when
+// their parent is neither imported nor a task of the caller, or when their
+// parents make a cycle. Rows whose id is taken by a task the caller can't see
+// get a new id instead, so an import doesn't tell whether it exists. Nothing
+// is stored on a dry run, but the result is the same. The whole import fails
+// when it exceeds the task quota of the tenant.
+func (tls *TodoListService) ImportTasks(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportResult, error) {
+	ctx, span := tracing.Start(ctx, "TodoListService.ImportTasks")
+	defer span.End()
+
+	result := &ImportResult{}
+	owner := identity.OwnerFromContext(ctx)
+	tenant := identity.TenantFromContext(ctx)
+	rowByID := make(map[uuid.UUID]int, len(rows))
+	tasks := make([]entity.Task, 0, len(rows))
+	for _, row := range rows {
+		task := row.Task
+		task.Owner = owner
+		task.Tenant = tenant
+
+		if err := task.Restore(); err != nil {
+			var invariantErr *domain.InvariantError
+			if !errors.As(err, &invariantErr) {
+				span.RecordError(err)
+				return nil, err
+			}
+			result.Failed++
+			result.Errors = append(result.Errors, ImportError{Row: row.Row, Id: row.Task.Id, Field: invariantErr.Field, Reason: invariantErr.Reason})
+			continue
+		}
+		if _, ok := rowByID[task.Id]; ok {
+			result.Duplicates++
+			result.Errors = append(result.Errors, ImportError{Row: row.Row, Id: task.Id, Field: "id", Reason: "is duplicated in the import"})
+			continue
+		}
+
+		rowByID[task.Id] = row.Row
+		tasks = append(tasks, task)
+	}
+
+	// the dry run finds the stored duplicates, so the quota only counts the
+	// tasks really added
+	duplicates, hidden, err := tls.repository.ImportTasks(ctx, tasks, true)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	for {
+		plan, err := tls.planImport(ctx, tasks, rowByID, duplicates, hidden)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+
+		release, err := tls.reserveQuota(ctx, len(plan.tasks))
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		var raced, racedHidden []uuid.UUID
+		if !dryRun {
+			raced, racedHidden, err = tls.repository.ImportTasks(ctx, plan.tasks, false)
+		}
+		release()
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+
+		// ids taken since the dry run: nothing was stored, so the import is
+		// planned again with them, giving new ids to the whole batch
+		if len(raced) > 0 || len(racedHidden) > 0 {
+			known := len(duplicates) + len(hidden)
+			duplicates = appendNew(duplicates, sourceIDsOf(plan.sourceIDs, raced))
+			hidden = appendNew(hidden, sourceIDsOf(plan.sourceIDs, racedHidden))
+			if len(duplicates)+len(hidden) == known {
+				err = errors.New("import keeps conflicting with stored tasks")
+				span.RecordError(err)
+				return nil, err
+			}
+			continue
+		}
+
+		result.Imported = len(plan.tasks)
+		result.Duplicates += plan.duplicates
+		result.Failed += plan.failed
+		result.Errors = append(result.Errors, plan.errors...)
+		if !dryRun {
+			for i := range plan.tasks {
+				tls.publish(ctx, events.TaskCreated, plan.tasks[i].Id, plan.tasks[i].Owner, &plan.tasks[i])
+			}
+		}
+		return result, nil
+	}
+}
+
+// importPlan holds the tasks an import stores, and why the others are left
+// out.
+type importPlan struct {
+	tasks []entity.Task
+	// sourceIDs maps the tasks given a new id to the id of their row.
+	sourceIDs  map[uuid.UUID]uuid.UUID
+	duplicates int
+	failed     int
+	errors     []ImportError
+}
+
+// planImport leaves out the duplicates and the subtasks without a valid
+// parent, and gives new ids to the tasks whose id is hidden. tasks isn't
+// changed.
+func (tls *TodoListService) planImport(ctx context.Context, tasks []entity.Task, rowByID map[uuid.UUID]int, duplicates []uuid.UUID, hidden []uuid.UUID) (*importPlan, error) {
+	plan := &importPlan{}
+	for _, id := range duplicates {
+		plan.duplicates++
+		plan.errors = append(plan.errors, ImportError{Row: rowByID[id], Id: id, Field: "id", Reason: "already exists"})
+	}
+
+	tasks = slices.Clone(withoutDuplicates(tasks, duplicates))
+	plan.sourceIDs = withNewIDs(tasks, hidden)
+	rowOf := func(id uuid.UUID) int {
+		return rowByID[sourceID(plan.sourceIDs, id)]
+	}
+
+	stored := map[uuid.UUID]*entity.Task{}
+	tasks, orphans, err := tls.withParents(ctx, tasks, stored)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range orphans {
+		plan.failed++
+		plan.errors = append(plan.errors, ImportError{Row: rowOf(task.Id), Id: sourceID(plan.sourceIDs, task.Id), Field: "parent_id", Reason: "doesn't exist"})
+	}
+	tasks, cyclic, err := tls.withoutCycles(ctx, tasks, stored)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range cyclic {
+		plan.failed++
+		plan.errors = append(plan.errors, ImportError{Row: rowOf(task.Id), Id: sourceID(plan.sourceIDs, task.Id), Field: "parent_id", Reason: "makes a cycle"})
+	}
+
+	plan.tasks = tasks
+	return plan, nil
+}
+
+func withoutDuplicates(tasks []entity.Task, duplicates []uuid.UUID) []entity.Task {
+	if len(duplicates) == 0 {
+		return tasks
+	}
+
+	skip := make(map[uuid.UUID]bool, len(duplicates))
+	for _, id := range duplicates {
+		skip[id] = true
+	}
+	kept := make([]entity.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if !skip[task.Id] {
+			kept = append(kept, task)
+		}
+	}
+	return kept
+}
+
+// withNewIDs gives new ids to the tasks whose id is in taken, and moves their
+// subtasks with them. It returns the id each changed task had before.
+func withNewIDs(tasks []entity.Task, taken []uuid.UUID) map[uuid.UUID]uuid.UUID {
+	if len(taken) == 0 {
+		return nil
+	}
+
+	newIDs := make(map[uuid.UUID]uuid.UUID, len(taken))
+	for _, id := range taken {
+		newIDs[id] = uuid.New()
+	}
+	sourceIDs := make(map[uuid.UUID]uuid.UUID, len(taken))
+	for i := range tasks {
+		task := &tasks[i]
+		if id, ok := newIDs[task.Id]; ok {
+			sourceIDs[id] = task.Id
+			task.Id = id
+		}
+		if task.ParentId != nil {
+			if id, ok := newIDs[*task.ParentId]; ok {
+				task.ParentId = &id
+			}
+		}
+	}
+	return sourceIDs
+}
+
+// sourceID returns the id a task had in its row.
+func sourceID(sourceIDs map[uuid.UUID]uuid.UUID, id uuid.UUID) uuid.UUID {
+	if source, ok := sourceIDs[id]; ok {
+		return source
+	}
+	return id
+}
+
+func sourceIDsOf(sourceIDs map[uuid.UUID]uuid.UUID, ids []uuid.UUID) []uuid.UUID {
+	sources := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		sources = append(sources, sourceID(sourceIDs, id))
+	}
+	return sources
+}
+
+// appendNew appends the ids not in ids yet.
+func appendNew(ids []uuid.UUID, more []uuid.UUID) []uuid.UUID {
+	for _, id := range more {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// withParents splits the subtasks whose parent is neither imported with them
+// nor a stored task of the caller from the other tasks. The subtasks of a
+// split task are split too.
+func (tls *TodoListService) withParents(ctx context.Context, tasks []entity.Task, stored map[uuid.UUID]*entity.Task) (kept []entity.Task, orphans []entity.Task, err error) {
+	kept = tasks
+	for {
+		imported := make(map[uuid.UUID]bool, len(kept))
+		for _, task := range kept {
+			imported[task.Id] = true
+		}
+
+		next := make([]entity.Task, 0, len(kept))
+		for _, task := range kept {
+			found := task.ParentId == nil || imported[*task.ParentId]
+			if !found {
+				parent, err := tls.ownTask(ctx, *task.ParentId, stored)
+				if err != nil {
+					return nil, nil, err
+				}
+				found = parent != nil
+			}
+
+			if found {
+				next = append(next, task)
+			} else {
+				orphans = append(orphans, task)
+			}
+		}
+
+		if len(next) == len(kept) {
+			return kept, orphans, nil
+		}
+		kept = next
+	}
+}
+
+// withoutCycles splits the tasks whose parents lead back to a task already
+// met, because they are in a cycle or under one, from the other tasks.
+// Parents are looked up in the import first, then in the stored tasks of the
+// caller.
+func (tls *TodoListService) withoutCycles(ctx context.Context, tasks []entity.Task, stored map[uuid.UUID]*entity.Task) (kept []entity.Task, cyclic []entity.Task, err error) {
+	imported := make(map[uuid.UUID]*uuid.UUID, len(tasks))
+	for _, task := range tasks {
+		imported[task.Id] = task.ParentId
+	}
+	parentOf := func(id uuid.UUID) (*uuid.UUID, error) {
+		if parent, ok := imported[id]; ok {
+			return parent, nil
+		}
+		task, err := tls.ownTask(ctx, id, stored)
+		if err != nil || task == nil {
+			return nil, err
+		}
+		return task.ParentId, nil
+	}
+
+	kept = make([]entity.Task, 0, len(tasks))
+	for _, task := range tasks {
+		met := map[uuid.UUID]bool{task.Id: true}
+		loops := false
+		for parent := task.ParentId; parent != nil; {
+			if met[*parent] {
+				loops = true
+				break
+			}
+			met[*parent] = true
+			if parent, err = parentOf(*parent); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if loops {
+			cyclic = append(cyclic, task)
+		} else {
+			kept = append(kept, task)
+		}
+	}
+	return kept, cyclic, nil
+}
+
+// ownTask returns the stored task of the caller with the id, nil when there
+// is none. Lookups are kept in stored.
+func (tls *TodoListService) ownTask(ctx context.Context, id uuid.UUID, stored map[uuid.UUID]*entity.Task) (*entity.Task, error) {
+	if task, ok := stored[id]; ok {
+		return task, nil
+	}
+
+	task, err := tls.repository.GetTaskByID(ctx, id)
+	if err != nil && !errors.Is(err, domain.ErrTaskNotFound) {
+		return nil, err
+	}
+	if task != nil && task.Owner != identity.OwnerFromContext(ctx) {
+		task = nil
+	}
+	stored[id] = task
+	return task, nil
+}
+
+// UpsertResult tells what an upsert did.
+type UpsertResult struct {
+	Created int
+	Updated int
+	Failed  int
+	Errors  []ImportError
+}
+
+// UpsertTasks replaces the tasks of the rows the caller can edit and imports
+// the others like ImportTasks. Replaced tasks take the title, description,
+// completion, tags and due date of their row, and its priority when it has
+// one.
+func (tls *TodoListService) UpsertTasks(ctx context.Context, rows []ImportRow) (*UpsertResult, error) {
+	ctx, span := tracing.Start(ctx, "TodoListService.UpsertTasks")
+	defer span.End()
+
+	ctx, err := tls.authorize(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	result := &UpsertResult{}
+	var creates, updates []ImportRow
+	for _, row := range rows {
+		_, err := tls.getTask(ctx, row.Task.Id, entity.RoleEditor)
+		switch {
+		case errors.Is(err, domain.ErrTaskNotFound):
+			creates = append(creates, row)
+		case errors.Is(err, domain.ErrForbidden):
+			result.Failed++
+			result.Errors = append(result.Errors, ImportError{Row: row.Row, Id: row.Task.Id, Field: "id", Reason: "can't be changed by the caller"})
+		case err != nil:
+			span.RecordError(err)
+			return nil, err
+		default:
+			updates = append(updates, row)
+		}
+	}
+
+	imported, err := tls.ImportTasks(ctx, creates, false)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	result.Created = imported.Imported
+	result.Failed += imported.Failed + imported.Duplicates
+	result.Errors = append(result.Errors, imported.Errors...)
+
+	for _, row := range updates {
+		err := tls.replaceTask(ctx, row.Task)
+		var invariantErr *domain.InvariantError
+		switch {
+		case errors.As(err, &invariantErr):
+			result.Failed++
+			result.Errors = append(result.Errors, ImportError{Row: row.Row, Id: row.Task.Id, Field: invariantErr.Field, Reason: invariantErr.Reason})
+		case err != nil:
+			span.RecordError(err)
+			return nil, err
+		default:
+			result.Updated++
+		}
+	}
+
+	slices.SortStableFunc(result.Errors, func(a, b ImportError) int {
+		return a.Row - b.Row
+	})
+
+	return result, nil
+}
+
+func (tls *TodoListService) replaceTask(ctx context.Context, replacement entity.Task) error {
+	task, err := tls.getTask(ctx, replacement.Id, entity.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	priority := task.Priority
+	if replacement.Priority != "" {
+		priority = replacement.Priority
+	}
+	if err := task.Classify(priority, replacement.Tags); err != nil {
+		return err
+	}
+	if err := task.Update(replacement.Title, replacement.Description, replacement.IsCompleted); err != nil {
+		return err
+	}
+	task.Reschedule(replacement.DueAt)
+
+	updated, err := tls.repository.UpdateTask(ctx, task)
+	if err != nil {
+		return err
+	}
+	tls.publish(ctx, events.TaskUpdated, task.Id, task.Owner, updated)
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/infrastructure"
+	"github.com/manuelbeos/code-branch-todo-test/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTodoListService_ImportTasks(t *testing.T) {
+	asserts := assert.New(t)
+	existing := entity.Task{Id: uuid.New(), Title: "Existing", Owner: "bob"}
+	mine := entity.Task{Id: uuid.New(), Title: "Mine", Owner: "alice"}
+	service := newSharingService(existing, mine)
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	imported := entity.Task{Id: uuid.New(), Title: "Imported", Tags: []string{"Work"}, CreatedAt: created, UpdatedAt: created.Add(time.Hour)}
+	rows := []ImportRow{
+		{Row: 1, Task: imported},
+		{Row: 2, Task: entity.Task{Title: " "}},
+		{Row: 3, Task: entity.Task{Id: imported.Id, Title: "Same id"}},
+		{Row: 4, Task: entity.Task{Id: existing.Id, Title: "Existing again"}},
+		{Row: 5, Task: entity.Task{Id: mine.Id, Title: "Mine again"}},
+	}
+
+	// a dry run reports the same without storing anything
+	result, err := service.ImportTasks(as("alice"), rows, true)
+	asserts.Nil(err)
+	asserts.Equal(2, result.Imported)
+	asserts.Equal(2, result.Duplicates)
+	asserts.Equal(1, result.Failed)
+	_, err = service.GetTaskByID(as("alice"), imported.Id)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+
+	result, err = service.ImportTasks(as("alice"), rows, false)
+	asserts.Nil(err)
+	asserts.Equal(2, result.Imported)
+	// the id of the task of bob is replaced rather than reported
+	asserts.ElementsMatch([]ImportError{
+		{Row: 2, Field: "title", Reason: "is required"},
+		{Row: 3, Id: imported.Id, Field: "id", Reason: "is duplicated in the import"},
+		{Row: 5, Id: mine.Id, Field: "id", Reason: "already exists"},
+	}, result.Errors)
+
+	task, err := service.GetTaskByID(as("alice"), imported.Id)
+	asserts.Nil(err)
+	asserts.Equal("alice", task.Owner)
+	asserts.Equal(created, task.CreatedAt)
+	asserts.Equal(created.Add(time.Hour), task.UpdatedAt)
+	asserts.Equal([]string{"work"}, task.Tags)
+	asserts.Equal(entity.PriorityNormal, task.Priority)
+
+	// the existing task of bob is untouched
+	task, err = service.GetTaskByID(as("bob"), existing.Id)
+	asserts.Nil(err)
+	asserts.Equal("Existing", task.Title)
+
+	tasks, err := service.GetAllTasks(as("alice"))
+	asserts.Nil(err)
+	titles := map[string]uuid.UUID{}
+	for _, task := range tasks {
+		titles[task.Title] = task.Id
+	}
+	asserts.Contains(titles, "Existing again")
+	asserts.NotEqual(existing.Id, titles["Existing again"])
+}
+
+func TestTodoListService_ImportTasks_Hidden_Parent(t *testing.T) {
+	asserts := assert.New(t)
+	existing := entity.Task{Id: uuid.New(), Title: "Existing", Owner: "bob"}
+	service := newSharingService(existing)
+	rows := []ImportRow{
+		{Row: 1, Task: entity.Task{Id: existing.Id, Title: "Parent"}},
+		{Row: 2, Task: entity.Task{Id: uuid.New(), Title: "Child", ParentId: &existing.Id}},
+	}
+
+	result, err := service.ImportTasks(as("alice"), rows, false)
+
+	asserts.Nil(err)
+	asserts.Equal(2, result.Imported)
+	asserts.Empty(result.Errors)
+	child, err := service.GetTaskByID(as("alice"), rows[1].Task.Id)
+	asserts.Nil(err)
+	asserts.NotEqual(existing.Id, *child.ParentId)
+	parent, err := service.GetTaskByID(as("alice"), *child.ParentId)
+	asserts.Nil(err)
+	asserts.Equal("Parent", parent.Title)
+}
+
+func TestTodoListService_ImportTasks_Raced_Hidden_Parent(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := as("alice")
+	parent := entity.Task{Id: uuid.New(), Title: "Parent"}
+	child := entity.Task{Id: uuid.New(), Title: "Child", ParentId: &parent.Id}
+	rows := []ImportRow{{Row: 1, Task: parent}, {Row: 2, Task: child}}
+	mockRepository := mocks.NewTodoListRepository(t)
+	mockRepository.On("ImportTasks", ctx, mock.Anything, true).Return(nil, nil, nil).Once()
+	// the id of the parent is taken by another user after the dry run
+	mockRepository.On("ImportTasks", ctx, mock.Anything, false).Return(nil, []uuid.UUID{parent.Id}, nil).Once()
+	var stored []entity.Task
+	mockRepository.On("ImportTasks", ctx, mock.Anything, false).Run(func(args mock.Arguments) {
+		stored = args.Get(1).([]entity.Task)
+	}).Return(nil, nil, nil).Once()
+	service := NewTodoListService(mockRepository)
+
+	result, err := service.ImportTasks(ctx, rows, false)
+
+	asserts.Nil(err)
+	asserts.Equal(2, result.Imported)
+	asserts.Empty(result.Errors)
+	asserts.Len(stored, 2)
+	asserts.NotEqual(parent.Id, stored[0].Id)
+	asserts.Equal(child.Id, stored[1].Id)
+	asserts.Equal(&stored[0].Id, stored[1].ParentId)
+}
+
+func TestTodoListService_ImportTasks_Subtasks(t *testing.T) {
+	asserts := assert.New(t)
+	mine := entity.Task{Id: uuid.New(), Title: "Mine", Owner: "alice"}
+	other := entity.Task{Id: uuid.New(), Title: "Other", Owner: "bob"}
+	service := newSharingService(mine, other)
+	parent := entity.Task{Id: uuid.New(), Title: "Parent"}
+	broken := entity.Task{Id: uuid.New(), Title: " "}
+	orphan := entity.Task{Id: uuid.New(), Title: "Orphan", ParentId: &other.Id}
+	rows := []ImportRow{
+		{Row: 1, Task: entity.Task{Id: uuid.New(), Title: "Child", ParentId: &parent.Id}},
+		{Row: 2, Task: parent},
+		{Row: 3, Task: entity.Task{Id: uuid.New(), Title: "Under mine", ParentId: &mine.Id}},
+		{Row: 4, Task: orphan},
+		{Row: 5, Task: entity.Task{Id: uuid.New(), Title: "Under orphan", ParentId: &orphan.Id}},
+		{Row: 6, Task: broken},
+		{Row: 7, Task: entity.Task{Id: uuid.New(), Title: "Under broken", ParentId: &broken.Id}},
+	}
+
+	result, err := service.ImportTasks(as("alice"), rows, false)
+
+	asserts.Nil(err)
+	asserts.Equal(3, result.Imported)
+	asserts.Equal(4, result.Failed)
+	asserts.ElementsMatch([]ImportError{
+		{Row: 4, Id: orphan.Id, Field: "parent_id", Reason: "doesn't exist"},
+		{Row: 5, Id: rows[4].Task.Id, Field: "parent_id", Reason: "doesn't exist"},
+		{Row: 6, Id: broken.Id, Field: "title", Reason: "is required"},
+		{Row: 7, Id: rows[6].Task.Id, Field: "parent_id", Reason: "doesn't exist"},
+	}, result.Errors)
+
+	task, err := service.GetTaskByID(as("alice"), rows[0].Task.Id)
+	asserts.Nil(err)
+	asserts.Equal(&parent.Id, task.ParentId)
+}
+
+func TestTodoListService_ImportTasks_Cycles(t *testing.T) {
+	asserts := assert.New(t)
+	// a stored task under a task that is only imported now
+	returningID := uuid.New()
+	stored := entity.Task{Id: uuid.New(), Title: "Stored", Owner: "alice", ParentId: &returningID}
+	mine := entity.Task{Id: uuid.New(), Title: "Mine", Owner: "alice"}
+	first := entity.Task{Id: uuid.New(), Title: "First"}
+	second := entity.Task{Id: uuid.New(), Title: "Second", ParentId: &first.Id}
+	first.ParentId = &second.Id
+	returning := entity.Task{Id: returningID, Title: "Returning", ParentId: &stored.Id}
+	service := newSharingService(stored, mine)
+	rows := []ImportRow{
+		{Row: 1, Task: first},
+		{Row: 2, Task: second},
+		{Row: 3, Task: entity.Task{Id: uuid.New(), Title: "Under cycle", ParentId: &second.Id}},
+		{Row: 4, Task: returning},
+		{Row: 5, Task: entity.Task{Id: uuid.New(), Title: "Under mine", ParentId: &mine.Id}},
+	}
+
+	result, err := service.ImportTasks(as("alice"), rows, false)
+
+	asserts.Nil(err)
+	asserts.Equal(1, result.Imported)
+	asserts.ElementsMatch([]ImportError{
+		{Row: 1, Id: first.Id, Field: "parent_id", Reason: "makes a cycle"},
+		{Row: 2, Id: second.Id, Field: "parent_id", Reason: "makes a cycle"},
+		{Row: 3, Id: rows[2].Task.Id, Field: "parent_id", Reason: "makes a cycle"},
+		{Row: 4, Id: returning.Id, Field: "parent_id", Reason: "makes a cycle"},
+	}, result.Errors)
+}
+
+func TestTodoListService_ImportTasks_Quota(t *testing.T) {
+	asserts := assert.New(t)
+	service := NewTodoListService(
+		infrastructure.NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{}),
+		WithTaskQuota(func(string) int { return 2 }),
+	)
+	ctx := identity.WithTenant(context.Background(), "acme")
+	rows := []ImportRow{{Row: 1, Task: entity.Task{Title: "One"}}, {Row: 2, Task: entity.Task{Title: "Two"}}, {Row: 3, Task: entity.Task{Title: "Three"}}}
+
+	_, err := service.ImportTasks(ctx, rows, true)
+	asserts.ErrorIs(err, domain.ErrTaskQuotaExceeded)
+
+	result, err := service.ImportTasks(ctx, rows[:2], false)
+	asserts.Nil(err)
+	asserts.Equal(2, result.Imported)
+
+	count, err := service.repository.CountTasks(ctx)
+	asserts.Nil(err)
+	asserts.Equal(2, count)
+}
+
+func TestTodoListService_UpsertTasks(t *testing.T) {
+	asserts := assert.New(t)
+	due := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	mine := entity.Task{Id: uuid.New(), Title: "Mine", Owner: "alice", Priority: entity.PriorityHigh, Tags: []string{"home"}, DueAt: &due}
+	shared := entity.Task{Id: uuid.New(), Title: "Shared", Owner: "bob"}
+	hidden := entity.Task{Id: uuid.New(), Title: "Hidden", Owner: "bob"}
+	service := newSharingService(mine, shared, hidden)
+	_, err := service.ShareTask(as("bob"), shared.Id, "alice", entity.RoleViewer)
+	asserts.Nil(err)
+	created := entity.Task{Id: uuid.New(), Title: "Created"}
+	rows := []ImportRow{
+		{Row: 1, Task: entity.Task{Id: mine.Id, Title: "Mine renamed", IsCompleted: true}},
+		{Row: 2, Task: created},
+		{Row: 3, Task: entity.Task{Id: shared.Id, Title: "Shared renamed"}},
+		{Row: 4, Task: entity.Task{Id: hidden.Id, Title: "Hidden renamed"}},
+		{Row: 5, Task: entity.Task{Id: mine.Id, Title: " "}},
+	}
+
+	result, err := service.UpsertTasks(as("alice"), rows)
+
+	asserts.Nil(err)
+	// the hidden task of bob is created again under a new id
+	asserts.Equal(2, result.Created)
+	asserts.Equal(1, result.Updated)
+	asserts.Equal(2, result.Failed)
+	asserts.Equal([]ImportError{
+		{Row: 3, Id: shared.Id, Field: "id", Reason: "can't be changed by the caller"},
+		{Row: 5, Id: mine.Id, Field: "title", Reason: "is required"},
+	}, result.Errors)
+
+	// the priority is kept when the row has none, tags and due date are replaced
+	task, err := service.GetTaskByID(as("alice"), mine.Id)
+	asserts.Nil(err)
+	asserts.Equal("Mine renamed", task.Title)
+	asserts.True(task.IsCompleted)
+	asserts.Equal(entity.PriorityHigh, task.Priority)
+	asserts.Empty(task.Tags)
+	asserts.Nil(task.DueAt)
+
+	task, err = service.GetTaskByID(as("alice"), created.Id)
+	asserts.Nil(err)
+	asserts.Equal("alice", task.Owner)
+
+	task, err = service.GetTaskByID(as("bob"), shared.Id)
+	asserts.Nil(err)
+	asserts.Equal("Shared", task.Title)
+}
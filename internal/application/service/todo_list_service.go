@@ -2,51 +2,438 @@ package service
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 
 	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/events"
 	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
 	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+	"github.com/manuelbeos/code-branch-todo-test/internal/tracing"
 )
 
 type TodoListService struct {
 	repository repository.TodoListRepository
+	grants     repository.GrantRepository
+	// subtaskFinder is nil when the repository can't find subtasks.
+	subtaskFinder repository.SubtaskFinder
+	eventBus      *events.Bus
+	taskQuota     func(tenant string) int
+	// quotaReserved holds an *atomic.Int64 per tenant counting the tasks
+	// being created, which the quota counts on top of the stored ones.
+	quotaReserved sync.Map
 }
 
-func NewTodoListService(repository repository.TodoListRepository) *TodoListService {
-	return &TodoListService{repository: repository}
+type Option func(*TodoListService)
+
+// WithEventBus publishes every successful change to the given bus.
+func WithEventBus(bus *events.Bus) Option {
+	return func(tls *TodoListService) {
+		tls.eventBus = bus
+	}
+}
+
+// WithGrantRepository enables sharing tasks with other users.
+func WithGrantRepository(grants repository.GrantRepository) Option {
+	return func(tls *TodoListService) {
+		tls.grants = grants
+	}
+}
+
+// WithSubtaskFinder lets DeleteTask find the subtasks of a task to delete
+// them with it. Without it subtasks are left in place.
+func WithSubtaskFinder(finder repository.SubtaskFinder) Option {
+	return func(tls *TodoListService) {
+		tls.subtaskFinder = finder
+	}
+}
+
+// WithTaskQuota limits the number of tasks of each tenant, limit returns 0
+// for tenants without a quota.
+func WithTaskQuota(limit func(tenant string) int) Option {
+	return func(tls *TodoListService) {
+		tls.taskQuota = limit
+	}
 }
 
-func (tls *TodoListService) CreateTask(ctx context.Context, title string, description string) (*entity.Task, error) {
-	task := entity.NewTask(title, description)
+func NewTodoListService(repository repository.TodoListRepository, opts ...Option) *TodoListService {
+	tls := &TodoListService{repository: repository}
+	for _, opt := range opts {
+		opt(tls)
+	}
 
-	return tls.repository.CreateTask(ctx, task)
+	return tls
+}
+
+// CreateTask creates a task from the title, description, priority, tags and
+// due date of draft, the other fields are set by the service.
+func (tls *TodoListService) CreateTask(ctx context.Context, draft entity.Task) (*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TodoListService.CreateTask")
+	defer span.End()
+
+	release, err := tls.reserveQuota(ctx, 1)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer release()
+
+	task, err := entity.NewTask(draft.Title, draft.Description)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	err = task.Classify(draft.Priority, draft.Tags)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	task.Reschedule(draft.DueAt)
+	task.Owner = identity.OwnerFromContext(ctx)
+	task.Tenant = identity.TenantFromContext(ctx)
+
+	created, err := tls.repository.CreateTask(ctx, task)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	tls.publish(ctx, events.TaskCreated, task.Id, task.Owner, created)
+
+	return created, nil
 }
 
 func (tls *TodoListService) GetAllTasks(ctx context.Context) ([]*entity.Task, error) {
-	return tls.repository.GetAllTasks(ctx)
+	ctx, span := tracing.Start(ctx, "TodoListService.GetAllTasks")
+	defer span.End()
+
+	ctx, err := tls.authorize(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	tasks, err := tls.repository.GetAllTasks(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	visible := make([]*entity.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task != nil && identity.CanAccess(ctx, task) {
+			visible = append(visible, task)
+		}
+	}
+	if len(visible) == 0 && len(tasks) > 0 {
+		return nil, domain.ErrThereAreNoTasks
+	}
+
+	return visible, nil
 }
 
 func (tls *TodoListService) GetTaskByID(ctx context.Context, id uuid.UUID) (*entity.Task, error) {
-	return tls.repository.GetTaskByID(ctx, id)
+	ctx, span := tracing.Start(ctx, "TodoListService.GetTaskByID")
+	defer span.End()
+
+	ctx, err := tls.authorize(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	task, err := tls.getTask(ctx, id, entity.RoleViewer)
+	span.RecordError(err)
+
+	return task, err
 }
 
 func (tls *TodoListService) UpdateTask(ctx context.Context, taskToUpdate entity.Task) (*entity.Task, error) {
-	task, err := tls.repository.GetTaskByID(ctx, taskToUpdate.Id)
+	ctx, span := tracing.Start(ctx, "TodoListService.UpdateTask")
+	defer span.End()
 
+	ctx, err := tls.authorize(ctx)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
-	task.Update(taskToUpdate.Title, taskToUpdate.Description, taskToUpdate.IsCompleted)
+	task, err := tls.getTask(ctx, taskToUpdate.Id, entity.RoleEditor)
+
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// an empty priority, nil tags or a nil due date keep the current ones
+	priority, tags := task.Priority, task.Tags
+	if taskToUpdate.Priority != "" {
+		priority = taskToUpdate.Priority
+	}
+	if taskToUpdate.Tags != nil {
+		tags = taskToUpdate.Tags
+	}
+	err = task.Classify(priority, tags)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
 
-	return tls.repository.UpdateTask(ctx, task)
+	err = task.Update(taskToUpdate.Title, taskToUpdate.Description, taskToUpdate.IsCompleted)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if taskToUpdate.DueAt != nil {
+		task.Reschedule(taskToUpdate.DueAt)
+	}
+
+	updated, err := tls.repository.UpdateTask(ctx, task)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	tls.publish(ctx, events.TaskUpdated, task.Id, task.Owner, updated)
+
+	return updated, nil
+}
+
+// CompleteTask marks a task as done, it needs the editor role like updates.
+func (tls *TodoListService) CompleteTask(ctx context.Context, id uuid.UUID) (*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TodoListService.CompleteTask")
+	defer span.End()
+
+	task, err := tls.changeTask(ctx, id, (*entity.Task).Complete)
+	span.RecordError(err)
+
+	return task, err
+}
+
+// ReopenTask puts a completed task back to pending.
+func (tls *TodoListService) ReopenTask(ctx context.Context, id uuid.UUID) (*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TodoListService.ReopenTask")
+	defer span.End()
+
+	task, err := tls.changeTask(ctx, id, (*entity.Task).Reopen)
+	span.RecordError(err)
+
+	return task, err
+}
+
+// changeTask applies change to the task the caller can edit and stores it.
+// Tasks the change leaves as they were are returned without being stored.
+func (tls *TodoListService) changeTask(ctx context.Context, id uuid.UUID, change func(*entity.Task)) (*entity.Task, error) {
+	ctx, err := tls.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := tls.getTask(ctx, id, entity.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := task.IsCompleted
+	change(task)
+	if task.IsCompleted == completed {
+		return task, nil
+	}
+
+	updated, err := tls.repository.UpdateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	tls.publish(ctx, events.TaskUpdated, task.Id, task.Owner, updated)
+
+	return updated, nil
 }
 
 func (tls *TodoListService) DeleteTask(ctx context.Context, id uuid.UUID) error {
-	_, err := tls.repository.GetTaskByID(ctx, id)
+	ctx, span := tracing.Start(ctx, "TodoListService.DeleteTask")
+	defer span.End()
+
+	ctx, err := tls.authorize(ctx)
 	if err != nil {
+		span.RecordError(err)
 		return err
 	}
 
-	return tls.repository.DeleteTask(ctx, id)
+	task, err := tls.getTask(ctx, id, entity.RoleOwner)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	subtasks, err := tls.subtasks(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	// the deepest subtasks go first, a failure never leaves a subtask
+	// without its parent
+	ids := append(subtasks, id)
+	for i := len(ids) - 1; i >= 0; i-- {
+		err = tls.repository.DeleteTask(ctx, ids[i])
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+
+		tls.publish(ctx, events.TaskDeleted, ids[i], task.Owner, nil)
+		tls.deleteTaskGrants(ctx, task.Owner, ids[i])
+	}
+
+	return nil
+}
+
+// subtasks returns the ids of the tasks under the task id, at any depth,
+// every task after its parent. It finds none without a subtask finder.
+func (tls *TodoListService) subtasks(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	if tls.subtaskFinder == nil {
+		return nil, nil
+	}
+
+	var subtasks []uuid.UUID
+	met := map[uuid.UUID]bool{id: true}
+	for next := []uuid.UUID{id}; len(next) > 0; next = next[1:] {
+		children, err := tls.subtaskFinder.GetSubtaskIDs(ctx, next[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			if !met[child] {
+				met[child] = true
+				subtasks = append(subtasks, child)
+				next = append(next, child)
+			}
+		}
+	}
+	return subtasks, nil
+}
+
+// reserveQuota checks the tenant can hold n more tasks and keeps them
+// reserved until release is called, after the tasks are stored. Creations in
+// the same tenant don't wait for each other: tasks stored while their
+// reservation is still held count twice, which may turn down a creation the
+// quota had room for but never lets the tenant go over it.
+func (tls *TodoListService) reserveQuota(ctx context.Context, n int) (release func(), err error) {
+	if tls.taskQuota == nil {
+		return func() {}, nil
+	}
+
+	tenant := identity.TenantFromContext(ctx)
+	limit := tls.taskQuota(tenant)
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	counter, _ := tls.quotaReserved.LoadOrStore(tenant, &atomic.Int64{})
+	reserved := counter.(*atomic.Int64)
+	// reserving before counting, tasks stored after the count belong to
+	// reservations already included in the total
+	total := reserved.Add(int64(n))
+	release = func() { reserved.Add(-int64(n)) }
+
+	count, err := tls.repository.CountTasks(ctx)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if int64(count)+total > int64(limit) {
+		release()
+		return nil, domain.ErrTaskQuotaExceeded
+	}
+
+	return release, nil
+}
+
+// authorize adds what other users shared with the caller to ctx, so the
+// repositories include those tasks. ctx is returned unchanged when sharing
+// is disabled or the request is anonymous.
+func (tls *TodoListService) authorize(ctx context.Context) (context.Context, error) {
+	caller := identity.OwnerFromContext(ctx)
+	if tls.grants == nil || caller == "" {
+		return ctx, nil
+	}
+
+	grants, err := tls.grants.GetGrantsByGrantee(ctx, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := identity.Shares{Lists: map[string]entity.Role{}, Tasks: map[uuid.UUID]entity.Role{}}
+	for _, grant := range grants {
+		if grant.TaskId == nil {
+			shares.Lists[grant.Owner] = grant.Role
+		} else {
+			shares.Tasks[*grant.TaskId] = grant.Role
+		}
+	}
+
+	return identity.WithShares(ctx, shares), nil
+}
+
+// getTask returns the task when the caller holds at least role on it, ctx
+// must come from authorize. Tasks the caller can't see are reported as not
+// found and visible ones with a weaker role as forbidden, so the ids of other
+// users' tasks never leak.
+func (tls *TodoListService) getTask(ctx context.Context, id uuid.UUID, role entity.Role) (*entity.Task, error) {
+	task, err := tls.repository.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, domain.ErrTaskNotFound
+	}
+
+	granted, ok := identity.RoleFor(ctx, task.Id, task.Owner)
+	if !ok {
+		return nil, domain.ErrTaskNotFound
+	}
+	if !granted.AtLeast(role) {
+		return nil, domain.ErrForbidden
+	}
+
+	return task, nil
+}
+
+func (tls *TodoListService) publish(ctx context.Context, eventType events.EventType, id uuid.UUID, owner string, task *entity.Task) {
+	if tls.eventBus == nil {
+		return
+	}
+
+	tls.eventBus.Publish(events.TaskEvent{
+		Type:     eventType,
+		TaskID:   id,
+		Task:     task,
+		Tenant:   identity.TenantFromContext(ctx),
+		Audience: tls.audience(ctx, owner, id),
+		Origin:   events.OriginFromContext(ctx),
+	})
+}
+
+// audience lists the users allowed to see a task: its owner and everybody it
+// or its list is shared with.
+func (tls *TodoListService) audience(ctx context.Context, owner string, id uuid.UUID) []string {
+	audience := []string{owner}
+	if tls.grants == nil || owner == "" {
+		return audience
+	}
+
+	grants, err := tls.grants.GetGrantsByOwner(ctx, owner)
+	if err != nil {
+		return audience
+	}
+	for _, grant := range grants {
+		if grant.TaskId == nil || *grant.TaskId == id {
+			audience = append(audience, grant.Grantee)
+		}
+	}
+
+	return audience
 }
@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+	"github.com/manuelbeos/code-branch-todo-test/internal/infrastructure"
 	"github.com/manuelbeos/code-branch-todo-test/internal/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -27,7 +32,7 @@ func TestTodoListService_CreateTask_Success(t *testing.T) {
 	mockRepository.On("CreateTask", ctx, mock.Anything).Return(nil, nil)
 	service := NewTodoListService(mockRepository)
 
-	_, err := service.CreateTask(ctx, "title", "description")
+	_, err := service.CreateTask(ctx, entity.Task{Title: "title", Description: "description"})
 
 	asserts.Nil(err)
 }
@@ -40,7 +45,7 @@ func TestTodoListService_CreateTask_Error(t *testing.T) {
 	mockRepository.On("CreateTask", ctx, mock.Anything).Return(nil, mockError)
 	service := NewTodoListService(mockRepository)
 
-	_, err := service.CreateTask(ctx, "title", "description")
+	_, err := service.CreateTask(ctx, entity.Task{Title: "title", Description: "description"})
 
 	asserts.ErrorIs(mockError, err)
 }
@@ -74,7 +79,7 @@ func TestTodoListService_GetTaskByID_Success(t *testing.T) {
 	asserts := assert.New(t)
 	mockRepository := mocks.NewTodoListRepository(t)
 	ctx := context.Background()
-	mockRepository.On("GetTaskByID", ctx, mock.Anything).Return(nil, nil)
+	mockRepository.On("GetTaskByID", ctx, mock.Anything).Return(&entity.Task{}, nil)
 	service := NewTodoListService(mockRepository)
 
 	_, err := service.GetTaskByID(ctx, uuid.New())
@@ -138,11 +143,26 @@ func TestTodoListService_UpdateTask_Error(t *testing.T) {
 	asserts.ErrorIs(mockError, err)
 }
 
+func TestTodoListService_CompleteTask_Unchanged(t *testing.T) {
+	asserts := assert.New(t)
+	mockRepository := mocks.NewTodoListRepository(t)
+	ctx := context.Background()
+	completedAt := time.Now().Add(-time.Hour)
+	task := &entity.Task{Id: uuid.New(), Title: "title", IsCompleted: true, CompletedAt: &completedAt}
+	mockRepository.On("GetTaskByID", ctx, task.Id).Return(task, nil)
+	service := NewTodoListService(mockRepository)
+
+	completed, err := service.CompleteTask(ctx, task.Id)
+
+	asserts.Nil(err)
+	asserts.Equal(&completedAt, completed.CompletedAt)
+}
+
 func TestTodoListService_DeleteTask_Success(t *testing.T) {
 	asserts := assert.New(t)
 	mockRepository := mocks.NewTodoListRepository(t)
 	ctx := context.Background()
-	mockRepository.On("GetTaskByID", ctx, mock.Anything).Return(nil, nil)
+	mockRepository.On("GetTaskByID", ctx, mock.Anything).Return(&entity.Task{}, nil)
 	mockRepository.On("DeleteTask", ctx, mock.Anything).Return(nil)
 	service := NewTodoListService(mockRepository)
 
@@ -169,7 +189,7 @@ func TestTodoListService_DeleteTask_Error(t *testing.T) {
 	mockRepository := mocks.NewTodoListRepository(t)
 	mockError := errors.New("mock error")
 	ctx := context.Background()
-	mockRepository.On("GetTaskByID", ctx, mock.Anything).Return(nil, nil)
+	mockRepository.On("GetTaskByID", ctx, mock.Anything).Return(&entity.Task{}, nil)
 	mockRepository.On("DeleteTask", ctx, mock.Anything).Return(mockError)
 	service := NewTodoListService(mockRepository)
 
@@ -177,3 +197,65 @@ func TestTodoListService_DeleteTask_Error(t *testing.T) {
 
 	asserts.ErrorIs(mockError, err)
 }
+
+func TestTodoListService_DeleteTask_Subtasks(t *testing.T) {
+	asserts := assert.New(t)
+	parent := entity.Task{Id: uuid.New(), Title: "Parent", Owner: "alice"}
+	child := entity.Task{Id: uuid.New(), Title: "Child", Owner: "alice", ParentId: &parent.Id}
+	grandchild := entity.Task{Id: uuid.New(), Title: "Grandchild", Owner: "alice", ParentId: &child.Id}
+	other := entity.Task{Id: uuid.New(), Title: "Other", Owner: "alice"}
+	memoryRepo := infrastructure.NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{
+		parent.Id:     parent,
+		child.Id:      child,
+		grandchild.Id: grandchild,
+		other.Id:      other,
+	})
+	service := NewTodoListService(memoryRepo, WithSubtaskFinder(memoryRepo.(repository.SubtaskFinder)))
+
+	err := service.DeleteTask(as("alice"), parent.Id)
+
+	asserts.Nil(err)
+	tasks, err := service.GetAllTasks(as("alice"))
+	asserts.Nil(err)
+	asserts.Len(tasks, 1)
+	asserts.Equal(other.Id, tasks[0].Id)
+}
+
+func TestTodoListService_CreateTask_Sets_Owner(t *testing.T) {
+	asserts := assert.New(t)
+	mockRepository := mocks.NewTodoListRepository(t)
+	ctx := identity.WithOwner(context.Background(), "alice")
+	mockRepository.On("CreateTask", ctx, mock.MatchedBy(func(task entity.Task) bool {
+		return task.Owner == "alice"
+	})).Return(&entity.Task{Owner: "alice"}, nil)
+	service := NewTodoListService(mockRepository)
+
+	task, err := service.CreateTask(ctx, entity.Task{Title: "title", Description: "description"})
+
+	asserts.Nil(err)
+	asserts.Equal("alice", task.Owner)
+}
+
+func TestTodoListService_GetTaskByID_Other_Owner(t *testing.T) {
+	asserts := assert.New(t)
+	mockRepository := mocks.NewTodoListRepository(t)
+	ctx := identity.WithOwner(context.Background(), "alice")
+	mockRepository.On("GetTaskByID", ctx, mock.Anything).Return(&entity.Task{Owner: "bob"}, nil)
+	service := NewTodoListService(mockRepository)
+
+	_, err := service.GetTaskByID(ctx, uuid.New())
+
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func TestTodoListService_GetTaskByID_Missing(t *testing.T) {
+	asserts := assert.New(t)
+	mockRepository := mocks.NewTodoListRepository(t)
+	ctx := context.Background()
+	mockRepository.On("GetTaskByID", ctx, mock.Anything).Return(nil, nil)
+	service := NewTodoListService(mockRepository)
+
+	_, err := service.GetTaskByID(ctx, uuid.New())
+
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+}
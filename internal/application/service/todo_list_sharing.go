@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/tracing"
+)
+
+// sharingCaller returns the caller allowed to share, sharing needs both an
+// authenticated caller and a grant repository.
+func (tls *TodoListService) sharingCaller(ctx context.Context) (string, error) {
+	caller := identity.OwnerFromContext(ctx)
+	if tls.grants == nil || caller == "" {
+		return "", domain.ErrForbidden
+	}
+	return caller, nil
+}
+
+func validGrant(caller string, grantee string, role entity.Role) error {
+	if !role.Valid() {
+		return domain.ErrInvalidRole
+	}
+	if grantee == "" || grantee == caller {
+		return domain.ErrInvalidGrant
+	}
+	return nil
+}
+
+// ShareTask gives grantee a role on a single task, only owners of the task
+// can share it.
+func (tls *TodoListService) ShareTask(ctx context.Context, id uuid.UUID, grantee string, role entity.Role) (*entity.Grant, error) {
+	ctx, span := tracing.Start(ctx, "TodoListService.ShareTask")
+	defer span.End()
+
+	grant, err := tls.shareTask(ctx, id, grantee, role)
+	span.RecordError(err)
+
+	return grant, err
+}
+
+func (tls *TodoListService) shareTask(ctx context.Context, id uuid.UUID, grantee string, role entity.Role) (*entity.Grant, error) {
+	caller, err := tls.sharingCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validGrant(caller, grantee, role); err != nil {
+		return nil, err
+	}
+
+	ctx, err = tls.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	task, err := tls.getTask(ctx, id, entity.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if grantee == task.Owner {
+		return nil, domain.ErrInvalidGrant
+	}
+
+	return tls.grants.CreateGrant(ctx, entity.NewGrant(task.Tenant, task.Owner, &task.Id, grantee, role, caller))
+}
+
+// ShareList gives grantee a role on every task of the caller, present and
+// future.
+func (tls *TodoListService) ShareList(ctx context.Context, grantee string, role entity.Role) (*entity.Grant, error) {
+	ctx, span := tracing.Start(ctx, "TodoListService.ShareList")
+	defer span.End()
+
+	caller, err := tls.sharingCaller(ctx)
+	if err == nil {
+		err = validGrant(caller, grantee, role)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	grant, err := tls.grants.CreateGrant(ctx, entity.NewGrant(identity.TenantFromContext(ctx), caller, nil, grantee, role, caller))
+	span.RecordError(err)
+
+	return grant, err
+}
+
+// GetTaskGrants lists who a task is shared with, its list grants included.
+func (tls *TodoListService) GetTaskGrants(ctx context.Context, id uuid.UUID) ([]*entity.Grant, error) {
+	ctx, span := tracing.Start(ctx, "TodoListService.GetTaskGrants")
+	defer span.End()
+
+	grants, err := tls.getTaskGrants(ctx, id)
+	span.RecordError(err)
+
+	return grants, err
+}
+
+func (tls *TodoListService) getTaskGrants(ctx context.Context, id uuid.UUID) ([]*entity.Grant, error) {
+	if _, err := tls.sharingCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, err := tls.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	task, err := tls.getTask(ctx, id, entity.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := tls.grants.GetGrantsByOwner(ctx, task.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	taskGrants := []*entity.Grant{}
+	for _, grant := range grants {
+		if grant.TaskId == nil || *grant.TaskId == task.Id {
+			taskGrants = append(taskGrants, grant)
+		}
+	}
+
+	return taskGrants, nil
+}
+
+// GetGrants lists what the caller shared and what was shared with it.
+func (tls *TodoListService) GetGrants(ctx context.Context) (given []*entity.Grant, received []*entity.Grant, err error) {
+	ctx, span := tracing.Start(ctx, "TodoListService.GetGrants")
+	defer span.End()
+
+	caller, err := tls.sharingCaller(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, nil, err
+	}
+
+	given, err = tls.grants.GetGrantsByOwner(ctx, caller)
+	if err != nil {
+		span.RecordError(err)
+		return nil, nil, err
+	}
+	received, err = tls.grants.GetGrantsByGrantee(ctx, caller)
+	if err != nil {
+		span.RecordError(err)
+		return nil, nil, err
+	}
+
+	return given, received, nil
+}
+
+// RevokeGrant removes a grant. The owner of the list, an owner of the shared
+// task and the grantee itself may revoke it, anybody else gets not found.
+func (tls *TodoListService) RevokeGrant(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "TodoListService.RevokeGrant")
+	defer span.End()
+
+	err := tls.revokeGrant(ctx, id)
+	span.RecordError(err)
+
+	return err
+}
+
+func (tls *TodoListService) revokeGrant(ctx context.Context, id uuid.UUID) error {
+	caller, err := tls.sharingCaller(ctx)
+	if err != nil {
+		return err
+	}
+
+	grant, err := tls.grants.GetGrantByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	allowed := caller == grant.Owner || caller == grant.Grantee
+	if !allowed && grant.TaskId != nil {
+		ctx, err = tls.authorize(ctx)
+		if err != nil {
+			return err
+		}
+		role, ok := identity.RoleFor(ctx, *grant.TaskId, grant.Owner)
+		allowed = ok && role.AtLeast(entity.RoleOwner)
+	}
+	if !allowed {
+		return domain.ErrGrantNotFound
+	}
+
+	return tls.grants.DeleteGrant(ctx, id)
+}
+
+// deleteTaskGrants drops the grants of a deleted task, failures only leave
+// grants pointing to a task that no longer exists.
+func (tls *TodoListService) deleteTaskGrants(ctx context.Context, owner string, id uuid.UUID) {
+	if tls.grants == nil || owner == "" {
+		return
+	}
+
+	grants, err := tls.grants.GetGrantsByOwner(ctx, owner)
+	if err != nil {
+		return
+	}
+	for _, grant := range grants {
+		if grant.TaskId != nil && *grant.TaskId == id {
+			_ = tls.grants.DeleteGrant(ctx, grant.Id)
+		}
+	}
+}
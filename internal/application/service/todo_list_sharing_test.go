@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/infrastructure"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSharingService(tasks ...entity.Task) *TodoListService {
+	memory := map[uuid.UUID]entity.Task{}
+	for _, task := range tasks {
+		memory[task.Id] = task
+	}
+
+	return NewTodoListService(
+		infrastructure.NewMemoryStorageTodoListRepository(memory),
+		WithGrantRepository(infrastructure.NewMemoryGrantRepository()),
+	)
+}
+
+func as(user string) context.Context {
+	return identity.WithOwner(context.Background(), user)
+}
+
+func TestTodoListService_Sharing_Task_Roles(t *testing.T) {
+	asserts := assert.New(t)
+	task := entity.Task{Id: uuid.New(), Title: "Alice", Owner: "alice"}
+	service := newSharingService(task)
+	update := entity.Task{Id: task.Id, Title: "Renamed"}
+
+	// without a grant the task doesn't exist for bob
+	_, err := service.GetTaskByID(as("bob"), task.Id)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+	_, err = service.UpdateTask(as("bob"), update)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+	asserts.ErrorIs(service.DeleteTask(as("bob"), task.Id), domain.ErrTaskNotFound)
+	_, err = service.ShareTask(as("bob"), task.Id, "mallory", entity.RoleOwner)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+
+	_, err = service.ShareTask(as("alice"), task.Id, "bob", entity.RoleViewer)
+	asserts.Nil(err)
+
+	_, err = service.GetTaskByID(as("bob"), task.Id)
+	asserts.Nil(err)
+	_, err = service.UpdateTask(as("bob"), update)
+	asserts.ErrorIs(err, domain.ErrForbidden)
+
+	// sharing again replaces the role
+	grant, err := service.ShareTask(as("alice"), task.Id, "bob", entity.RoleEditor)
+	asserts.Nil(err)
+
+	updated, err := service.UpdateTask(as("bob"), update)
+	asserts.Nil(err)
+	asserts.Equal("alice", updated.Owner)
+	asserts.ErrorIs(service.DeleteTask(as("bob"), task.Id), domain.ErrForbidden)
+	_, err = service.ShareTask(as("bob"), task.Id, "carol", entity.RoleViewer)
+	asserts.ErrorIs(err, domain.ErrForbidden)
+
+	grants, err := service.GetTaskGrants(as("alice"), task.Id)
+	asserts.Nil(err)
+	asserts.Len(grants, 1)
+	asserts.Equal(entity.RoleEditor, grants[0].Role)
+
+	asserts.ErrorIs(service.RevokeGrant(as("carol"), grant.Id), domain.ErrGrantNotFound)
+	asserts.Nil(service.RevokeGrant(as("alice"), grant.Id))
+
+	_, err = service.GetTaskByID(as("bob"), task.Id)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func TestTodoListService_Sharing_List(t *testing.T) {
+	asserts := assert.New(t)
+	first := entity.Task{Id: uuid.New(), Title: "First", Owner: "alice"}
+	second := entity.Task{Id: uuid.New(), Title: "Second", Owner: "alice"}
+	other := entity.Task{Id: uuid.New(), Title: "Other", Owner: "dave"}
+	service := newSharingService(first, second, other)
+
+	_, err := service.ShareList(as("alice"), "carol", entity.RoleOwner)
+	asserts.Nil(err)
+
+	tasks, err := service.GetAllTasks(as("carol"))
+	asserts.Nil(err)
+	asserts.Len(tasks, 2)
+
+	asserts.Nil(service.DeleteTask(as("carol"), first.Id))
+
+	given, received, err := service.GetGrants(as("carol"))
+	asserts.Nil(err)
+	asserts.Empty(given)
+	asserts.Len(received, 1)
+}
+
+func TestTodoListService_Sharing_Invalid(t *testing.T) {
+	asserts := assert.New(t)
+	task := entity.Task{Id: uuid.New(), Title: "Alice", Owner: "alice"}
+	service := newSharingService(task)
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		grantee     string
+		role        entity.Role
+		expectedErr error
+	}{
+		{name: "ShareTask - Anonymous", ctx: context.Background(), grantee: "bob", role: entity.RoleViewer, expectedErr: domain.ErrForbidden},
+		{name: "ShareTask - Unknown role", ctx: as("alice"), grantee: "bob", role: "admin", expectedErr: domain.ErrInvalidRole},
+		{name: "ShareTask - Share with yourself", ctx: as("alice"), grantee: "alice", role: entity.RoleViewer, expectedErr: domain.ErrInvalidGrant},
+		{name: "ShareTask - Missing grantee", ctx: as("alice"), grantee: "", role: entity.RoleViewer, expectedErr: domain.ErrInvalidGrant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ShareTask(tt.ctx, task.Id, tt.grantee, tt.role)
+
+			asserts.ErrorIs(err, tt.expectedErr)
+		})
+	}
+}
+
+func TestTodoListService_Complete_Reopen(t *testing.T) {
+	asserts := assert.New(t)
+	task := entity.Task{Id: uuid.New(), Title: "Alice", Owner: "alice"}
+	service := newSharingService(task)
+
+	_, err := service.CompleteTask(as("bob"), task.Id)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+
+	_, err = service.ShareTask(as("alice"), task.Id, "bob", entity.RoleViewer)
+	asserts.Nil(err)
+	_, err = service.CompleteTask(as("bob"), task.Id)
+	asserts.ErrorIs(err, domain.ErrForbidden)
+
+	completed, err := service.CompleteTask(as("alice"), task.Id)
+	asserts.Nil(err)
+	asserts.True(completed.IsCompleted)
+	asserts.NotNil(completed.CompletedAt)
+
+	reopened, err := service.ReopenTask(as("alice"), task.Id)
+	asserts.Nil(err)
+	asserts.False(reopened.IsCompleted)
+	asserts.Nil(reopened.CompletedAt)
+	asserts.Equal(1, reopened.ReopenedCount)
+	asserts.Equal("Alice", reopened.Title)
+}
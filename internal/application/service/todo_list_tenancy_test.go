@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/infrastructure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoListService_CreateTask_Quota(t *testing.T) {
+	asserts := assert.New(t)
+	service := NewTodoListService(
+		infrastructure.NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{}),
+		WithTaskQuota(func(tenant string) int {
+			if tenant == "acme" {
+				return 2
+			}
+			return 0
+		}),
+	)
+	acmeCtx := identity.WithTenant(identity.WithoutAuthentication(context.Background()), "acme")
+	globexCtx := identity.WithTenant(identity.WithoutAuthentication(context.Background()), "globex")
+
+	for i := 0; i < 2; i++ {
+		created, err := service.CreateTask(acmeCtx, entity.Task{Title: "Task", Description: "Task"})
+		asserts.Nil(err)
+		asserts.Equal("acme", created.Tenant)
+	}
+
+	_, err := service.CreateTask(acmeCtx, entity.Task{Title: "Task", Description: "Task"})
+	asserts.ErrorIs(err, domain.ErrTaskQuotaExceeded)
+
+	// unlimited tenants are not affected
+	for i := 0; i < 3; i++ {
+		_, err = service.CreateTask(globexCtx, entity.Task{Title: "Task", Description: "Task"})
+		asserts.Nil(err)
+	}
+
+	tasks, err := service.GetAllTasks(acmeCtx)
+	asserts.Nil(err)
+	asserts.Len(tasks, 2)
+}
+
+func TestTodoListService_CreateTask_Quota_Concurrent(t *testing.T) {
+	asserts := assert.New(t)
+	service := NewTodoListService(
+		infrastructure.NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{}),
+		WithTaskQuota(func(string) int { return 2 }),
+	)
+	ctx := identity.WithTenant(identity.WithoutAuthentication(context.Background()), "acme")
+
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.CreateTask(ctx, entity.Task{Title: "Task", Description: "Task"}); err == nil {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	tasks, err := service.GetAllTasks(ctx)
+	asserts.Nil(err)
+	asserts.LessOrEqual(len(tasks), 2)
+	asserts.Equal(int(created.Load()), len(tasks))
+	asserts.Positive(len(tasks))
+}
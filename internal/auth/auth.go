@@ -0,0 +1,77 @@
+// Package auth holds the identity of the caller and the scopes it was granted.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeAdminKeys  = "admin:keys"
+	ScopeStatsRead  = "stats:read"
+)
+
+// APIKeySubjectPrefix starts the subject of API keys without an owner, it is
+// reserved so no other credential can act as one of those keys.
+const APIKeySubjectPrefix = "apikey:"
+
+// ReservedSubject reports whether subject can only be given to API keys
+// without an owner.
+func ReservedSubject(subject string) bool {
+	return strings.HasPrefix(subject, APIKeySubjectPrefix)
+}
+
+// Scopes lists every scope a credential can be granted.
+var Scopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeAdminKeys, ScopeStatsRead}
+
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	// Method tells how the caller authenticated, e.g. "api_key".
+	Method string
+	Scopes []string
+	// Tenant is set when the credential is bound to a single tenant.
+	Tenant string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal of the request, or nil when the request
+// is anonymous.
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
+
+// Guard wraps a handler so it only runs for callers holding scope.
+type Guard func(scope string, next http.HandlerFunc) http.HandlerFunc
+
+// Open is the Guard used when authentication is disabled.
+func Open(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return next
+}
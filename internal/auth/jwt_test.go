@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	data, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	asserts := assert.New(t)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	verifier, err := NewJWTVerifier(JWTConfig{
+		HMACSecret:  testSecret,
+		JWKSFile:    writeJWKS(t, "key-1", &rsaKey.PublicKey),
+		Issuer:      "https://auth.example.com",
+		TenantClaim: "tenant",
+	})
+	require.NoError(t, err)
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":    "alice",
+			"iss":    "https://auth.example.com",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"scope":  "tasks:read tasks:write",
+			"tenant": "acme",
+		}
+	}
+	expired := valid()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongIssuer := valid()
+	wrongIssuer["iss"] = "https://evil.example.com"
+	noSubject := valid()
+	delete(noSubject, "sub")
+	noExpiry := valid()
+	delete(noExpiry, "exp")
+	apiKeySubject := valid()
+	apiKeySubject["sub"] = APIKeySubjectPrefix + "6f1c0b7e-8f4e-4a8e-9a57-1b7d7c3f8e21"
+
+	tests := []struct {
+		name            string
+		token           string
+		expectedSubject string
+	}{
+		{name: "Verify - HS256 Success", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", valid()), expectedSubject: "alice"},
+		{name: "Verify - RS256 Success", token: sign(t, jwt.SigningMethodRS256, rsaKey, "key-1", valid()), expectedSubject: "alice"},
+		{name: "Verify - HS256 wrong secret", token: sign(t, jwt.SigningMethodHS256, []byte("another secret of thirty-two bytes"), "", valid())},
+		{name: "Verify - RS256 unknown signer", token: sign(t, jwt.SigningMethodRS256, otherKey, "key-1", valid())},
+		{name: "Verify - RS256 unknown kid", token: sign(t, jwt.SigningMethodRS256, rsaKey, "key-2", valid())},
+		{name: "Verify - Unsigned token", token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", valid())},
+		{name: "Verify - Expired", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", expired)},
+		{name: "Verify - Missing expiry", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", noExpiry)},
+		{name: "Verify - Wrong issuer", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", wrongIssuer)},
+		{name: "Verify - Missing subject", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", noSubject)},
+		{name: "Verify - API key subject", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", apiKeySubject)},
+		{name: "Verify - Malformed", token: "not.a.token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), tt.token)
+
+			if tt.expectedSubject == "" {
+				asserts.ErrorIs(err, ErrInvalidToken)
+				return
+			}
+			asserts.Nil(err)
+			asserts.Equal(tt.expectedSubject, principal.Subject)
+			asserts.Equal("jwt", principal.Method)
+			asserts.True(principal.HasScope(ScopeTasksWrite))
+			asserts.Equal("acme", principal.Tenant)
+		})
+	}
+}
+
+func TestJWTVerifier_RS256_Disabled_Without_JWKS(t *testing.T) {
+	asserts := assert.New(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	verifier, err := NewJWTVerifier(JWTConfig{HMACSecret: testSecret})
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, rsaKey, "", jwt.MapClaims{
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}))
+
+	asserts.ErrorIs(err, ErrInvalidToken)
+}
@@ -0,0 +1,73 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Group runs long lived background workers (purgers, flushers, watchers...)
+// and stops them together: Stop cancels their context and waits for them to
+// return.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	closers []func(context.Context) error
+}
+
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Go starts worker in its own goroutine. The worker must return once ctx is done.
+func (g *Group) Go(worker func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		worker(g.ctx)
+	}()
+}
+
+// OnStop registers a function called after the workers returned, in reverse
+// order of registration, typically to flush buffers or close resources.
+func (g *Group) OnStop(closer func(context.Context) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.closers = append(g.closers, closer)
+}
+
+// Stop cancels the workers, waits for them until ctx expires and then runs
+// the registered closers. Every error is returned.
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	var errs []error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, errors.New("background workers didn't stop in time"))
+	}
+
+	g.mu.Lock()
+	closers := g.closers
+	g.closers = nil
+	g.mu.Unlock()
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
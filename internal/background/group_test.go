@@ -0,0 +1,54 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_Stop_WaitsForWorkersThenClosers(t *testing.T) {
+	asserts := assert.New(t)
+	group := NewGroup()
+	var calls []string
+
+	group.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		calls = append(calls, "worker")
+	})
+	group.OnStop(func(context.Context) error {
+		calls = append(calls, "first closer")
+		return nil
+	})
+	group.OnStop(func(context.Context) error {
+		calls = append(calls, "second closer")
+		return nil
+	})
+
+	err := group.Stop(context.Background())
+
+	asserts.Nil(err)
+	asserts.Equal([]string{"worker", "second closer", "first closer"}, calls)
+}
+
+func TestGroup_Stop_Errors(t *testing.T) {
+	asserts := assert.New(t)
+	group := NewGroup()
+	mockError := errors.New("flush failed")
+	blocked := make(chan struct{})
+	defer close(blocked)
+
+	group.Go(func(ctx context.Context) { <-blocked })
+	group.OnStop(func(context.Context) error { return mockError })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := group.Stop(ctx)
+
+	asserts.ErrorIs(err, mockError)
+	asserts.ErrorContains(err, "didn't stop in time")
+}
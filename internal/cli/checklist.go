@@ -0,0 +1,191 @@
+// Package cli holds the subcommands of the api binary that talk to a running
+// server instead of starting one.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/middlewares"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/transfer"
+)
+
+// ChecklistCommand is the name of the checklist subcommand.
+const ChecklistCommand = "checklist"
+
+const (
+	EnvURL    = "TODO_API_URL"
+	EnvAPIKey = "TODO_API_KEY"
+	EnvToken  = "TODO_API_TOKEN"
+
+	defaultURL = "http://localhost:8080"
+)
+
+const checklistUsage = `usage:
+  api checklist import [flags] FILE   create the tasks of a markdown checklist, "-" reads stdin
+  api checklist export [flags]        write the tasks as a markdown checklist`
+
+// client calls the API of a server as one caller.
+type client struct {
+	http   *http.Client
+	url    string
+	apiKey string
+	token  string
+}
+
+// newFlagSet declares the flags shared by the checklist subcommands.
+func newFlagSet(name string, getenv func(string) string, output io.Writer) (*flag.FlagSet, *client) {
+	fs := flag.NewFlagSet("checklist "+name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	c := &client{http: http.DefaultClient, url: defaultURL}
+	if value := getenv(EnvURL); value != "" {
+		c.url = value
+	}
+	fs.StringVar(&c.url, "url", c.url, "base URL of the API (env "+EnvURL+")")
+	fs.StringVar(&c.apiKey, "api-key", getenv(EnvAPIKey), "API key of the caller (env "+EnvAPIKey+")")
+	fs.StringVar(&c.token, "token", getenv(EnvToken), "bearer token of the caller, used without an API key (env "+EnvToken+")")
+	return fs, c
+}
+
+// Checklist runs the checklist subcommand with the arguments that follow it.
+// Results go to stdout, usage and flag errors to stderr.
+func Checklist(ctx context.Context, args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, checklistUsage)
+		return flag.ErrHelp
+	}
+
+	switch args[0] {
+	case "import":
+		return importChecklist(ctx, args[1:], getenv, stdin, stdout, stderr)
+	case "export":
+		return exportChecklist(ctx, args[1:], getenv, stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprintln(stderr, checklistUsage)
+		return flag.ErrHelp
+	}
+
+	fmt.Fprintln(stderr, checklistUsage)
+	return fmt.Errorf("unknown checklist command %q", args[0])
+}
+
+func importChecklist(ctx context.Context, args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	fs, c := newFlagSet("import", getenv, stderr)
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without storing anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, checklistUsage)
+		return errors.New("checklist import takes one file")
+	}
+
+	body := stdin
+	if name := fs.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		body = file
+	}
+
+	path := "/tasks.md"
+	if *dryRun {
+		path += "?dry_run=true"
+	}
+	response, err := c.do(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	var result dtos.ImportResultResponseDto
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return fmt.Errorf("reading the import result: %w", err)
+	}
+
+	verb := "imported"
+	if result.DryRun {
+		verb = "would import"
+	}
+	fmt.Fprintf(stdout, "%s %d of %d tasks, %d failed\n", verb, result.Imported, result.Total, result.Failed)
+	for _, importErr := range result.Errors {
+		fmt.Fprintf(stdout, "line %d: %s %s\n", importErr.Row, importErr.Field, importErr.Reason)
+	}
+	return nil
+}
+
+func exportChecklist(ctx context.Context, args []string, getenv func(string) string, stdout io.Writer, stderr io.Writer) error {
+	fs, c := newFlagSet("export", getenv, stderr)
+	output := fs.String("o", "", "file written instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(stderr, checklistUsage)
+		return errors.New("checklist export takes no arguments")
+	}
+
+	response, err := c.do(ctx, http.MethodGet, "/tasks.md", nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if *output == "" {
+		_, err = io.Copy(stdout, response.Body)
+		return err
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, response.Body); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// do sends a request and returns successful responses, the error message of
+// the server otherwise.
+func (c *client) do(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.url, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", transfer.FormatMarkdown.ContentType())
+	}
+	switch {
+	case c.apiKey != "":
+		request.Header.Set(middlewares.APIKeyHeader, c.apiKey)
+	case c.token != "":
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 300 {
+		return response, nil
+	}
+
+	defer response.Body.Close()
+	var errorResponse dtos.ErrorResponse
+	if err := json.NewDecoder(response.Body).Decode(&errorResponse); err != nil || errorResponse.Message == "" {
+		return nil, fmt.Errorf("%s %s: %s", method, path, response.Status)
+	}
+	return nil, fmt.Errorf("%s %s: %s: %s", method, path, response.Status, errorResponse.Message)
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/middlewares"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/public"
+	"github.com/manuelbeos/code-branch-todo-test/internal/infrastructure"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	router := mux.NewRouter()
+	router.Use(middlewares.AuthenticationDisabledMiddleware)
+	repository := infrastructure.NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{})
+	public.NewTodoListHandler(service.NewTodoListService(repository)).RegisterEndpoints(router)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChecklist_Import_Export(t *testing.T) {
+	asserts := assert.New(t)
+	server := newTestServer(t)
+	getenv := func(name string) string {
+		if name == EnvURL {
+			return server.URL
+		}
+		return ""
+	}
+	dir := t.TempDir()
+	checklist := "- [x] Write changelog\n  - [ ] Check links\n- [ ]\n"
+	input := filepath.Join(dir, "TODO.md")
+	asserts.Nil(os.WriteFile(input, []byte(checklist), 0o600))
+
+	tests := []struct {
+		name           string
+		args           []string
+		stdin          string
+		expectedOutput string
+	}{
+		{name: "Checklist - Dry run", args: []string{"import", "-dry-run", input},
+			expectedOutput: "would import 2 of 3 tasks, 1 failed\nline 3: title is required\n"},
+		{name: "Checklist - Export nothing", args: []string{"export"}, expectedOutput: ""},
+		{name: "Checklist - Import stdin", args: []string{"import", "-"}, stdin: checklist,
+			expectedOutput: "imported 2 of 3 tasks, 1 failed\nline 3: title is required\n"},
+		{name: "Checklist - Export", args: []string{"export"}, expectedOutput: "- [x] Write changelog\n  - [ ] Check links\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+
+			err := Checklist(context.Background(), tt.args, getenv, strings.NewReader(tt.stdin), stdout, &bytes.Buffer{})
+
+			asserts.Nil(err)
+			asserts.Equal(tt.expectedOutput, stdout.String())
+		})
+	}
+
+	output := filepath.Join(dir, "exported.md")
+	asserts.Nil(Checklist(context.Background(), []string{"export", "-url", server.URL, "-o", output}, func(string) string { return "" }, nil, &bytes.Buffer{}, &bytes.Buffer{}))
+	exported, err := os.ReadFile(output)
+	asserts.Nil(err)
+	asserts.Equal("- [x] Write changelog\n  - [ ] Check links\n", string(exported))
+}
+
+func TestChecklist_Errors(t *testing.T) {
+	asserts := assert.New(t)
+	server := newTestServer(t)
+	getenv := func(string) string { return "" }
+	run := func(args ...string) error {
+		return Checklist(context.Background(), args, getenv, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+	}
+
+	asserts.ErrorIs(run(), flag.ErrHelp)
+	asserts.EqualError(run("sync"), `unknown checklist command "sync"`)
+	asserts.EqualError(run("import", "-url", server.URL), "checklist import takes one file")
+	asserts.EqualError(run("export", "-url", server.URL, "extra"), "checklist export takes no arguments")
+	asserts.EqualError(run("import", "-url", server.URL+"/missing", "-"), "POST /tasks.md: 404 Not Found")
+}
@@ -0,0 +1,373 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	StorageMemory = "memory"
+
+	KeyStoreMemory = "memory"
+	KeyStoreFile   = "file"
+)
+
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Tenancy     TenancyConfig     `yaml:"tenancy"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Features    FeaturesConfig    `yaml:"features"`
+}
+
+type ServerConfig struct {
+	Address string `yaml:"address"`
+	// ShutdownTimeout bounds the draining of in-flight requests and
+	// background workers once shutdown starts.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// DrainDelay is waited between failing readiness and closing the
+	// listener, so load balancers stop routing traffic first.
+	DrainDelay        time.Duration `yaml:"drain_delay"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes"`
+	// MaxBodyBytes is the largest request body accepted, bigger ones get a 413.
+	MaxBodyBytes int64     `yaml:"max_body_bytes"`
+	TLS          TLSConfig `yaml:"tls"`
+}
+
+// TLSConfig enables HTTPS when both files are set. The files are checked every
+// ReloadInterval and the certificate is swapped without a restart when they change.
+type TLSConfig struct {
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+type StorageConfig struct {
+	Backend string `yaml:"backend"`
+}
+
+type LoggingConfig struct {
+	Level        string   `yaml:"level"`
+	Format       string   `yaml:"format"`
+	LogBodies    bool     `yaml:"log_bodies"`
+	MaxBodyBytes int      `yaml:"max_body_bytes"`
+	RedactFields []string `yaml:"redact_fields"`
+}
+
+type TracingConfig struct {
+	Exporter     string `yaml:"exporter"`
+	FilePath     string `yaml:"file_path"`
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	ServiceName  string `yaml:"service_name"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// AuthConfig protects the API with API keys and JWT bearer tokens when
+// Enabled. BootstrapKey is stored with every scope at startup so the first
+// keys can be created through the admin endpoints, BootstrapTenant binds it
+// to a tenant so it can reach the task routes with tenancy enabled too.
+type AuthConfig struct {
+	Enabled         bool      `yaml:"enabled"`
+	KeyStore        string    `yaml:"key_store"`
+	KeyFile         string    `yaml:"key_file"`
+	BootstrapKey    string    `yaml:"bootstrap_key"`
+	BootstrapTenant string    `yaml:"bootstrap_tenant"`
+	JWT             JWTConfig `yaml:"jwt"`
+}
+
+// JWTConfig accepts HS256 tokens signed with HMACSecret and RS256 tokens
+// signed by a key of the JWKS file.
+type JWTConfig struct {
+	HMACSecret string        `yaml:"hmac_secret"`
+	JWKSFile   string        `yaml:"jwks_file"`
+	Issuer     string        `yaml:"issuer"`
+	Audience   string        `yaml:"audience"`
+	Leeway     time.Duration `yaml:"leeway"`
+}
+
+func (j JWTConfig) Enabled() bool {
+	return j.HMACSecret != "" || j.JWKSFile != ""
+}
+
+// TenancyConfig isolates tasks per tenant when Enabled. The tenant comes from
+// the Claim of a bearer token (or the tenant of an API key), the Header, or
+// the subdomain of BaseDomain. Quotas caps the number of tasks of a tenant,
+// DefaultQuota applies to the others; 0 means unlimited.
+type TenancyConfig struct {
+	Enabled      bool           `yaml:"enabled"`
+	Header       string         `yaml:"header"`
+	BaseDomain   string         `yaml:"base_domain"`
+	Claim        string         `yaml:"claim"`
+	DefaultQuota int            `yaml:"default_quota"`
+	Quotas       map[string]int `yaml:"quotas"`
+}
+
+// TaskQuota returns the task quota of tenant, 0 when it is unlimited.
+func (t TenancyConfig) TaskQuota(tenant string) int {
+	if quota, ok := t.Quotas[tenant]; ok {
+		return quota
+	}
+	return t.DefaultQuota
+}
+
+// RateLimitRule allows Burst requests at once and refills Requests every Period,
+// Burst defaults to Requests. A zero Requests disables the limit.
+type RateLimitRule struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	Burst    int           `yaml:"burst"`
+}
+
+// RateLimitConfig limits every client (API key, user or IP address) when
+// Enabled. Routes gives routes such as "POST /tasks" their own limit, the
+// others share the default one. Auth limits the requests carrying
+// credentials per IP address, before they are checked.
+type RateLimitConfig struct {
+	Enabled        bool                     `yaml:"enabled"`
+	Default        RateLimitRule            `yaml:",inline"`
+	ClientIPHeader string                   `yaml:"client_ip_header"`
+	Routes         map[string]RateLimitRule `yaml:"routes"`
+	Auth           RateLimitRule            `yaml:"auth"`
+}
+
+// IdempotencyConfig replays the response of POST /tasks to the retries
+// sharing its Idempotency-Key for TTL when Enabled.
+type IdempotencyConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+}
+
+// FeaturesConfig switches optional parts of the API on and off.
+type FeaturesConfig struct {
+	WebSocket bool `yaml:"websocket"`
+	Metrics   bool `yaml:"metrics"`
+	Swagger   bool `yaml:"swagger"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Address:           ":8080",
+			ShutdownTimeout:   5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      1 << 20,
+			TLS: TLSConfig{
+				ReloadInterval: 30 * time.Second,
+			},
+		},
+		Storage: StorageConfig{
+			Backend: StorageMemory,
+		},
+		Logging: LoggingConfig{
+			Level:        "info",
+			Format:       "json",
+			LogBodies:    false,
+			MaxBodyBytes: 4096,
+			RedactFields: []string{"password", "token", "secret", "api_key", "key"},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			FilePath:    "traces.json",
+			ServiceName: "todo-list-api",
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Content-Type", "X-Request-ID", "Authorization", "X-API-Key", "X-Tenant-ID", "Idempotency-Key"},
+			ExposedHeaders: []string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "Idempotent-Replayed"},
+			MaxAge:         10 * time.Minute,
+		},
+		Auth: AuthConfig{
+			Enabled:  false,
+			KeyStore: KeyStoreMemory,
+			KeyFile:  "api_keys.json",
+			JWT: JWTConfig{
+				Leeway: 30 * time.Second,
+			},
+		},
+		Tenancy: TenancyConfig{
+			Enabled: false,
+			Header:  "X-Tenant-ID",
+			Claim:   "tenant",
+			Quotas:  map[string]int{},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: false,
+			Default: RateLimitRule{Requests: 300, Period: time.Minute},
+			Routes: map[string]RateLimitRule{
+				"POST /tasks": {Requests: 30, Period: time.Minute, Burst: 10},
+			},
+			Auth: RateLimitRule{Requests: 600, Period: time.Minute},
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled: true,
+			TTL:     24 * time.Hour,
+		},
+		Features: FeaturesConfig{
+			WebSocket: true,
+			Metrics:   true,
+			Swagger:   true,
+		},
+	}
+}
+
+// normalize lower cases the settings picking one of a few values, so the
+// code reading them can compare them exactly whatever case they were given in.
+func (c *Config) normalize() {
+	for _, value := range []*string{
+		&c.Storage.Backend,
+		&c.Logging.Level,
+		&c.Logging.Format,
+		&c.Tracing.Exporter,
+		&c.Auth.KeyStore,
+	} {
+		*value = strings.ToLower(*value)
+	}
+}
+
+func oneOf(value string, allowed ...string) bool {
+	return slices.Contains(allowed, value)
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Address == "" {
+		errs = append(errs, errors.New("server.address is required"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	for name, timeout := range map[string]time.Duration{
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+		"server.drain_delay":         c.Server.DrainDelay,
+	} {
+		if timeout < 0 {
+			errs = append(errs, fmt.Errorf("%s can't be negative", name))
+		}
+	}
+	if c.Server.MaxHeaderBytes <= 0 {
+		errs = append(errs, errors.New("server.max_header_bytes must be positive"))
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("server.max_body_bytes must be positive"))
+	}
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("server.tls.cert_file and server.tls.key_file must be set together"))
+	}
+	if c.Server.TLS.Enabled() && c.Server.TLS.ReloadInterval < 0 {
+		errs = append(errs, errors.New("server.tls.reload_interval can't be negative"))
+	}
+	if !oneOf(c.Storage.Backend, StorageMemory) {
+		errs = append(errs, fmt.Errorf("storage.backend %q is not supported", c.Storage.Backend))
+	}
+	if !oneOf(c.Logging.Level, "debug", "info", "warn", "error") {
+		errs = append(errs, fmt.Errorf("logging.level %q must be debug, info, warn or error", c.Logging.Level))
+	}
+	if !oneOf(c.Logging.Format, "json", "text") {
+		errs = append(errs, fmt.Errorf("logging.format %q must be json or text", c.Logging.Format))
+	}
+	if c.Logging.MaxBodyBytes < 0 {
+		errs = append(errs, errors.New("logging.max_body_bytes can't be negative"))
+	}
+	if !oneOf(c.Tracing.Exporter, "none", "stdout", "file", "otlp") {
+		errs = append(errs, fmt.Errorf("tracing.exporter %q must be none, stdout, file or otlp", c.Tracing.Exporter))
+	}
+	if c.Tracing.Exporter == "otlp" && c.Tracing.OTLPEndpoint == "" {
+		errs = append(errs, errors.New("tracing.otlp_endpoint is required by the otlp exporter"))
+	}
+	if c.Tracing.Exporter == "file" && c.Tracing.FilePath == "" {
+		errs = append(errs, errors.New("tracing.file_path is required by the file exporter"))
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin != "*" && strings.Count(origin, "*") > 1 {
+			errs = append(errs, fmt.Errorf("cors.allowed_origins %q can contain a single wildcard", origin))
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		errs = append(errs, errors.New("cors.max_age can't be negative"))
+	}
+	if !oneOf(c.Auth.KeyStore, KeyStoreMemory, KeyStoreFile) {
+		errs = append(errs, fmt.Errorf("auth.key_store %q must be memory or file", c.Auth.KeyStore))
+	}
+	if c.Auth.KeyStore == KeyStoreFile && c.Auth.KeyFile == "" {
+		errs = append(errs, errors.New("auth.key_file is required by the file key store"))
+	}
+	if c.Auth.Enabled && c.Auth.KeyStore == KeyStoreMemory && c.Auth.BootstrapKey == "" && !c.Auth.JWT.Enabled() {
+		errs = append(errs, errors.New("auth.bootstrap_key or auth.jwt is required with the memory key store, otherwise nobody could authenticate"))
+	}
+	if c.Auth.JWT.HMACSecret != "" && len(c.Auth.JWT.HMACSecret) < 32 {
+		errs = append(errs, errors.New("auth.jwt.hmac_secret must be at least 32 bytes long"))
+	}
+	if c.Auth.JWT.Leeway < 0 {
+		errs = append(errs, errors.New("auth.jwt.leeway can't be negative"))
+	}
+
+	if c.Tenancy.Enabled && c.Tenancy.Header == "" && c.Tenancy.BaseDomain == "" && (c.Tenancy.Claim == "" || !c.Auth.Enabled) {
+		errs = append(errs, errors.New("tenancy needs tenancy.header, tenancy.base_domain or tenancy.claim with auth enabled"))
+	}
+	if c.Tenancy.DefaultQuota < 0 {
+		errs = append(errs, errors.New("tenancy.default_quota can't be negative"))
+	}
+	for tenant, quota := range c.Tenancy.Quotas {
+		if quota < 0 {
+			errs = append(errs, fmt.Errorf("tenancy.quotas of %q can't be negative", tenant))
+		}
+	}
+
+	errs = append(errs, c.RateLimit.Default.validate("rate_limit")...)
+	errs = append(errs, c.RateLimit.Auth.validate("rate_limit.auth")...)
+	for route, limit := range c.RateLimit.Routes {
+		if method, path, ok := strings.Cut(route, " "); !ok || method == "" || !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("rate_limit.routes %q must be a method and a route such as \"POST /tasks\"", route))
+		}
+		errs = append(errs, limit.validate(fmt.Sprintf("rate_limit.routes %q", route))...)
+	}
+	if c.Idempotency.Enabled && c.Idempotency.TTL <= 0 {
+		errs = append(errs, errors.New("idempotency.ttl must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r RateLimitRule) validate(name string) []error {
+	var errs []error
+	if r.Requests < 0 || r.Burst < 0 {
+		errs = append(errs, fmt.Errorf("%s requests and burst can't be negative", name))
+	}
+	if r.Requests > 0 && r.Period <= 0 {
+		errs = append(errs, fmt.Errorf("%s period must be positive", name))
+	}
+	return errs
+}
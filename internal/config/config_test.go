@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	asserts := assert.New(t)
+
+	cfg, err := Load(nil, env(nil))
+
+	asserts.Nil(err)
+	asserts.Equal(Default(), cfg)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	asserts := assert.New(t)
+	path := writeConfigFile(t, `
+server:
+  address: ":9000"
+  shutdown_timeout: 20s
+logging:
+  level: debug
+  format: text
+cors:
+  allowed_origins: ["https://file.example.com"]
+`)
+
+	cfg, err := Load(
+		[]string{"-config", path, "-addr", ":7000"},
+		env(map[string]string{
+			"TODO_SERVER_ADDRESS":       ":8000",
+			"TODO_LOG_LEVEL":            "warn",
+			"TODO_CORS_ALLOWED_ORIGINS": "https://a.example.com, https://b.example.com",
+		}),
+	)
+
+	require.NoError(t, err)
+	// flag beats env beats file
+	asserts.Equal(":7000", cfg.Server.Address)
+	asserts.Equal("warn", cfg.Logging.Level)
+	asserts.Equal([]string{"https://a.example.com", "https://b.example.com"}, cfg.CORS.AllowedOrigins)
+	// file beats defaults
+	asserts.Equal(20*time.Second, cfg.Server.ShutdownTimeout)
+	asserts.Equal("text", cfg.Logging.Format)
+	// untouched defaults
+	asserts.Equal(StorageMemory, cfg.Storage.Backend)
+}
+
+func TestLoad_ConfigFileFromEnv(t *testing.T) {
+	asserts := assert.New(t)
+	path := writeConfigFile(t, "features:\n  websocket: false\n")
+
+	cfg, err := Load(nil, env(map[string]string{EnvConfigFile: path}))
+
+	require.NoError(t, err)
+	asserts.False(cfg.Features.WebSocket)
+	asserts.True(cfg.Features.Metrics)
+}
+
+func TestLoad_OTLPEndpointEnablesExporter(t *testing.T) {
+	asserts := assert.New(t)
+
+	cfg, err := Load(nil, env(map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318"}))
+
+	require.NoError(t, err)
+	asserts.Equal("otlp", cfg.Tracing.Exporter)
+}
+
+func TestLoad_NormalizesCase(t *testing.T) {
+	asserts := assert.New(t)
+
+	cfg, err := Load([]string{"-tracing-exporter", "Stdout", "-log-level", "DEBUG"}, env(map[string]string{
+		"TODO_STORAGE_BACKEND": "Memory",
+		"TODO_LOG_FORMAT":      "Text",
+		"TODO_AUTH_KEY_STORE":  "FILE",
+	}))
+
+	require.NoError(t, err)
+	asserts.Equal("stdout", cfg.Tracing.Exporter)
+	asserts.Equal("debug", cfg.Logging.Level)
+	asserts.Equal(StorageMemory, cfg.Storage.Backend)
+	asserts.Equal("text", cfg.Logging.Format)
+	asserts.Equal(KeyStoreFile, cfg.Auth.KeyStore)
+}
+
+func TestLoad_TenancyQuotas(t *testing.T) {
+	asserts := assert.New(t)
+
+	cfg, err := Load(nil, env(map[string]string{
+		"TODO_TENANCY_ENABLED":       "true",
+		"TODO_TENANCY_DEFAULT_QUOTA": "100",
+		"TODO_TENANCY_QUOTAS":        "acme=10, globex=0",
+	}))
+
+	require.NoError(t, err)
+	asserts.Equal(10, cfg.Tenancy.TaskQuota("acme"))
+	asserts.Equal(0, cfg.Tenancy.TaskQuota("globex"))
+	asserts.Equal(100, cfg.Tenancy.TaskQuota("initech"))
+}
+
+func TestLoad_RateLimitRoutes(t *testing.T) {
+	asserts := assert.New(t)
+
+	cfg, err := Load(nil, env(map[string]string{
+		"TODO_RATE_LIMIT_ENABLED": "true",
+		"TODO_RATE_LIMIT_ROUTES":  "POST /tasks=5/1m, DELETE /tasks/{id}=1/10s",
+	}))
+
+	require.NoError(t, err)
+	asserts.Equal(map[string]RateLimitRule{
+		"POST /tasks":        {Requests: 5, Period: time.Minute},
+		"DELETE /tasks/{id}": {Requests: 1, Period: 10 * time.Second},
+	}, cfg.RateLimit.Routes)
+	asserts.Equal(300, cfg.RateLimit.Default.Requests)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	asserts := assert.New(t)
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		file    string
+		message string
+	}{
+		{name: "Load - Invalid duration env", env: map[string]string{"TODO_SERVER_SHUTDOWN_TIMEOUT": "soon"}, message: "TODO_SERVER_SHUTDOWN_TIMEOUT"},
+		{name: "Load - Invalid bool flag", args: []string{"-feature-metrics", "maybe"}, message: "-feature-metrics"},
+		{name: "Load - Unknown flag", args: []string{"-nope"}, message: "nope"},
+		{name: "Load - Unknown file field", file: "server:\n  port: 80\n", message: "field port not found"},
+		{name: "Load - Unsupported backend", env: map[string]string{"TODO_STORAGE_BACKEND": "postgres"}, message: `storage.backend "postgres"`},
+		{name: "Load - Several invalid values", args: []string{"-log-level", "loud", "-log-format", "xml"}, message: "logging.format"},
+		{name: "Load - Invalid tenancy quotas", env: map[string]string{"TODO_TENANCY_QUOTAS": "acme"}, message: "TODO_TENANCY_QUOTAS"},
+		{name: "Load - Invalid rate limit route", env: map[string]string{"TODO_RATE_LIMIT_ROUTES": "POST /tasks=5"}, message: "TODO_RATE_LIMIT_ROUTES"},
+		{name: "Load - Rate limit without period", file: "rate_limit:\n  requests: 10\n  period: 0s\n", message: "rate_limit period"},
+		{name: "Load - Idempotency without ttl", env: map[string]string{"TODO_IDEMPOTENCY_TTL": "0s"}, message: "idempotency.ttl"},
+		{name: "Load - OTLP without endpoint", args: []string{"-tracing-exporter", "otlp"}, message: "tracing.otlp_endpoint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeConfigFile(t, tt.file)}, args...)
+			}
+
+			cfg, err := Load(args, env(tt.env))
+
+			asserts.Nil(cfg)
+			require.Error(t, err)
+			asserts.Contains(err.Error(), tt.message)
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const EnvConfigFile = "TODO_CONFIG_FILE"
+
+// setting is a single option that can be overridden from the environment and
+// from the command line.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+func stringSetting(target func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		*target(c) = value
+		return nil
+	}
+}
+
+func boolSetting(target func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*target(c) = parsed
+		return nil
+	}
+}
+
+func intSetting(target func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*target(c) = parsed
+		return nil
+	}
+}
+
+func int64Setting(target func(*Config) *int64) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*target(c) = parsed
+		return nil
+	}
+}
+
+func durationSetting(target func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*target(c) = parsed
+		return nil
+	}
+}
+
+// listSetting reads comma separated values.
+func listSetting(target func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*target(c) = list
+		return nil
+	}
+}
+
+// quotaSetting reads comma separated tenant=quota pairs.
+func quotaSetting(target func(*Config) *map[string]int) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		quotas := map[string]int{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			tenant, quota, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("%q is not a tenant=quota pair", item)
+			}
+			parsed, err := strconv.Atoi(strings.TrimSpace(quota))
+			if err != nil {
+				return err
+			}
+			quotas[strings.TrimSpace(tenant)] = parsed
+		}
+		*target(c) = quotas
+		return nil
+	}
+}
+
+// routeLimitSetting reads comma separated "METHOD /route=requests/period"
+// pairs, e.g. "POST /tasks=30/1m".
+func routeLimitSetting(target func(*Config) *map[string]RateLimitRule) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		routes := map[string]RateLimitRule{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			route, limit, hasLimit := strings.Cut(item, "=")
+			requests, period, hasPeriod := strings.Cut(limit, "/")
+			if !hasLimit || !hasPeriod {
+				return fmt.Errorf("%q is not a route=requests/period pair", item)
+			}
+			parsedRequests, err := strconv.Atoi(strings.TrimSpace(requests))
+			if err != nil {
+				return err
+			}
+			parsedPeriod, err := time.ParseDuration(strings.TrimSpace(period))
+			if err != nil {
+				return err
+			}
+			routes[strings.TrimSpace(route)] = RateLimitRule{Requests: parsedRequests, Period: parsedPeriod}
+		}
+		*target(c) = routes
+		return nil
+	}
+}
+
+var settings = []setting{
+	{"TODO_SERVER_ADDRESS", "addr", "listen address", stringSetting(func(c *Config) *string { return &c.Server.Address })},
+	{"TODO_SERVER_SHUTDOWN_TIMEOUT", "shutdown-timeout", "graceful shutdown timeout", durationSetting(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"TODO_SERVER_DRAIN_DELAY", "drain-delay", "time between failing readiness and closing the listener", durationSetting(func(c *Config) *time.Duration { return &c.Server.DrainDelay })},
+	{"TODO_SERVER_READ_TIMEOUT", "read-timeout", "maximum duration for reading a whole request", durationSetting(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{"TODO_SERVER_READ_HEADER_TIMEOUT", "read-header-timeout", "maximum duration for reading request headers", durationSetting(func(c *Config) *time.Duration { return &c.Server.ReadHeaderTimeout })},
+	{"TODO_SERVER_WRITE_TIMEOUT", "write-timeout", "maximum duration for writing a response", durationSetting(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{"TODO_SERVER_IDLE_TIMEOUT", "idle-timeout", "how long keep-alive connections stay idle", durationSetting(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{"TODO_SERVER_MAX_HEADER_BYTES", "max-header-bytes", "maximum size of request headers", intSetting(func(c *Config) *int { return &c.Server.MaxHeaderBytes })},
+	{"TODO_SERVER_MAX_BODY_BYTES", "max-body-bytes", "maximum size of request bodies", int64Setting(func(c *Config) *int64 { return &c.Server.MaxBodyBytes })},
+	{"TODO_TLS_CERT_FILE", "tls-cert", "TLS certificate file, enables HTTPS with -tls-key", stringSetting(func(c *Config) *string { return &c.Server.TLS.CertFile })},
+	{"TODO_TLS_KEY_FILE", "tls-key", "TLS private key file", stringSetting(func(c *Config) *string { return &c.Server.TLS.KeyFile })},
+	{"TODO_TLS_RELOAD_INTERVAL", "tls-reload-interval", "how often the TLS files are checked for changes (0 disables)", durationSetting(func(c *Config) *time.Duration { return &c.Server.TLS.ReloadInterval })},
+	{"TODO_STORAGE_BACKEND", "storage", "storage backend (memory)", stringSetting(func(c *Config) *string { return &c.Storage.Backend })},
+	{"TODO_LOG_LEVEL", "log-level", "log level (debug, info, warn, error)", stringSetting(func(c *Config) *string { return &c.Logging.Level })},
+	{"TODO_LOG_FORMAT", "log-format", "log format (json, text)", stringSetting(func(c *Config) *string { return &c.Logging.Format })},
+	{"TODO_LOG_BODIES", "log-bodies", "log request and response bodies", boolSetting(func(c *Config) *bool { return &c.Logging.LogBodies })},
+	{"TODO_LOG_MAX_BODY_BYTES", "log-max-body-bytes", "maximum number of body bytes logged", intSetting(func(c *Config) *int { return &c.Logging.MaxBodyBytes })},
+	{"TODO_LOG_REDACT_FIELDS", "log-redact-fields", "comma separated JSON fields never logged", listSetting(func(c *Config) *[]string { return &c.Logging.RedactFields })},
+	{"TODO_TRACING_EXPORTER", "tracing-exporter", "trace exporter (none, stdout, file, otlp)", stringSetting(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"TODO_TRACING_FILE", "tracing-file", "file used by the file trace exporter", stringSetting(func(c *Config) *string { return &c.Tracing.FilePath })},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", "otlp-endpoint", "OTLP/HTTP collector endpoint", stringSetting(func(c *Config) *string { return &c.Tracing.OTLPEndpoint })},
+	{"OTEL_SERVICE_NAME", "service-name", "service name reported in traces", stringSetting(func(c *Config) *string { return &c.Tracing.ServiceName })},
+	{"TODO_CORS_ALLOWED_ORIGINS", "cors-allowed-origins", "comma separated allowed origins", listSetting(func(c *Config) *[]string { return &c.CORS.AllowedOrigins })},
+	{"TODO_CORS_ALLOWED_METHODS", "cors-allowed-methods", "comma separated allowed methods", listSetting(func(c *Config) *[]string { return &c.CORS.AllowedMethods })},
+	{"TODO_CORS_ALLOWED_HEADERS", "cors-allowed-headers", "comma separated allowed request headers", listSetting(func(c *Config) *[]string { return &c.CORS.AllowedHeaders })},
+	{"TODO_CORS_EXPOSED_HEADERS", "cors-exposed-headers", "comma separated exposed response headers", listSetting(func(c *Config) *[]string { return &c.CORS.ExposedHeaders })},
+	{"TODO_CORS_ALLOW_CREDENTIALS", "cors-allow-credentials", "allow credentialed cross-origin requests", boolSetting(func(c *Config) *bool { return &c.CORS.AllowCredentials })},
+	{"TODO_CORS_MAX_AGE", "cors-max-age", "how long browsers may cache preflight responses", durationSetting(func(c *Config) *time.Duration { return &c.CORS.MaxAge })},
+	{"TODO_AUTH_ENABLED", "auth", "require API keys", boolSetting(func(c *Config) *bool { return &c.Auth.Enabled })},
+	{"TODO_AUTH_KEY_STORE", "auth-key-store", "API key store (memory, file)", stringSetting(func(c *Config) *string { return &c.Auth.KeyStore })},
+	{"TODO_AUTH_KEY_FILE", "auth-key-file", "file used by the file API key store", stringSetting(func(c *Config) *string { return &c.Auth.KeyFile })},
+	{"TODO_AUTH_BOOTSTRAP_KEY", "auth-bootstrap-key", "API key granted every scope at startup", stringSetting(func(c *Config) *string { return &c.Auth.BootstrapKey })},
+	{"TODO_AUTH_BOOTSTRAP_TENANT", "auth-bootstrap-tenant", "tenant the bootstrap key is bound to", stringSetting(func(c *Config) *string { return &c.Auth.BootstrapTenant })},
+	{"TODO_AUTH_JWT_HMAC_SECRET", "auth-jwt-hmac-secret", "secret of HS256 bearer tokens", stringSetting(func(c *Config) *string { return &c.Auth.JWT.HMACSecret })},
+	{"TODO_AUTH_JWKS_FILE", "auth-jwks-file", "JWKS file with the keys of RS256 bearer tokens", stringSetting(func(c *Config) *string { return &c.Auth.JWT.JWKSFile })},
+	{"TODO_AUTH_JWT_ISSUER", "auth-jwt-issuer", "required iss claim of bearer tokens", stringSetting(func(c *Config) *string { return &c.Auth.JWT.Issuer })},
+	{"TODO_AUTH_JWT_AUDIENCE", "auth-jwt-audience", "required aud claim of bearer tokens", stringSetting(func(c *Config) *string { return &c.Auth.JWT.Audience })},
+	{"TODO_AUTH_JWT_LEEWAY", "auth-jwt-leeway", "clock skew tolerated when checking token times", durationSetting(func(c *Config) *time.Duration { return &c.Auth.JWT.Leeway })},
+	{"TODO_TENANCY_ENABLED", "tenancy", "isolate tasks per tenant", boolSetting(func(c *Config) *bool { return &c.Tenancy.Enabled })},
+	{"TODO_TENANCY_HEADER", "tenancy-header", "header carrying the tenant id", stringSetting(func(c *Config) *string { return &c.Tenancy.Header })},
+	{"TODO_TENANCY_BASE_DOMAIN", "tenancy-base-domain", "domain whose subdomains are tenants", stringSetting(func(c *Config) *string { return &c.Tenancy.BaseDomain })},
+	{"TODO_TENANCY_CLAIM", "tenancy-claim", "bearer token claim carrying the tenant id", stringSetting(func(c *Config) *string { return &c.Tenancy.Claim })},
+	{"TODO_TENANCY_DEFAULT_QUOTA", "tenancy-default-quota", "maximum number of tasks per tenant (0 is unlimited)", intSetting(func(c *Config) *int { return &c.Tenancy.DefaultQuota })},
+	{"TODO_TENANCY_QUOTAS", "tenancy-quotas", "comma separated tenant=quota overrides", quotaSetting(func(c *Config) *map[string]int { return &c.Tenancy.Quotas })},
+	{"TODO_RATE_LIMIT_ENABLED", "rate-limit", "rate limit every client", boolSetting(func(c *Config) *bool { return &c.RateLimit.Enabled })},
+	{"TODO_RATE_LIMIT_REQUESTS", "rate-limit-requests", "requests allowed per period and client (0 is unlimited)", intSetting(func(c *Config) *int { return &c.RateLimit.Default.Requests })},
+	{"TODO_RATE_LIMIT_PERIOD", "rate-limit-period", "period of the rate limit", durationSetting(func(c *Config) *time.Duration { return &c.RateLimit.Default.Period })},
+	{"TODO_RATE_LIMIT_BURST", "rate-limit-burst", "requests allowed at once (defaults to the requests)", intSetting(func(c *Config) *int { return &c.RateLimit.Default.Burst })},
+	{"TODO_RATE_LIMIT_CLIENT_IP_HEADER", "rate-limit-client-ip-header", "header trusted for the client address, e.g. X-Forwarded-For", stringSetting(func(c *Config) *string { return &c.RateLimit.ClientIPHeader })},
+	{"TODO_RATE_LIMIT_AUTH_REQUESTS", "rate-limit-auth-requests", "requests with credentials allowed per period and IP address (0 is unlimited)", intSetting(func(c *Config) *int { return &c.RateLimit.Auth.Requests })},
+	{"TODO_RATE_LIMIT_AUTH_PERIOD", "rate-limit-auth-period", "period of the credentials rate limit", durationSetting(func(c *Config) *time.Duration { return &c.RateLimit.Auth.Period })},
+	{"TODO_RATE_LIMIT_ROUTES", "rate-limit-routes", "comma separated \"METHOD /route=requests/period\" limits", routeLimitSetting(func(c *Config) *map[string]RateLimitRule { return &c.RateLimit.Routes })},
+	{"TODO_IDEMPOTENCY_ENABLED", "idempotency", "replay POST /tasks responses to retries with the same Idempotency-Key", boolSetting(func(c *Config) *bool { return &c.Idempotency.Enabled })},
+	{"TODO_IDEMPOTENCY_TTL", "idempotency-ttl", "how long idempotency keys are remembered", durationSetting(func(c *Config) *time.Duration { return &c.Idempotency.TTL })},
+	{"TODO_FEATURE_WEBSOCKET", "feature-websocket", "enable the websocket endpoint", boolSetting(func(c *Config) *bool { return &c.Features.WebSocket })},
+	{"TODO_FEATURE_METRICS", "feature-metrics", "enable the metrics endpoint", boolSetting(func(c *Config) *bool { return &c.Features.Metrics })},
+	{"TODO_FEATURE_SWAGGER", "feature-swagger", "enable the swagger documentation", boolSetting(func(c *Config) *bool { return &c.Features.Swagger })},
+}
+
+// Load builds the configuration from, in increasing order of precedence, the
+// defaults, the YAML file given with -config (or TODO_CONFIG_FILE), the
+// environment and the command line flags. The result is validated.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	configFile := fs.String("config", getenv(EnvConfigFile), "path to a YAML configuration file")
+
+	type override struct {
+		setting setting
+		value   string
+	}
+	var flagOverrides []override
+	for _, s := range settings {
+		s := s
+		fs.Func(s.flag, s.usage+" (env "+s.env+")", func(value string) error {
+			flagOverrides = append(flagOverrides, override{setting: s, value: value})
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if value := getenv(s.env); value != "" {
+			if err := s.set(cfg, value); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", s.env, err))
+			}
+		}
+	}
+
+	for _, o := range flagOverrides {
+		if err := o.setting.set(cfg, o.value); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: %w", o.setting.flag, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	cfg.normalize()
+
+	// an OTLP endpoint alone is enough to turn the exporter on, as with the
+	// OpenTelemetry SDKs
+	if cfg.Tracing.OTLPEndpoint != "" && cfg.Tracing.Exporter == "none" {
+		cfg.Tracing.Exporter = "otlp"
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	return nil
+}
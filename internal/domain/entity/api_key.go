@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is stored without its secret, only the SHA-256 hash of the full key
+// is kept so a leaked store can't be used to call the API.
+type APIKey struct {
+	Id   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	// Owner is the user the key acts for, keys without one are their own user.
+	Owner string `json:"owner,omitempty"`
+	// Tenant binds the key to a single tenant, keys without one may pick it.
+	Tenant    string     `json:"tenant,omitempty"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"hash"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func NewAPIKey(name string, owner string, scopes []string, prefix string, hash string) APIKey {
+	return APIKey{
+		Id:        uuid.New(),
+		Name:      name,
+		Owner:     owner,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKey) Revoke() {
+	now := time.Now()
+	k.RevokedAt = &now
+}
+
+// Rotate replaces the secret, the id, name and scopes are kept.
+func (k *APIKey) Rotate(prefix string, hash string) {
+	now := time.Now()
+	k.Prefix = prefix
+	k.Hash = hash
+	k.RotatedAt = &now
+}
@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role is the access a user has on a shared task or list.
+type Role string
+
+const (
+	// RoleViewer can read.
+	RoleViewer Role = "viewer"
+	// RoleEditor can read and update.
+	RoleEditor Role = "editor"
+	// RoleOwner can also delete and share.
+	RoleOwner Role = "owner"
+)
+
+var roleRanks = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// AtLeast reports whether r grants everything other grants.
+func (r Role) AtLeast(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
+// Grant gives Grantee a role on one task of Owner, or on all of Owner's tasks
+// when TaskId is nil.
+type Grant struct {
+	Id        uuid.UUID  `json:"id"`
+	Tenant    string     `json:"tenant,omitempty"`
+	Owner     string     `json:"owner"`
+	TaskId    *uuid.UUID `json:"task_id,omitempty"`
+	Grantee   string     `json:"grantee"`
+	Role      Role       `json:"role"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func NewGrant(tenant string, owner string, taskID *uuid.UUID, grantee string, role Role, createdBy string) Grant {
+	return Grant{
+		Id:        uuid.New(),
+		Tenant:    tenant,
+		Owner:     owner,
+		TaskId:    taskID,
+		Grantee:   grantee,
+		Role:      role,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+}
+
+// SameTarget reports whether both grants give the same grantee access to the
+// same task or list, a new grant replaces the role of the old one.
+func (g *Grant) SameTarget(other *Grant) bool {
+	if g.Tenant != other.Tenant || g.Owner != other.Owner || g.Grantee != other.Grantee {
+		return false
+	}
+	if g.TaskId == nil || other.TaskId == nil {
+		return g.TaskId == nil && other.TaskId == nil
+	}
+	return *g.TaskId == *other.TaskId
+}
@@ -1,34 +1,287 @@
 package entity
 
 import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
 )
 
+const (
+	MaxTaskTitleLength       = 200
+	MaxTaskDescriptionLength = 2000
+	MaxTaskTags              = 10
+	MaxTagLength             = 32
+)
+
+// Priority tells how urgent a task is, tasks without one are normal.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+)
+
+// Priorities lists every priority, from the lowest.
+var Priorities = []Priority{PriorityLow, PriorityNormal, PriorityHigh}
+
+func (p Priority) Valid() bool {
+	return slices.Contains(Priorities, p)
+}
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
 type Task struct {
-	Id          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	IsCompleted bool      `json:"is_completed"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Id          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	IsCompleted bool       `json:"is_completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// ReopenedCount counts how many times the task went back to pending.
+	ReopenedCount int      `json:"reopened_count"`
+	Priority      Priority `json:"priority,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	// DueAt is when the task should be done by, if ever.
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// ParentId is the task this one is a subtask of, if any.
+	ParentId  *uuid.UUID `json:"parent_id,omitempty"`
+	Owner     string     `json:"owner,omitempty"`
+	Tenant    string     `json:"tenant,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func validTitle(title string) error {
+	if strings.TrimSpace(title) == "" {
+		return &domain.InvariantError{Field: "title", Reason: "is required"}
+	}
+	if utf8.RuneCountInString(title) > MaxTaskTitleLength {
+		return &domain.InvariantError{Field: "title", Reason: "must be at most " + strconv.Itoa(MaxTaskTitleLength) + " characters"}
+	}
+	return nil
+}
+
+func validDescription(description string) error {
+	if utf8.RuneCountInString(description) > MaxTaskDescriptionLength {
+		return &domain.InvariantError{Field: "description", Reason: "must be at most " + strconv.Itoa(MaxTaskDescriptionLength) + " characters"}
+	}
+	return nil
+}
+
+func validPriority(priority Priority) error {
+	if priority != "" && !priority.Valid() {
+		return &domain.InvariantError{Field: "priority", Reason: "must be one of low, normal, high"}
+	}
+	return nil
 }
 
-func NewTask(title string, description string) Task {
+// normalizeTags lower cases, sorts and deduplicates tags.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, &domain.InvariantError{Field: "tags", Reason: "must be at most " + strconv.Itoa(MaxTagLength) + " characters each"}
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, &domain.InvariantError{Field: "tags", Reason: "must be letters, digits, dashes and underscores"}
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+
+	if len(normalized) > MaxTaskTags {
+		return nil, &domain.InvariantError{Field: "tags", Reason: "must be at most " + strconv.Itoa(MaxTaskTags) + " items"}
+	}
+	return normalized, nil
+}
+
+// NewTask returns a pending task, or a *domain.InvariantError when the title
+// or the description are not valid.
+func NewTask(title string, description string) (Task, error) {
+	if err := validTitle(title); err != nil {
+		return Task{}, err
+	}
+	if err := validDescription(description); err != nil {
+		return Task{}, err
+	}
+
+	now := time.Now()
 	return Task{
 		Id:          uuid.New(),
 		Title:       title,
 		Description: description,
 		IsCompleted: false,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		Priority:    PriorityNormal,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// Validate checks the invariants of tasks built without NewTask, e.g. when
+// they are imported.
+func (t *Task) Validate() error {
+	if t.Id == uuid.Nil {
+		return &domain.InvariantError{Field: "id", Reason: "is required"}
+	}
+	if err := validTitle(t.Title); err != nil {
+		return err
+	}
+	if err := validDescription(t.Description); err != nil {
+		return err
+	}
+	if t.IsCompleted != (t.CompletedAt != nil) {
+		return &domain.InvariantError{Field: "completed_at", Reason: "must be set on completed tasks only"}
+	}
+	if err := validPriority(t.Priority); err != nil {
+		return err
+	}
+	if _, err := normalizeTags(t.Tags); err != nil {
+		return err
+	}
+	if t.ReopenedCount < 0 {
+		return &domain.InvariantError{Field: "reopened_count", Reason: "can't be negative"}
+	}
+	if t.ParentId != nil && *t.ParentId == t.Id {
+		return &domain.InvariantError{Field: "parent_id", Reason: "can't be the task itself"}
 	}
+	if t.UpdatedAt.Before(t.CreatedAt) {
+		return &domain.InvariantError{Field: "updated_at", Reason: "can't be before created_at"}
+	}
+	return nil
+}
+
+// Restore prepares a task read back from an export: a missing id, priority
+// or timestamps are filled in and the tags are normalized, then the
+// invariants are checked. The values already set, timestamps included, are
+// kept as they are.
+func (t *Task) Restore() error {
+	if t.Id == uuid.Nil {
+		t.Id = uuid.New()
+	}
+	t.Priority = t.EffectivePriority()
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	if t.UpdatedAt.IsZero() {
+		t.UpdatedAt = t.CreatedAt
+	}
+	if t.IsCompleted && t.CompletedAt == nil {
+		completedAt := t.UpdatedAt
+		t.CompletedAt = &completedAt
+	}
+
+	tags, err := normalizeTags(t.Tags)
+	if err != nil {
+		return err
+	}
+	t.Tags = tags
+
+	return t.Validate()
 }
 
-func (t *Task) Update(title string, description string, isCompleted bool) {
+// Update changes every editable field, leaving the task untouched when one
+// of them is not valid. CompletedAt follows IsCompleted.
+func (t *Task) Update(title string, description string, isCompleted bool) error {
+	if err := validTitle(title); err != nil {
+		return err
+	}
+	if err := validDescription(description); err != nil {
+		return err
+	}
+
+	now := time.Now()
 	t.Title = title
 	t.Description = description
+	t.setCompleted(isCompleted, now)
+	t.touch(now)
+	return nil
+}
+
+// Classify sets the priority and the tags, leaving the task untouched when
+// one of them is not valid. An empty priority means normal.
+func (t *Task) Classify(priority Priority, tags []string) error {
+	if priority == "" {
+		priority = PriorityNormal
+	}
+	if err := validPriority(priority); err != nil {
+		return err
+	}
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	if priority != t.Priority || !slices.Equal(tags, t.Tags) {
+		t.Priority = priority
+		t.Tags = tags
+		t.touch(time.Now())
+	}
+	return nil
+}
+
+// Reschedule moves the due date of the task, nil removes it.
+func (t *Task) Reschedule(dueAt *time.Time) {
+	if dueAt == t.DueAt || (dueAt != nil && t.DueAt != nil && dueAt.Equal(*t.DueAt)) {
+		return
+	}
+	t.DueAt = dueAt
+	t.touch(time.Now())
+}
+
+// EffectivePriority returns the priority of the task, normal for tasks
+// stored before priorities existed.
+func (t *Task) EffectivePriority() Priority {
+	if t.Priority == "" {
+		return PriorityNormal
+	}
+	return t.Priority
+}
+
+// Complete marks the task as done, completing it again changes nothing.
+func (t *Task) Complete() {
+	if !t.IsCompleted {
+		now := time.Now()
+		t.setCompleted(true, now)
+		t.touch(now)
+	}
+}
+
+// Reopen puts a completed task back to pending, pending tasks are left as
+// they are.
+func (t *Task) Reopen() {
+	if t.IsCompleted {
+		now := time.Now()
+		t.setCompleted(false, now)
+		t.touch(now)
+	}
+}
+
+func (t *Task) setCompleted(isCompleted bool, now time.Time) {
+	switch {
+	case isCompleted && !t.IsCompleted:
+		t.CompletedAt = &now
+	case !isCompleted && t.IsCompleted:
+		t.CompletedAt = nil
+		t.ReopenedCount++
+	}
 	t.IsCompleted = isCompleted
-	t.UpdatedAt = time.Now()
+}
+
+// touch moves UpdatedAt to now, never backwards even if the clock does.
+func (t *Task) touch(now time.Time) {
+	if now.After(t.UpdatedAt) {
+		t.UpdatedAt = now
+	}
 }
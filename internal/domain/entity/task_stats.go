@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"maps"
+	"time"
+)
+
+// TaskCount counts tasks and how many of them are completed.
+type TaskCount struct {
+	Total     int
+	Completed int
+}
+
+// TaskAggregate holds counters over a set of tasks. Adding a task and
+// removing it again leaves the counters as they were, so repositories keep it
+// up to date on every write instead of scanning the tasks to report on them.
+type TaskAggregate struct {
+	Tasks TaskCount
+	// CompletionTime sums how long the CompletionTimeCount completed tasks
+	// having a CompletedAt took, from creation to completion.
+	CompletionTime      time.Duration
+	CompletionTimeCount int
+	// CreatedByDay and CompletedByDay are keyed by UTC midnight.
+	CreatedByDay   map[time.Time]int
+	CompletedByDay map[time.Time]int
+	ByPriority     map[Priority]TaskCount
+	ByTag          map[string]TaskCount
+}
+
+func NewTaskAggregate() *TaskAggregate {
+	return &TaskAggregate{
+		CreatedByDay:   map[time.Time]int{},
+		CompletedByDay: map[time.Time]int{},
+		ByPriority:     map[Priority]TaskCount{},
+		ByTag:          map[string]TaskCount{},
+	}
+}
+
+func (a *TaskAggregate) Add(task *Task) {
+	a.apply(task, 1)
+}
+
+// Remove takes back a task added before, it must be passed as it was added.
+func (a *TaskAggregate) Remove(task *Task) {
+	a.apply(task, -1)
+}
+
+// Merge adds the counters of other to a.
+func (a *TaskAggregate) Merge(other *TaskAggregate) {
+	a.Tasks = a.Tasks.add(other.Tasks.Total, other.Tasks.Completed)
+	a.CompletionTime += other.CompletionTime
+	a.CompletionTimeCount += other.CompletionTimeCount
+	for day, n := range other.CreatedByDay {
+		addCount(a.CreatedByDay, day, n)
+	}
+	for day, n := range other.CompletedByDay {
+		addCount(a.CompletedByDay, day, n)
+	}
+	for priority, count := range other.ByPriority {
+		addTaskCount(a.ByPriority, priority, count.Total, count.Completed)
+	}
+	for tag, count := range other.ByTag {
+		addTaskCount(a.ByTag, tag, count.Total, count.Completed)
+	}
+}
+
+// Clone returns a copy the caller can read while a keeps changing.
+func (a *TaskAggregate) Clone() *TaskAggregate {
+	clone := *a
+	clone.CreatedByDay = maps.Clone(a.CreatedByDay)
+	clone.CompletedByDay = maps.Clone(a.CompletedByDay)
+	clone.ByPriority = maps.Clone(a.ByPriority)
+	clone.ByTag = maps.Clone(a.ByTag)
+	return &clone
+}
+
+func (a *TaskAggregate) apply(task *Task, n int) {
+	completed := 0
+	if task.IsCompleted {
+		completed = n
+	}
+
+	a.Tasks = a.Tasks.add(n, completed)
+	addCount(a.CreatedByDay, Day(task.CreatedAt), n)
+	if task.IsCompleted && task.CompletedAt != nil {
+		a.CompletionTime += time.Duration(n) * task.CompletedAt.Sub(task.CreatedAt)
+		a.CompletionTimeCount += n
+		addCount(a.CompletedByDay, Day(*task.CompletedAt), n)
+	}
+	addTaskCount(a.ByPriority, task.EffectivePriority(), n, completed)
+	for _, tag := range task.Tags {
+		addTaskCount(a.ByTag, tag, n, completed)
+	}
+}
+
+func (c TaskCount) add(total int, completed int) TaskCount {
+	return TaskCount{Total: c.Total + total, Completed: c.Completed + completed}
+}
+
+// addCount and addTaskCount drop the keys going back to zero, so the maps
+// only hold what is still counted.
+func addCount[K comparable](counts map[K]int, key K, n int) {
+	if counts[key]+n == 0 {
+		delete(counts, key)
+		return
+	}
+	counts[key] += n
+}
+
+func addTaskCount[K comparable](counts map[K]TaskCount, key K, total int, completed int) {
+	count := counts[key].add(total, completed)
+	if count == (TaskCount{}) {
+		delete(counts, key)
+		return
+	}
+	counts[key] = count
+}
+
+// Day returns the UTC midnight starting the day of t.
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
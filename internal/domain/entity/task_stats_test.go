@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskAggregate_Add_Remove(t *testing.T) {
+	asserts := assert.New(t)
+	created := time.Date(2026, 10, 5, 23, 30, 0, 0, time.UTC)
+	completedAt := created.Add(2 * time.Hour)
+	pending := &Task{Priority: PriorityHigh, Tags: []string{"work"}, CreatedAt: created}
+	completed := &Task{IsCompleted: true, CompletedAt: &completedAt, Tags: []string{"work", "home"}, CreatedAt: created}
+	aggregate := NewTaskAggregate()
+
+	aggregate.Add(pending)
+	aggregate.Add(completed)
+
+	asserts.Equal(TaskCount{Total: 2, Completed: 1}, aggregate.Tasks)
+	asserts.Equal(2*time.Hour, aggregate.CompletionTime)
+	asserts.Equal(1, aggregate.CompletionTimeCount)
+	asserts.Equal(map[time.Time]int{Day(created): 2}, aggregate.CreatedByDay)
+	asserts.Equal(map[time.Time]int{Day(completedAt): 1}, aggregate.CompletedByDay)
+	asserts.Equal(map[Priority]TaskCount{PriorityHigh: {Total: 1}, PriorityNormal: {Total: 1, Completed: 1}}, aggregate.ByPriority)
+	asserts.Equal(map[string]TaskCount{"work": {Total: 2, Completed: 1}, "home": {Total: 1, Completed: 1}}, aggregate.ByTag)
+
+	clone := aggregate.Clone()
+	aggregate.Remove(completed)
+	aggregate.Remove(pending)
+
+	asserts.Equal(NewTaskAggregate(), aggregate)
+	asserts.Equal(2, clone.Tasks.Total)
+	asserts.Len(clone.ByTag, 2)
+}
+
+func TestTaskAggregate_Merge(t *testing.T) {
+	asserts := assert.New(t)
+	created := time.Date(2026, 10, 5, 23, 30, 0, 0, time.UTC)
+	completedAt := created.Add(2 * time.Hour)
+	pending := &Task{Priority: PriorityHigh, Tags: []string{"work"}, CreatedAt: created}
+	completed := &Task{IsCompleted: true, CompletedAt: &completedAt, Tags: []string{"work", "home"}, CreatedAt: created}
+	both := NewTaskAggregate()
+	both.Add(pending)
+	both.Add(completed)
+	first := NewTaskAggregate()
+	first.Add(pending)
+	second := NewTaskAggregate()
+	second.Add(completed)
+
+	first.Merge(second)
+	first.Merge(NewTaskAggregate())
+
+	asserts.Equal(both, first)
+}
@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTask_Invariants(t *testing.T) {
+	asserts := assert.New(t)
+
+	tests := []struct {
+		name        string
+		title       string
+		description string
+		expected    *domain.InvariantError
+	}{
+		{name: "NewTask - Valid", title: "title", description: "description"},
+		{name: "NewTask - Empty title", title: " ", expected: &domain.InvariantError{Field: "title", Reason: "is required"}},
+		{name: "NewTask - Title too long", title: strings.Repeat("a", MaxTaskTitleLength+1), expected: &domain.InvariantError{Field: "title", Reason: "must be at most 200 characters"}},
+		{name: "NewTask - Description too long", title: "title", description: strings.Repeat("a", MaxTaskDescriptionLength+1), expected: &domain.InvariantError{Field: "description", Reason: "must be at most 2000 characters"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := NewTask(tt.title, tt.description)
+			if tt.expected == nil {
+				asserts.Nil(err)
+				asserts.NotEqual(uuid.Nil, task.Id)
+				asserts.Nil(task.Validate())
+				return
+			}
+			asserts.Equal(tt.expected, err)
+			asserts.ErrorIs(err, domain.ErrInvalidTask)
+		})
+	}
+}
+
+func TestTask_Update(t *testing.T) {
+	asserts := assert.New(t)
+	task, _ := NewTask("title", "description")
+	future := time.Now().Add(time.Hour)
+	task.UpdatedAt = future
+
+	asserts.Nil(task.Update("done", "description", true))
+	asserts.True(task.IsCompleted)
+	asserts.NotNil(task.CompletedAt)
+	// the clock going backwards doesn't move UpdatedAt back
+	asserts.Equal(future, task.UpdatedAt)
+
+	completedAt := task.CompletedAt
+	asserts.Nil(task.Update("still done", "description", true))
+	asserts.Equal(completedAt, task.CompletedAt)
+
+	asserts.Nil(task.Update("reopened", "description", false))
+	asserts.False(task.IsCompleted)
+	asserts.Nil(task.CompletedAt)
+
+	err := task.Update("", "description", true)
+	asserts.ErrorIs(err, domain.ErrInvalidTask)
+	asserts.Equal("reopened", task.Title)
+	asserts.False(task.IsCompleted)
+}
+
+func TestTask_Validate(t *testing.T) {
+	asserts := assert.New(t)
+	now := time.Now()
+
+	asserts.Equal(&domain.InvariantError{Field: "id", Reason: "is required"}, (&Task{Title: "title"}).Validate())
+	asserts.Equal(&domain.InvariantError{Field: "completed_at", Reason: "must be set on completed tasks only"},
+		(&Task{Id: uuid.New(), Title: "title", IsCompleted: true}).Validate())
+	asserts.Equal(&domain.InvariantError{Field: "updated_at", Reason: "can't be before created_at"},
+		(&Task{Id: uuid.New(), Title: "title", CreatedAt: now, UpdatedAt: now.Add(-time.Second)}).Validate())
+	id := uuid.New()
+	asserts.Equal(&domain.InvariantError{Field: "parent_id", Reason: "can't be the task itself"},
+		(&Task{Id: id, Title: "title", ParentId: &id}).Validate())
+}
+
+func TestTask_Complete_Reopen(t *testing.T) {
+	asserts := assert.New(t)
+	task, _ := NewTask("title", "description")
+
+	task.Reopen()
+	asserts.Equal(0, task.ReopenedCount)
+
+	task.Complete()
+	asserts.True(task.IsCompleted)
+	completedAt := task.CompletedAt
+	asserts.NotNil(completedAt)
+
+	// completing twice keeps the original timestamp
+	task.Complete()
+	asserts.Equal(completedAt, task.CompletedAt)
+
+	task.Reopen()
+	asserts.False(task.IsCompleted)
+	asserts.Nil(task.CompletedAt)
+	asserts.Equal(1, task.ReopenedCount)
+
+	task.Reopen()
+	asserts.Equal(1, task.ReopenedCount)
+	asserts.Equal(&domain.InvariantError{Field: "reopened_count", Reason: "can't be negative"},
+		(&Task{Id: uuid.New(), Title: "title", ReopenedCount: -1}).Validate())
+}
+
+func TestTask_Classify(t *testing.T) {
+	asserts := assert.New(t)
+	task, _ := NewTask("title", "description")
+	asserts.Equal(PriorityNormal, task.Priority)
+
+	asserts.Nil(task.Classify(PriorityHigh, []string{" Work", "home", "work"}))
+	asserts.Equal(PriorityHigh, task.Priority)
+	asserts.Equal([]string{"home", "work"}, task.Tags)
+
+	asserts.Equal(&domain.InvariantError{Field: "priority", Reason: "must be one of low, normal, high"}, task.Classify("urgent", nil))
+	asserts.Equal(&domain.InvariantError{Field: "tags", Reason: "must be letters, digits, dashes and underscores"}, task.Classify(PriorityLow, []string{"two words"}))
+	asserts.Equal(&domain.InvariantError{Field: "tags", Reason: "must be at most 32 characters each"}, task.Classify(PriorityLow, []string{strings.Repeat("a", MaxTagLength+1)}))
+	asserts.Equal(PriorityHigh, task.Priority)
+
+	asserts.Nil(task.Classify("", nil))
+	asserts.Equal(PriorityNormal, task.Priority)
+	asserts.Nil(task.Tags)
+}
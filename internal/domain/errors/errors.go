@@ -6,3 +6,41 @@ var (
 	ErrTaskNotFound    = errors.New("task not found")
 	ErrThereAreNoTasks = errors.New("there are no tasks created yet")
 )
+
+// ErrInvalidTask matches every *InvariantError.
+var ErrInvalidTask = errors.New("invalid task")
+
+// InvariantError reports a task rule broken by Field, Reason completes the
+// sentence, e.g. "title" "is required".
+type InvariantError struct {
+	Field  string
+	Reason string
+}
+
+func (e *InvariantError) Error() string {
+	return "invalid task: " + e.Field + " " + e.Reason
+}
+
+func (e *InvariantError) Is(target error) bool {
+	return target == ErrInvalidTask
+}
+
+var (
+	ErrTaskQuotaExceeded = errors.New("task quota of the tenant exceeded")
+	ErrInvalidTenant     = errors.New("invalid tenant")
+)
+
+var (
+	ErrForbidden     = errors.New("operation not allowed")
+	ErrGrantNotFound = errors.New("grant not found")
+	ErrInvalidRole   = errors.New("invalid role")
+	ErrInvalidGrant  = errors.New("invalid grant")
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrAPIKeyRevoked  = errors.New("api key revoked")
+	ErrInvalidScope   = errors.New("invalid scope")
+	ErrInvalidOwner   = errors.New("invalid owner")
+)
@@ -0,0 +1,116 @@
+// Package identity carries the caller's identity, and what was shared with
+// it, from the handlers down to the service and repositories, which scope
+// tasks to the ones the caller may see.
+package identity
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+)
+
+type ownerKey struct{}
+
+type tenantKey struct{}
+
+type sharesKey struct{}
+
+type unauthenticatedKey struct{}
+
+// Shares are the roles other users granted to the caller, on their whole list
+// (keyed by owner) or on single tasks.
+type Shares struct {
+	Lists map[string]entity.Role
+	Tasks map[uuid.UUID]entity.Role
+}
+
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// OwnerFromContext returns the owner the request acts for. It is empty when
+// authentication is disabled, or for anonymous requests.
+func OwnerFromContext(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	return owner
+}
+
+// WithoutAuthentication marks requests served with authentication disabled,
+// they have no owner and every task is shared between them.
+func WithoutAuthentication(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unauthenticatedKey{}, true)
+}
+
+// AuthenticationDisabled reports whether ctx was marked by
+// WithoutAuthentication. A missing owner only gives access to every task
+// then, an anonymous request to a server with authentication only sees the
+// tasks created without one.
+func AuthenticationDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(unauthenticatedKey{}).(bool)
+	return disabled
+}
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidTenant reports whether tenant is a valid tenant id: lower case letters,
+// digits and dashes, like a DNS label.
+func ValidTenant(tenant string) bool {
+	return tenantPattern.MatchString(tenant)
+}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant the request acts in, empty when
+// tenancy is disabled.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// InTenant reports whether data of tenant belongs to the tenant in ctx. The
+// comparison is strict, so data is never shared across tenants whatever the
+// owner or the shares say.
+func InTenant(ctx context.Context, tenant string) bool {
+	return TenantFromContext(ctx) == tenant
+}
+
+func WithShares(ctx context.Context, shares Shares) context.Context {
+	return context.WithValue(ctx, sharesKey{}, shares)
+}
+
+// RoleFor returns the role of the caller on a task owned by owner, ok is
+// false when the caller can't see the task at all. With authentication
+// disabled every task is fully accessible, otherwise a request without a
+// caller only sees the tasks without an owner.
+func RoleFor(ctx context.Context, taskID uuid.UUID, owner string) (role entity.Role, ok bool) {
+	caller := OwnerFromContext(ctx)
+	if caller == owner || (caller == "" && AuthenticationDisabled(ctx)) {
+		return entity.RoleOwner, true
+	}
+	if caller == "" {
+		return "", false
+	}
+
+	shares, _ := ctx.Value(sharesKey{}).(Shares)
+	if listRole, shared := shares.Lists[owner]; shared {
+		role, ok = listRole, true
+	}
+	if taskRole, shared := shares.Tasks[taskID]; shared && (!ok || taskRole.AtLeast(role)) {
+		role, ok = taskRole, true
+	}
+
+	return role, ok
+}
+
+// CanAccess reports whether the caller in ctx may see the task.
+func CanAccess(ctx context.Context, task *entity.Task) bool {
+	if !InTenant(ctx, task.Tenant) {
+		return false
+	}
+	_, ok := RoleFor(ctx, task.Id, task.Owner)
+	return ok
+}
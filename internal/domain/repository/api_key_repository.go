@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(context.Context, entity.APIKey) (*entity.APIKey, error)
+	GetAPIKeyByID(context.Context, uuid.UUID) (*entity.APIKey, error)
+	GetAPIKeyByHash(context.Context, string) (*entity.APIKey, error)
+	GetAllAPIKeys(context.Context) ([]*entity.APIKey, error)
+	UpdateAPIKey(context.Context, *entity.APIKey) (*entity.APIKey, error)
+}
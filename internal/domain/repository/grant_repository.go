@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+)
+
+type GrantRepository interface {
+	// CreateGrant replaces the role of an existing grant with the same target.
+	CreateGrant(context.Context, entity.Grant) (*entity.Grant, error)
+	GetGrantByID(context.Context, uuid.UUID) (*entity.Grant, error)
+	GetGrantsByGrantee(context.Context, string) ([]*entity.Grant, error)
+	GetGrantsByOwner(context.Context, string) ([]*entity.Grant, error)
+	DeleteGrant(context.Context, uuid.UUID) error
+}
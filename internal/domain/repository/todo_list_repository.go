@@ -13,4 +13,45 @@ type TodoListRepository interface {
 	GetTaskByID(context.Context, uuid.UUID) (*entity.Task, error)
 	UpdateTask(context.Context, *entity.Task) (*entity.Task, error)
 	DeleteTask(context.Context, uuid.UUID) error
+	// ImportTasks stores the tasks as they are, keeping their ids and
+	// timestamps. It returns the ids already taken: the ids of the tasks the
+	// caller can see as duplicates, the others, of other owners or tenants,
+	// as hidden. Nothing is stored when dryRun is set or when an id is taken,
+	// so the caller can leave those tasks out and import the rest again.
+	ImportTasks(ctx context.Context, tasks []entity.Task, dryRun bool) (duplicates []uuid.UUID, hidden []uuid.UUID, err error)
+	// CountTasks counts every task of the tenant in the context, whoever owns
+	// them. It's used to enforce the per-tenant quotas.
+	CountTasks(context.Context) (int, error)
+	// Ping checks that the storage is reachable, it's used by the readiness probe.
+	Ping(context.Context) error
+}
+
+// TaskStateCounter is implemented by repositories able to count their tasks
+// by completion state without going through GetAllTasks.
+type TaskStateCounter interface {
+	CountTasksByState() (completed int, pending int)
+}
+
+// SubtaskFinder is implemented by repositories indexing tasks by parent, so
+// deleting a task finds its subtasks without going through GetAllTasks.
+type SubtaskFinder interface {
+	// GetSubtaskIDs returns the ids of the tasks directly under parent that
+	// the caller in the context can see.
+	GetSubtaskIDs(ctx context.Context, parent uuid.UUID) ([]uuid.UUID, error)
+}
+
+// TaskAggregator is implemented by repositories keeping an
+// entity.TaskAggregate per owner and tenant up to date on every write, so
+// statistics don't need to scan every task.
+type TaskAggregator interface {
+	// AggregateTasks returns a copy of the aggregate of the tasks the caller
+	// in the context owns in its tenant. Without a caller, when
+	// authentication is disabled, it covers the whole tenant.
+	AggregateTasks(context.Context) (*entity.TaskAggregate, error)
+}
+
+// Flusher is implemented by repositories buffering writes, Flush is called
+// once during graceful shutdown.
+type Flusher interface {
+	Flush(context.Context) error
 }
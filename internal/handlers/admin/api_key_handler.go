@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/mappers"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+)
+
+type APIKeyHandler struct {
+	service *service.APIKeyService
+	guard   auth.Guard
+}
+
+func NewAPIKeyHandler(service *service.APIKeyService, guard auth.Guard) *APIKeyHandler {
+	return &APIKeyHandler{service: service, guard: guard}
+}
+
+func (akh *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			handler_utils.HandlerErrorResponse(w, http.StatusRequestEntityTooLarge, error_response.ErrRequestBodyTooLarge)
+			return
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrReadingRequestBody)
+		return
+	}
+
+	createAPIKeyReq := &dtos.CreateAPIKeyRequestDto{}
+	err = json.Unmarshal(body, createAPIKeyReq)
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingRequestBody)
+		return
+	}
+
+	if fieldErrors := createAPIKeyReq.Validate(); len(fieldErrors) > 0 {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.Validation(fieldErrors))
+		return
+	}
+
+	key, plain, err := akh.service.CreateAPIKey(ctx, createAPIKeyReq.Name, createAPIKeyReq.Owner, createAPIKeyReq.Tenant, createAPIKeyReq.Scopes)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidScope) {
+			handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrInvalidScopes)
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidTenant) {
+			handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrInvalidTenant)
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidOwner) {
+			handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrInvalidOwner)
+			return
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrCreatingAPIKey)
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusCreated, mappers.MapperAPIKeyEntityToResponse(key, plain))
+}
+
+func (akh *APIKeyHandler) GetAllAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	keys, err := akh.service.GetAllAPIKeys(ctx)
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrGettingAPIKeys)
+		return
+	}
+
+	response := make([]dtos.APIKeyResponseDto, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, mappers.MapperAPIKeyEntityToResponse(key, ""))
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusOK, response)
+}
+
+func (akh *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	keyID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingAPIKeyID)
+		return
+	}
+
+	err = akh.service.RevokeAPIKey(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			handler_utils.HandlerErrorResponse(w, http.StatusNotFound, error_response.ErrAPIKeyNotFound)
+			return
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrRevokingAPIKey)
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusNoContent, nil)
+}
+
+func (akh *APIKeyHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	keyID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingAPIKeyID)
+		return
+	}
+
+	key, plain, err := akh.service.RotateAPIKey(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			handler_utils.HandlerErrorResponse(w, http.StatusNotFound, error_response.ErrAPIKeyNotFound)
+			return
+		}
+		if errors.Is(err, domain.ErrAPIKeyRevoked) {
+			handler_utils.HandlerErrorResponse(w, http.StatusConflict, error_response.ErrAPIKeyRevoked)
+			return
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrRotatingAPIKey)
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusOK, mappers.MapperAPIKeyEntityToResponse(key, plain))
+}
+
+func (akh *APIKeyHandler) RegisterEndpoints(r *mux.Router) {
+	r.HandleFunc("/admin/keys", akh.guard(auth.ScopeAdminKeys, akh.CreateAPIKey)).Methods(http.MethodPost)
+	r.HandleFunc("/admin/keys", akh.guard(auth.ScopeAdminKeys, akh.GetAllAPIKeys)).Methods(http.MethodGet)
+	r.HandleFunc("/admin/keys/{id}", akh.guard(auth.ScopeAdminKeys, akh.RevokeAPIKey)).Methods(http.MethodDelete)
+	r.HandleFunc("/admin/keys/{id}/rotate", akh.guard(auth.ScopeAdminKeys, akh.RotateAPIKey)).Methods(http.MethodPost)
+}
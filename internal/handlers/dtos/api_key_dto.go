@@ -0,0 +1,52 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+)
+
+type CreateAPIKeyRequestDto struct {
+	Name   string   `json:"name"`
+	Owner  string   `json:"owner"`
+	Tenant string   `json:"tenant"`
+	Scopes []string `json:"scopes"`
+}
+
+func (ckr *CreateAPIKeyRequestDto) Validate() []FieldError {
+	var errs []FieldError
+	if ckr.Name == "" {
+		errs = append(errs, FieldError{Field: "name", Reason: "is required"})
+	}
+	validScopes := len(ckr.Scopes) > 0
+	for _, scope := range ckr.Scopes {
+		validScopes = validScopes && auth.ValidScope(scope)
+	}
+	if !validScopes {
+		errs = append(errs, FieldError{Field: "scopes", Reason: "must be a non empty list of known scopes"})
+	}
+	if ckr.Tenant != "" && !identity.ValidTenant(ckr.Tenant) {
+		errs = append(errs, FieldError{Field: "tenant", Reason: "must be lower case letters, digits and dashes"})
+	}
+	if auth.ReservedSubject(ckr.Owner) {
+		errs = append(errs, FieldError{Field: "owner", Reason: "can't start with " + auth.APIKeySubjectPrefix})
+	}
+	return errs
+}
+
+// APIKeyResponseDto never carries the hash, Key is only set when the key is
+// created or rotated.
+type APIKeyResponseDto struct {
+	Id        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Owner     string     `json:"owner,omitempty"`
+	Tenant    string     `json:"tenant,omitempty"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	Key       string     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
@@ -1,10 +1,31 @@
 package dtos
 
-import "strconv"
+import (
+	"strconv"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/validation"
+)
 
 type ErrorResponse struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+	Message   string `json:"message"`
+	Code      int    `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists every invalid field, only problem details render them.
+	Errors []FieldError `json:"-"`
+}
+
+type FieldError = validation.FieldError
+
+// ProblemDetails is the RFC 7807 error body sent to clients accepting
+// application/problem+json.
+type ProblemDetails struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
 }
 
 func (e *ErrorResponse) Error() string {
@@ -17,3 +38,18 @@ func NewErrorResponse(message string, code int) *ErrorResponse {
 		Code:    code,
 	}
 }
+
+// WithRequestID returns a copy of the error tagged with the given request id,
+// the shared error values are never modified.
+func (e *ErrorResponse) WithRequestID(requestID string) *ErrorResponse {
+	tagged := *e
+	tagged.RequestID = requestID
+	return &tagged
+}
+
+// WithErrors returns a copy of the error listing the invalid fields.
+func (e *ErrorResponse) WithErrors(errs []FieldError) *ErrorResponse {
+	tagged := *e
+	tagged.Errors = errs
+	return &tagged
+}
@@ -0,0 +1,24 @@
+package dtos
+
+import "github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+
+type ShareRequestDto struct {
+	Grantee string      `json:"grantee"`
+	Role    entity.Role `json:"role"`
+}
+
+func (sr *ShareRequestDto) Validate() []FieldError {
+	var errs []FieldError
+	if sr.Grantee == "" {
+		errs = append(errs, FieldError{Field: "grantee", Reason: "is required"})
+	}
+	if !sr.Role.Valid() {
+		errs = append(errs, FieldError{Field: "role", Reason: "must be viewer, editor or owner"})
+	}
+	return errs
+}
+
+type GrantsResponseDto struct {
+	Given    []*entity.Grant `json:"given"`
+	Received []*entity.Grant `json:"received"`
+}
@@ -0,0 +1,20 @@
+package dtos
+
+import "github.com/manuelbeos/code-branch-todo-test/internal/version"
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusNotReady = "not_ready"
+)
+
+type ComponentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type HealthResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+	Build      version.Info               `json:"build"`
+}
@@ -0,0 +1,27 @@
+package dtos
+
+import "github.com/google/uuid"
+
+type ImportErrorDto struct {
+	Row    int        `json:"row"`
+	Id     *uuid.UUID `json:"id,omitempty"`
+	Field  string     `json:"field"`
+	Reason string     `json:"reason"`
+}
+
+type ImportResultResponseDto struct {
+	DryRun     bool             `json:"dry_run"`
+	Total      int              `json:"total"`
+	Imported   int              `json:"imported"`
+	Duplicates int              `json:"duplicates"`
+	Failed     int              `json:"failed"`
+	Errors     []ImportErrorDto `json:"errors"`
+}
+
+type CalendarImportResponseDto struct {
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportErrorDto `json:"errors"`
+}
@@ -0,0 +1,25 @@
+package dtos
+
+type TaskCountDto struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+}
+
+type PeriodStatsDto struct {
+	// Start is the first day of the period, e.g. the Monday of a week.
+	Start     string `json:"start"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+type TaskStatsResponseDto struct {
+	Total                        int                     `json:"total"`
+	Completed                    int                     `json:"completed"`
+	Pending                      int                     `json:"pending"`
+	CompletionRate               float64                 `json:"completion_rate"`
+	AverageTimeToCompleteSeconds float64                 `json:"average_time_to_complete_seconds"`
+	GroupBy                      string                  `json:"group_by"`
+	Periods                      []PeriodStatsDto        `json:"periods"`
+	ByPriority                   map[string]TaskCountDto `json:"by_priority"`
+	ByTag                        map[string]TaskCountDto `json:"by_tag"`
+}
@@ -1,25 +1,36 @@
 package dtos
 
 import (
+	"time"
+
 	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/validation"
 )
 
 type CreateTaskRequestDto struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title       string     `json:"title" validate:"trim,required,max=200,singleline"`
+	Description string     `json:"description" validate:"trim,max=2000,printable"`
+	Priority    string     `json:"priority" validate:"trim,oneof=low normal high"`
+	Tags        []string   `json:"tags" validate:"max=10"`
+	DueAt       *time.Time `json:"due_at"`
 }
 
 type UpdateTaskRequestDto struct {
 	Id          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
+	Title       string    `json:"title" validate:"trim,required,max=200,singleline"`
+	Description string    `json:"description" validate:"trim,max=2000,printable"`
 	IsCompleted bool      `json:"is_completed"`
+	// Priority, Tags and DueAt are left as they are when missing, an empty
+	// list removes every tag.
+	Priority string     `json:"priority" validate:"trim,oneof=low normal high"`
+	Tags     []string   `json:"tags" validate:"max=10"`
+	DueAt    *time.Time `json:"due_at"`
 }
 
-func (utr *UpdateTaskRequestDto) ValidTitleField() bool {
-	return utr.Title != ""
+func (utr *UpdateTaskRequestDto) Validate() []FieldError {
+	return validation.Struct(utr)
 }
 
-func (ctr *CreateTaskRequestDto) ValidTitleField() bool {
-	return ctr.Title != ""
+func (ctr *CreateTaskRequestDto) Validate() []FieldError {
+	return validation.Struct(ctr)
 }
@@ -1,26 +1,147 @@
 package error_response
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
 	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
 )
 
 var (
-	ErrReadingRequestBody = dtos.NewErrorResponse("Error reading request body", http.StatusBadRequest)
-	ErrParsingRequestBody = dtos.NewErrorResponse("Error parsing request body", http.StatusBadRequest)
-	ErrCreatingTask       = dtos.NewErrorResponse("Error creating task", http.StatusInternalServerError)
-	ErrGettingTasks       = dtos.NewErrorResponse("Error getting all tasks", http.StatusInternalServerError)
-	ErrParsingTaskID      = dtos.NewErrorResponse("Error parsing task id is not a valid uuid", http.StatusBadRequest)
-	ErrGettingTaskByID    = dtos.NewErrorResponse("Error getting task by id", http.StatusInternalServerError)
-	ErrUpdatingTask       = dtos.NewErrorResponse("Error updating task", http.StatusInternalServerError)
-	ErrDeletingTask       = dtos.NewErrorResponse("Error deleting task", http.StatusInternalServerError)
-	ErrThereAreNoTasks    = dtos.NewErrorResponse("There are no tasks", http.StatusNotFound)
-	ErrTaskNotFound       = dtos.NewErrorResponse("Task not found", http.StatusNotFound)
+	ErrReadingRequestBody  = dtos.NewErrorResponse("Error reading request body", http.StatusBadRequest)
+	ErrRequestBodyTooLarge = dtos.NewErrorResponse("Request body too large", http.StatusRequestEntityTooLarge)
+	ErrParsingRequestBody  = dtos.NewErrorResponse("Error parsing request body", http.StatusBadRequest)
+	ErrCreatingTask        = dtos.NewErrorResponse("Error creating task", http.StatusInternalServerError)
+	ErrGettingTasks        = dtos.NewErrorResponse("Error getting all tasks", http.StatusInternalServerError)
+	ErrParsingTaskID       = dtos.NewErrorResponse("Error parsing task id is not a valid uuid", http.StatusBadRequest)
+	ErrGettingTaskByID     = dtos.NewErrorResponse("Error getting task by id", http.StatusInternalServerError)
+	ErrUpdatingTask        = dtos.NewErrorResponse("Error updating task", http.StatusInternalServerError)
+	ErrDeletingTask        = dtos.NewErrorResponse("Error deleting task", http.StatusInternalServerError)
+	ErrThereAreNoTasks     = dtos.NewErrorResponse("There are no tasks", http.StatusNotFound)
+	ErrTaskNotFound        = dtos.NewErrorResponse("Task not found", http.StatusNotFound)
+	ErrUnknownCommand      = dtos.NewErrorResponse("Unknown command", http.StatusBadRequest)
+	ErrOperationNotAllowed = dtos.NewErrorResponse("Operation not allowed on this task", http.StatusForbidden)
+	ErrInternalServer      = dtos.NewErrorResponse("Internal server error", http.StatusInternalServerError)
+)
+
+//tenancy
+
+var (
+	ErrTenantRequired    = dtos.NewErrorResponse("Tenant is required", http.StatusBadRequest)
+	ErrInvalidTenant     = dtos.NewErrorResponse("Tenant must be lower case letters, digits and dashes", http.StatusBadRequest)
+	ErrTenantMismatch    = dtos.NewErrorResponse("Credentials are not valid for this tenant", http.StatusForbidden)
+	ErrTaskQuotaExceeded = dtos.NewErrorResponse("Task quota of the tenant exceeded", http.StatusForbidden)
+)
+
+//sharing
+
+var (
+	ErrGranteeIsRequired = dtos.NewErrorResponse("Grantee field is required and can't be yourself or the owner", http.StatusBadRequest)
+	ErrInvalidRole       = dtos.NewErrorResponse("Role must be viewer, editor or owner", http.StatusBadRequest)
+	ErrParsingGrantID    = dtos.NewErrorResponse("Error parsing grant id is not a valid uuid", http.StatusBadRequest)
+	ErrGrantNotFound     = dtos.NewErrorResponse("Grant not found", http.StatusNotFound)
+	ErrSharing           = dtos.NewErrorResponse("Error sharing tasks", http.StatusInternalServerError)
+	ErrGettingGrants     = dtos.NewErrorResponse("Error getting grants", http.StatusInternalServerError)
+	ErrRevokingGrant     = dtos.NewErrorResponse("Error revoking grant", http.StatusInternalServerError)
+)
+
+//auth
+
+var (
+	ErrUnauthorized    = dtos.NewErrorResponse("Missing or invalid credentials", http.StatusUnauthorized)
+	ErrForbidden       = dtos.NewErrorResponse("Insufficient scope", http.StatusForbidden)
+	ErrInvalidScopes   = dtos.NewErrorResponse("Scopes must be a non empty list of known scopes", http.StatusBadRequest)
+	ErrNameIsRequired  = dtos.NewErrorResponse("Name field is required", http.StatusBadRequest)
+	ErrInvalidOwner    = dtos.NewErrorResponse("Owner field can't start with apikey:", http.StatusBadRequest)
+	ErrParsingAPIKeyID = dtos.NewErrorResponse("Error parsing api key id is not a valid uuid", http.StatusBadRequest)
+	ErrAPIKeyNotFound  = dtos.NewErrorResponse("API key not found", http.StatusNotFound)
+	ErrAPIKeyRevoked   = dtos.NewErrorResponse("API key is revoked", http.StatusConflict)
+	ErrCreatingAPIKey  = dtos.NewErrorResponse("Error creating api key", http.StatusInternalServerError)
+	ErrGettingAPIKeys  = dtos.NewErrorResponse("Error getting api keys", http.StatusInternalServerError)
+	ErrRevokingAPIKey  = dtos.NewErrorResponse("Error revoking api key", http.StatusInternalServerError)
+	ErrRotatingAPIKey  = dtos.NewErrorResponse("Error rotating api key", http.StatusInternalServerError)
+)
+
+//rate limit
+
+var (
+	ErrTooManyRequests = dtos.NewErrorResponse("Too many requests, retry later", http.StatusTooManyRequests)
+)
+
+//idempotency
+
+var (
+	ErrInvalidIdempotencyKey = dtos.NewErrorResponse("Idempotency-Key must be between 1 and 255 characters", http.StatusBadRequest)
+	ErrIdempotencyKeyReused  = dtos.NewErrorResponse("Idempotency-Key was already used with another request", http.StatusUnprocessableEntity)
+)
+
+//stats
+
+var (
+	ErrGettingStats = dtos.NewErrorResponse("Error getting task statistics", http.StatusInternalServerError)
+)
+
+//export and import
+
+var (
+	ErrImportingTasks    = dtos.NewErrorResponse("Error importing tasks", http.StatusInternalServerError)
+	ErrImportingCalendar = dtos.NewErrorResponse("Error importing calendar", http.StatusInternalServerError)
 )
 
 //params
 
 var (
 	ErrTitleFieldIsRequired = dtos.NewErrorResponse("Title field is required", http.StatusBadRequest)
+	ErrFieldWithoutName     = dtos.NewErrorResponse("Request body has a field without a name", http.StatusBadRequest)
 )
+
+// fieldErrors keeps the legacy messages of the field errors that had one.
+var fieldErrors = map[dtos.FieldError]*dtos.ErrorResponse{
+	{Field: "title", Reason: "is required"}:                                    ErrTitleFieldIsRequired,
+	{Field: "name", Reason: "is required"}:                                     ErrNameIsRequired,
+	{Field: "scopes", Reason: "must be a non empty list of known scopes"}:      ErrInvalidScopes,
+	{Field: "tenant", Reason: "must be lower case letters, digits and dashes"}: ErrInvalidTenant,
+	{Field: "grantee", Reason: "is required"}:                                  ErrGranteeIsRequired,
+	{Field: "role", Reason: "must be viewer, editor or owner"}:                 ErrInvalidRole,
+	{Field: "body", Reason: "has a field without a name"}:                      ErrFieldWithoutName,
+}
+
+// Validation lists every invalid field, the legacy message describes the
+// first one.
+func Validation(errs []dtos.FieldError) *dtos.ErrorResponse {
+	if len(errs) == 0 {
+		return ErrParsingRequestBody
+	}
+	errorResponse, ok := fieldErrors[errs[0]]
+	if !ok {
+		errorResponse = dtos.NewErrorResponse(fieldMessage(errs[0].Field, errs[0].Reason), http.StatusBadRequest)
+	}
+	return errorResponse.WithErrors(errs)
+}
+
+// fieldMessage describes a field error without a legacy message. Names made
+// of letters only are capitalized, the others, e.g. snake_case, are kept as
+// sent.
+func fieldMessage(field string, reason string) string {
+	if field == "" {
+		return "Request " + reason
+	}
+	if strings.IndexFunc(field, func(r rune) bool { return !unicode.IsLetter(r) }) >= 0 {
+		return field + " field " + reason
+	}
+	first, size := utf8.DecodeRuneInString(field)
+	return string(unicode.ToUpper(first)) + field[size:] + " field " + reason
+}
+
+// Invariant reports the task invariant broken by err like an invalid field.
+func Invariant(err error) (*dtos.ErrorResponse, bool) {
+	var invariantErr *domain.InvariantError
+	if !errors.As(err, &invariantErr) {
+		return nil, false
+	}
+	return Validation([]dtos.FieldError{{Field: invariantErr.Field, Reason: invariantErr.Reason}}), true
+}
@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+	"github.com/manuelbeos/code-branch-todo-test/internal/version"
+)
+
+// checkTimeout bounds every readiness probe so a stuck dependency can't hang
+// the orchestrator calling /readyz.
+const checkTimeout = 2 * time.Second
+
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+type HealthHandler struct {
+	build  version.Info
+	ready  atomic.Bool
+	mu     sync.RWMutex
+	checks []check
+}
+
+// NewHealthHandler starts not ready, SetReady(true) is called once the server
+// is accepting traffic.
+func NewHealthHandler(build version.Info) *HealthHandler {
+	return &HealthHandler{build: build}
+}
+
+// AddCheck registers a dependency probed by the readiness endpoint.
+func (hh *HealthHandler) AddCheck(name string, fn CheckFunc) {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+	hh.checks = append(hh.checks, check{name: name, fn: fn})
+}
+
+// SetReady flips readiness, the server sets it to false as soon as a graceful
+// shutdown starts so load balancers stop sending new requests.
+func (hh *HealthHandler) SetReady(ready bool) {
+	hh.ready.Store(ready)
+}
+
+func (hh *HealthHandler) Ready() bool {
+	return hh.ready.Load()
+}
+
+// Liveness only tells whether the process is able to answer.
+func (hh *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	handler_utils.HandlerSuccessResponse(w, http.StatusOK, dtos.HealthResponse{Status: dtos.StatusUp, Build: hh.build})
+}
+
+// Readiness probes every registered dependency concurrently.
+func (hh *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	hh.mu.RLock()
+	checks := append([]check(nil), hh.checks...)
+	hh.mu.RUnlock()
+
+	components := make(map[string]dtos.ComponentStatus, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+
+			status := dtos.ComponentStatus{Status: dtos.StatusUp}
+			if err := c.fn(ctx); err != nil {
+				status = dtos.ComponentStatus{Status: dtos.StatusDown, Error: err.Error()}
+			}
+
+			mu.Lock()
+			components[c.name] = status
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	response := dtos.HealthResponse{Status: dtos.StatusUp, Components: components, Build: hh.build}
+	statusCode := http.StatusOK
+
+	for _, component := range components {
+		if component.Status != dtos.StatusUp {
+			response.Status = dtos.StatusDown
+			statusCode = http.StatusServiceUnavailable
+		}
+	}
+
+	if !hh.Ready() {
+		response.Status = dtos.StatusNotReady
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	handler_utils.HandlerSuccessResponse(w, statusCode, response)
+}
+
+func (hh *HealthHandler) RegisterEndpoints(r *mux.Router) {
+	r.HandleFunc("/healthz", hh.Liveness).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", hh.Readiness).Methods(http.MethodGet)
+}
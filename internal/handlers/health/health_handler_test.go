@@ -0,0 +1,111 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	"github.com/manuelbeos/code-branch-todo-test/internal/mocks"
+	"github.com/manuelbeos/code-branch-todo-test/internal/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, handler *HealthHandler, path string) (int, dtos.HealthResponse) {
+	router := mux.NewRouter()
+	handler.RegisterEndpoints(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	response := dtos.HealthResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	return w.Code, response
+}
+
+func TestHealthHandler_Liveness(t *testing.T) {
+	asserts := assert.New(t)
+	handler := NewHealthHandler(version.Info{Version: "v1.0.0", GoVersion: "go"})
+
+	code, response := serve(t, handler, "/healthz")
+
+	asserts.Equal(http.StatusOK, code)
+	asserts.Equal(dtos.StatusUp, response.Status)
+	asserts.Equal("v1.0.0", response.Build.Version)
+}
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	asserts := assert.New(t)
+	mockError := errors.New("connection refused")
+
+	tests := []struct {
+		name               string
+		ready              bool
+		pingError          error
+		expectedStatusCode int
+		expectedStatus     string
+		expectedComponent  dtos.ComponentStatus
+	}{
+		{
+			name:               "Readiness - Success",
+			ready:              true,
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     dtos.StatusUp,
+			expectedComponent:  dtos.ComponentStatus{Status: dtos.StatusUp},
+		},
+		{
+			name:               "Readiness - Repository down",
+			ready:              true,
+			pingError:          mockError,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     dtos.StatusDown,
+			expectedComponent:  dtos.ComponentStatus{Status: dtos.StatusDown, Error: "connection refused"},
+		},
+		{
+			name:               "Readiness - Shutting down",
+			ready:              false,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     dtos.StatusNotReady,
+			expectedComponent:  dtos.ComponentStatus{Status: dtos.StatusUp},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewTodoListRepository(t)
+			mockRepo.On("Ping", mock.Anything).Return(tt.pingError)
+
+			handler := NewHealthHandler(version.Get())
+			handler.AddCheck("repository", mockRepo.Ping)
+			handler.SetReady(tt.ready)
+
+			code, response := serve(t, handler, "/readyz")
+
+			asserts.Equal(tt.expectedStatusCode, code)
+			asserts.Equal(tt.expectedStatus, response.Status)
+			asserts.Equal(tt.expectedComponent, response.Components["repository"])
+		})
+	}
+}
+
+func TestHealthHandler_Readiness_Check_Timeout(t *testing.T) {
+	asserts := assert.New(t)
+	handler := NewHealthHandler(version.Get())
+	handler.SetReady(true)
+	handler.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	code, response := serve(t, handler, "/readyz")
+
+	asserts.Equal(http.StatusServiceUnavailable, code)
+	asserts.Equal(context.DeadlineExceeded.Error(), response.Components["slow"].Error)
+}
@@ -1,8 +1,11 @@
 package mappers
 
 import (
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
 	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
 	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	"github.com/manuelbeos/code-branch-todo-test/internal/validation"
 )
 
 func MapperUpdateTaskRequestToTaskEntity(updateReq dtos.UpdateTaskRequestDto) entity.Task {
@@ -11,5 +14,99 @@ func MapperUpdateTaskRequestToTaskEntity(updateReq dtos.UpdateTaskRequestDto) en
 		Title:       updateReq.Title,
 		Description: updateReq.Description,
 		IsCompleted: updateReq.IsCompleted,
+		Priority:    entity.Priority(updateReq.Priority),
+		Tags:        updateReq.Tags,
+		DueAt:       updateReq.DueAt,
 	}
 }
+
+func MapperCreateTaskRequestToTaskEntity(createReq dtos.CreateTaskRequestDto) entity.Task {
+	return entity.Task{
+		Title:       createReq.Title,
+		Description: createReq.Description,
+		Priority:    entity.Priority(createReq.Priority),
+		Tags:        createReq.Tags,
+		DueAt:       createReq.DueAt,
+	}
+}
+
+func MapperAPIKeyEntityToResponse(key *entity.APIKey, plain string) dtos.APIKeyResponseDto {
+	return dtos.APIKeyResponseDto{
+		Id:        key.Id,
+		Name:      key.Name,
+		Owner:     key.Owner,
+		Tenant:    key.Tenant,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		Key:       plain,
+		CreatedAt: key.CreatedAt,
+		RotatedAt: key.RotatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+func MapperTaskStatsToResponse(stats *service.TaskStats) dtos.TaskStatsResponseDto {
+	periods := make([]dtos.PeriodStatsDto, 0, len(stats.Periods))
+	for _, period := range stats.Periods {
+		periods = append(periods, dtos.PeriodStatsDto{
+			Start:     period.Start.Format(validation.DateLayout),
+			Created:   period.Created,
+			Completed: period.Completed,
+		})
+	}
+
+	byPriority := make(map[string]dtos.TaskCountDto, len(stats.ByPriority))
+	for priority, count := range stats.ByPriority {
+		byPriority[string(priority)] = dtos.TaskCountDto{Total: count.Total, Completed: count.Completed}
+	}
+	byTag := make(map[string]dtos.TaskCountDto, len(stats.ByTag))
+	for tag, count := range stats.ByTag {
+		byTag[tag] = dtos.TaskCountDto{Total: count.Total, Completed: count.Completed}
+	}
+
+	return dtos.TaskStatsResponseDto{
+		Total:                        stats.Total,
+		Completed:                    stats.Completed,
+		Pending:                      stats.Pending,
+		CompletionRate:               stats.CompletionRate,
+		AverageTimeToCompleteSeconds: stats.AverageTimeToComplete.Seconds(),
+		GroupBy:                      string(stats.GroupBy),
+		Periods:                      periods,
+		ByPriority:                   byPriority,
+		ByTag:                        byTag,
+	}
+}
+
+func MapperImportResultToResponse(result *service.ImportResult, dryRun bool, total int) dtos.ImportResultResponseDto {
+	return dtos.ImportResultResponseDto{
+		DryRun:     dryRun,
+		Total:      total,
+		Imported:   result.Imported,
+		Duplicates: result.Duplicates,
+		Failed:     result.Failed,
+		Errors:     mapImportErrors(result.Errors),
+	}
+}
+
+func MapperUpsertResultToResponse(result *service.UpsertResult, total int) dtos.CalendarImportResponseDto {
+	return dtos.CalendarImportResponseDto{
+		Total:   total,
+		Created: result.Created,
+		Updated: result.Updated,
+		Failed:  result.Failed,
+		Errors:  mapImportErrors(result.Errors),
+	}
+}
+
+func mapImportErrors(errors []service.ImportError) []dtos.ImportErrorDto {
+	importErrors := make([]dtos.ImportErrorDto, 0, len(errors))
+	for _, importErr := range errors {
+		importError := dtos.ImportErrorDto{Row: importErr.Row, Field: importErr.Field, Reason: importErr.Reason}
+		if importErr.Id != uuid.Nil {
+			id := importErr.Id
+			importError.Id = &id
+		}
+		importErrors = append(importErrors, importError)
+	}
+	return importErrors
+}
@@ -0,0 +1,113 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+// credentials reads an API key from X-API-Key or "Authorization: ApiKey", or
+// a JWT from "Authorization: Bearer".
+func credentials(r *http.Request) (apiKey string, token string) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key, ""
+	}
+
+	scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok {
+		return "", ""
+	}
+	switch {
+	case strings.EqualFold(scheme, "ApiKey"):
+		return strings.TrimSpace(value), ""
+	case strings.EqualFold(scheme, "Bearer"):
+		return "", strings.TrimSpace(value)
+	}
+
+	return "", ""
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Add("WWW-Authenticate", `Bearer realm="todo-list-api"`)
+	w.Header().Add("WWW-Authenticate", `ApiKey realm="todo-list-api"`)
+	handler_utils.HandlerErrorResponse(w, http.StatusUnauthorized, error_response.ErrUnauthorized)
+}
+
+// AuthenticationMiddleware stores the principal of requests carrying an API
+// key or a bearer token in their context, together with the identity the
+// repositories scope tasks to. Requests without credentials pass through
+// anonymous, RequireScope decides whether the route needs them; invalid
+// credentials are rejected right away. A nil apiKeys or tokens disables that
+// kind of credential.
+func AuthenticationMiddleware(apiKeys APIKeyAuthenticator, tokens TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey, token := credentials(r)
+
+			var principal *auth.Principal
+			var err error
+			switch {
+			case apiKey != "" && apiKeys != nil:
+				principal, err = apiKeys.Authenticate(r.Context(), apiKey)
+			case token != "" && tokens != nil:
+				principal, err = tokens.Verify(r.Context(), token)
+			case apiKey != "" || token != "":
+				unauthorized(w)
+				return
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				unauthorized(w)
+				return
+			}
+
+			ctx := auth.NewContext(r.Context(), principal)
+			ctx = identity.WithOwner(ctx, principal.Subject)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AuthenticationDisabledMiddleware replaces AuthenticationMiddleware when
+// authentication is disabled, it marks every request so the repositories
+// share all the tasks between them.
+func AuthenticationDisabledMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(identity.WithoutAuthentication(r.Context())))
+	})
+}
+
+// RequireScope is the auth.Guard used when authentication is enabled, it
+// answers 401 to anonymous requests and 403 to principals missing scope.
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.FromContext(r.Context())
+		if principal == nil {
+			unauthorized(w)
+			return
+		}
+		if !principal.HasScope(scope) {
+			handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
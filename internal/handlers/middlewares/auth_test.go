@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuthenticator map[string]*auth.Principal
+
+func (fa fakeAuthenticator) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	principal, ok := fa[key]
+	if !ok {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	return principal, nil
+}
+
+func (fa fakeAuthenticator) Verify(ctx context.Context, token string) (*auth.Principal, error) {
+	principal, ok := fa["token:"+token]
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+	return principal, nil
+}
+
+func TestAuthenticationMiddleware_RequireScope(t *testing.T) {
+	asserts := assert.New(t)
+
+	authenticator := fakeAuthenticator{
+		"reader":    {Subject: "apikey:reader", Scopes: []string{auth.ScopeTasksRead}},
+		"writer":    {Subject: "apikey:writer", Scopes: []string{auth.ScopeTasksRead, auth.ScopeTasksWrite}},
+		"token:jwt": {Subject: "alice", Scopes: []string{auth.ScopeTasksWrite}},
+	}
+	handler := AuthenticationMiddleware(authenticator, authenticator)(RequireScope(auth.ScopeTasksWrite, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(identity.OwnerFromContext(r.Context())))
+	}))
+
+	tests := []struct {
+		name               string
+		headers            map[string]string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{name: "RequireScope - Missing key", expectedStatusCode: http.StatusUnauthorized, expectedResponse: `{"message":"Missing or invalid credentials","code":401}`},
+		{name: "RequireScope - Unknown key", headers: map[string]string{APIKeyHeader: "nope"}, expectedStatusCode: http.StatusUnauthorized, expectedResponse: `{"message":"Missing or invalid credentials","code":401}`},
+		{name: "RequireScope - Missing scope", headers: map[string]string{APIKeyHeader: "reader"}, expectedStatusCode: http.StatusForbidden, expectedResponse: `{"message":"Insufficient scope","code":403}`},
+		{name: "RequireScope - Success header", headers: map[string]string{APIKeyHeader: "writer"}, expectedStatusCode: http.StatusOK, expectedResponse: "apikey:writer"},
+		{name: "RequireScope - Success authorization", headers: map[string]string{"Authorization": "ApiKey writer"}, expectedStatusCode: http.StatusOK, expectedResponse: "apikey:writer"},
+		{name: "RequireScope - Success bearer", headers: map[string]string{"Authorization": "Bearer jwt"}, expectedStatusCode: http.StatusOK, expectedResponse: "alice"},
+		{name: "RequireScope - Invalid bearer", headers: map[string]string{"Authorization": "Bearer forged"}, expectedStatusCode: http.StatusUnauthorized, expectedResponse: `{"message":"Missing or invalid credentials","code":401}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+			if tt.expectedStatusCode == http.StatusUnauthorized {
+				asserts.NotEmpty(rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAuthenticationMiddleware_Anonymous_Open_Route(t *testing.T) {
+	asserts := assert.New(t)
+	handler := AuthenticationMiddleware(fakeAuthenticator{}, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asserts.Nil(auth.FromContext(r.Context()))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	asserts.Equal(http.StatusOK, rr.Code)
+}
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"net/http"
+
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+)
+
+// MaxBodyBytesMiddleware rejects requests announcing a body bigger than limit
+// and caps the others, so handlers reading past the limit get an
+// *http.MaxBytesError instead of buffering an unbounded body.
+func MaxBodyBytesMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				handler_utils.HandlerErrorResponse(w, http.StatusRequestEntityTooLarge, error_response.ErrRequestBodyTooLarge)
+				return
+			}
+
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
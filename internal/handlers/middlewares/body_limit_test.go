@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBodyBytesMiddleware(t *testing.T) {
+	asserts := assert.New(t)
+
+	tests := []struct {
+		name               string
+		body               string
+		unknownLength      bool
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{name: "MaxBodyBytes - Within limit", body: `{"title":"t"}`, expectedStatusCode: http.StatusOK, expectedResponse: `{"title":"t"}`},
+		{name: "MaxBodyBytes - Content-Length over limit", body: strings.Repeat("a", 32), expectedStatusCode: http.StatusRequestEntityTooLarge, expectedResponse: `{"message":"Request body too large","code":413}`},
+		{name: "MaxBodyBytes - Streamed body over limit", body: strings.Repeat("a", 32), unknownLength: true, expectedStatusCode: http.StatusRequestEntityTooLarge, expectedResponse: "too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := MaxBodyBytesMiddleware(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					_, _ = w.Write([]byte("too large"))
+					return
+				}
+				_, _ = w.Write(body)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tt.body))
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			asserts.Equal(tt.expectedStatusCode, w.Code)
+			asserts.Equal(tt.expectedResponse, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSConfig struct {
+	// AllowedOrigins accepts exact origins, "*" for any origin and patterns
+	// with a single wildcard such as "https://*.example.com".
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+type originPattern struct {
+	prefix string
+	suffix string
+}
+
+type cors struct {
+	config    CORSConfig
+	allowAll  bool
+	exact     map[string]struct{}
+	patterns  []originPattern
+	methods   map[string]struct{}
+	headers   map[string]struct{}
+	anyHeader bool
+}
+
+func newCORS(cfg CORSConfig) *cors {
+	c := &cors{
+		config:  cfg,
+		exact:   make(map[string]struct{}),
+		methods: make(map[string]struct{}),
+		headers: make(map[string]struct{}),
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			c.allowAll = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			c.patterns = append(c.patterns, originPattern{prefix: prefix, suffix: suffix})
+		default:
+			c.exact[origin] = struct{}{}
+		}
+	}
+	for _, method := range cfg.AllowedMethods {
+		c.methods[strings.ToUpper(method)] = struct{}{}
+	}
+	for _, header := range cfg.AllowedHeaders {
+		if header == "*" {
+			c.anyHeader = true
+		}
+		c.headers[http.CanonicalHeaderKey(header)] = struct{}{}
+	}
+
+	return c
+}
+
+func (c *cors) originAllowed(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if _, ok := c.exact[origin]; ok {
+		return true
+	}
+	for _, p := range c.patterns {
+		if len(origin) > len(p.prefix)+len(p.suffix) && strings.HasPrefix(origin, p.prefix) && strings.HasSuffix(origin, p.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *cors) headersAllowed(requested string) bool {
+	if c.anyHeader || requested == "" {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		if _, ok := c.headers[http.CanonicalHeaderKey(strings.TrimSpace(header))]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *cors) setOrigin(h http.Header, origin string) {
+	// browsers reject "*" on credentialed requests, the origin is echoed instead
+	if c.allowAll && !c.config.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+	}
+
+	if c.config.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// CORSMiddleware answers preflight requests and adds the CORS headers to the
+// responses for allowed origins. It has to wrap the router itself, not be
+// registered with Router.Use, because mux only runs middlewares on matched
+// routes and preflight OPTIONS requests don't match any.
+func CORSMiddleware(cfg CORSConfig) func(http.Handler) http.Handler {
+	c := newCORS(cfg)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if origin == "" || !c.originAllowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+
+			if !preflight {
+				c.setOrigin(h, origin)
+				if len(cfg.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+
+			method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			requestedHeaders := r.Header.Get("Access-Control-Request-Headers")
+			_, methodAllowed := c.methods[method]
+			if !methodAllowed || !c.headersAllowed(requestedHeaders) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			c.setOrigin(h, origin)
+			h.Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+			if requestedHeaders != "" {
+				// echoing the request is what makes the "*" header wildcard
+				// work with credentials too
+				h.Set("Access-Control-Allow-Headers", requestedHeaders)
+			}
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	asserts := assert.New(t)
+
+	cfg := CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.preview.example.com"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders: []string{"Content-Type", "X-Request-ID"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name               string
+		config             CORSConfig
+		method             string
+		origin             string
+		requestMethod      string
+		requestHeaders     string
+		expectedStatusCode int
+		expectedHeaders    map[string]string
+		reachesHandler     bool
+	}{
+		{
+			name:               "CORS - No origin",
+			config:             cfg,
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": ""},
+			reachesHandler:     true,
+		},
+		{
+			name:               "CORS - Allowed origin",
+			config:             cfg,
+			method:             http.MethodGet,
+			origin:             "https://app.example.com",
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example.com",
+				"Access-Control-Expose-Headers": "X-Request-ID",
+				"Vary":                          "Origin",
+			},
+			reachesHandler: true,
+		},
+		{
+			name:               "CORS - Wildcard origin",
+			config:             cfg,
+			method:             http.MethodGet,
+			origin:             "https://pr-42.preview.example.com",
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": "https://pr-42.preview.example.com"},
+			reachesHandler:     true,
+		},
+		{
+			name:               "CORS - Disallowed origin",
+			config:             cfg,
+			method:             http.MethodGet,
+			origin:             "https://evil.com",
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": ""},
+			reachesHandler:     true,
+		},
+		{
+			name:               "CORS - Preflight",
+			config:             cfg,
+			method:             http.MethodOptions,
+			origin:             "https://app.example.com",
+			requestMethod:      http.MethodPost,
+			requestHeaders:     "content-type, x-request-id",
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "content-type, x-request-id",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:               "CORS - Preflight method not allowed",
+			config:             cfg,
+			method:             http.MethodOptions,
+			origin:             "https://app.example.com",
+			requestMethod:      http.MethodDelete,
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:               "CORS - Preflight header not allowed",
+			config:             cfg,
+			method:             http.MethodOptions,
+			origin:             "https://app.example.com",
+			requestMethod:      http.MethodPost,
+			requestHeaders:     "X-Secret",
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:               "CORS - Any origin",
+			config:             CORSConfig{AllowedOrigins: []string{"*"}},
+			method:             http.MethodGet,
+			origin:             "https://anything.com",
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"Access-Control-Allow-Origin": "*"},
+			reachesHandler:     true,
+		},
+		{
+			name:               "CORS - Any origin with credentials",
+			config:             CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method:             http.MethodGet,
+			origin:             "https://anything.com",
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://anything.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+			reachesHandler: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			handler := CORSMiddleware(tt.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+			}))
+
+			req := httptest.NewRequest(tt.method, "/tasks", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			if tt.requestHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.requestHeaders)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			asserts.Equal(tt.expectedStatusCode, w.Code)
+			asserts.Equal(tt.reachesHandler, reached)
+			for header, value := range tt.expectedHeaders {
+				asserts.Equal(value, w.Header().Get(header), header)
+			}
+		})
+	}
+}
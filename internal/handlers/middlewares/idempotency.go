@@ -0,0 +1,137 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+	"github.com/manuelbeos/code-branch-todo-test/internal/idempotency"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// responseCapture copies what is written to the client.
+type responseCapture struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rc *responseCapture) WriteHeader(code int) {
+	rc.statusCode = code
+	rc.ResponseWriter.WriteHeader(code)
+}
+
+func (rc *responseCapture) Write(b []byte) (int, error) {
+	rc.body.Write(b)
+	return rc.ResponseWriter.Write(b)
+}
+
+func (rc *responseCapture) Unwrap() http.ResponseWriter {
+	return rc.ResponseWriter
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replayedHeaders keeps the headers describing the response, the others
+// (request id, rate limit...) belong to each request.
+func replayedHeaders(header http.Header) http.Header {
+	replayed := http.Header{}
+	for _, name := range []string{"Content-Type", "Location"} {
+		if values := header.Values(name); len(values) > 0 {
+			replayed[name] = values
+		}
+	}
+	return replayed
+}
+
+// Idempotency makes a route safe to retry with an Idempotency-Key header: the
+// first response is stored and replayed to the retries with the same key and
+// body, flagged with Idempotent-Replayed. Duplicates arriving while the first
+// request is in flight wait for it. Server errors are not stored, so the
+// request can be retried. Keys are scoped to the tenant and caller, requests
+// without one are not affected.
+func Idempotency(store *idempotency.MemoryStore) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			header, ok := r.Header[IdempotencyKeyHeader]
+			if !ok {
+				next(w, r)
+				return
+			}
+			if len(header) != 1 || header[0] == "" || len(header[0]) > maxIdempotencyKeyLength {
+				handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrInvalidIdempotencyKey)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					handler_utils.HandlerErrorResponse(w, http.StatusRequestEntityTooLarge, error_response.ErrRequestBodyTooLarge)
+					return
+				}
+
+				handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrReadingRequestBody)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			key := identity.TenantFromContext(ctx) + "\x00" + identity.OwnerFromContext(ctx) + "\x00" + header[0]
+			stored, err := store.Start(ctx, key, fingerprint(r, body))
+			if err != nil {
+				if errors.Is(err, idempotency.ErrKeyReused) {
+					handler_utils.HandlerErrorResponse(w, http.StatusUnprocessableEntity, error_response.ErrIdempotencyKeyReused)
+				}
+				// otherwise the client went away while waiting
+				return
+			}
+
+			if stored != nil {
+				for name, values := range stored.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(stored.StatusCode)
+				_, _ = w.Write(stored.Body)
+				return
+			}
+
+			capture := &responseCapture{ResponseWriter: w, statusCode: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					store.Abort(key)
+				}
+			}()
+			next(capture, r)
+			completed = true
+
+			if capture.statusCode >= http.StatusInternalServerError {
+				store.Abort(key)
+				return
+			}
+			store.Finish(key, idempotency.Response{
+				StatusCode: capture.statusCode,
+				Header:     replayedHeaders(w.Header()),
+				Body:       capture.body.Bytes(),
+			})
+		}
+	}
+}
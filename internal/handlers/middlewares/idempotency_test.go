@@ -0,0 +1,102 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/idempotency"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	asserts := assert.New(t)
+	var calls atomic.Int32
+	handler := Idempotency(idempotency.NewMemoryStore(time.Hour))(func(w http.ResponseWriter, r *http.Request) {
+		call := calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-ID", strconv.Itoa(int(call)))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(int(call)) + `}`))
+	})
+
+	send := func(key string, body string, owner string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		req = req.WithContext(identity.WithOwner(req.Context(), owner))
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	rr := send("retry", `{"title":"a"}`, "alice")
+	asserts.Equal(http.StatusCreated, rr.Code)
+	asserts.Equal(`{"call":1}`, rr.Body.String())
+	asserts.Empty(rr.Header().Get(IdempotentReplayedHeader))
+
+	rr = send("retry", `{"title":"a"}`, "alice")
+	asserts.Equal(http.StatusCreated, rr.Code)
+	asserts.Equal(`{"call":1}`, rr.Body.String())
+	asserts.Equal("true", rr.Header().Get(IdempotentReplayedHeader))
+	asserts.Equal("application/json", rr.Header().Get("Content-Type"))
+	asserts.Empty(rr.Header().Get("X-Request-ID"))
+
+	rr = send("retry", `{"title":"b"}`, "alice")
+	asserts.Equal(http.StatusUnprocessableEntity, rr.Code)
+	asserts.Equal(`{"message":"Idempotency-Key was already used with another request","code":422}`, rr.Body.String())
+
+	// keys are scoped to the caller
+	asserts.Equal(`{"call":2}`, send("retry", `{"title":"a"}`, "bob").Body.String())
+
+	// without a key every request goes through
+	asserts.Equal(`{"call":3}`, send("", `{"title":"a"}`, "alice").Body.String())
+
+	rr = send(strings.Repeat("k", 256), `{"title":"a"}`, "alice")
+	asserts.Equal(http.StatusBadRequest, rr.Code)
+
+	// concurrent duplicates wait for the first one
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bodies[i] = send("concurrent", `{"title":"c"}`, "alice").Body.String()
+		}()
+	}
+	wg.Wait()
+	for _, body := range bodies {
+		asserts.Equal(`{"call":4}`, body)
+	}
+	asserts.Equal(int32(4), calls.Load())
+}
+
+func TestIdempotency_Server_Errors_Are_Not_Stored(t *testing.T) {
+	asserts := assert.New(t)
+	var calls atomic.Int32
+	handler := Idempotency(idempotency.NewMemoryStore(time.Hour))(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	for _, expected := range []int{http.StatusInternalServerError, http.StatusCreated, http.StatusCreated} {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key")
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		asserts.Equal(expected, rr.Code)
+	}
+	asserts.Equal(int32(2), calls.Load())
+}
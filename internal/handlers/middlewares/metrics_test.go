@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddleware_UsesRouteTemplate(t *testing.T) {
+	asserts := assert.New(t)
+	m := metrics.New()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		asserts.Equal(float64(1), testutil.ToFloat64(m.HTTPRequestsInFlight))
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods(http.MethodGet)
+	router.Use(MetricsMiddleware(m))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/tasks/"+uuid.NewString(), nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	asserts.Equal(float64(2), testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues(http.MethodGet, "/tasks/{id}", "404")))
+	var duration dto.Metric
+	require.NoError(t, m.HTTPRequestDuration.WithLabelValues(http.MethodGet, "/tasks/{id}", "404").(prometheus.Metric).Write(&duration))
+	asserts.Equal(uint64(2), duration.GetHistogram().GetSampleCount())
+	asserts.Equal(float64(0), testutil.ToFloat64(m.HTTPRequestsInFlight))
+}
@@ -1,17 +1,66 @@
 package middlewares
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
+	"errors"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/requestid"
 )
 
+const redactedValue = "[REDACTED]"
+
+type LoggingConfig struct {
+	// Level is one of debug, info, warn or error.
+	Level string
+	// Format is either json or text.
+	Format string
+	// LogBodies enables logging of request and response bodies, each one
+	// truncated to MaxBodyBytes.
+	LogBodies    bool
+	MaxBodyBytes int
+	// RedactFields are JSON keys whose values are never logged, matched
+	// case-insensitively at any depth of the body.
+	RedactFields []string
+}
+
+// NewLogger builds a slog.Logger writing to w with the configured level and format.
+func NewLogger(w io.Writer, cfg LoggingConfig) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.Level)}
+
+	if strings.EqualFold(cfg.Format, "text") {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
 type responseLogger struct {
 	http.ResponseWriter
 	statusCode int
-	body       bytes.Buffer
+	bytes      int
+	body       cappedBuffer
 }
 
 func (rw *responseLogger) WriteHeader(code int) {
@@ -21,25 +70,172 @@ func (rw *responseLogger) WriteHeader(code int) {
 
 func (rw *responseLogger) Write(body []byte) (int, error) {
 	rw.body.Write(body)
-	return rw.ResponseWriter.Write(body)
+	n, err := rw.ResponseWriter.Write(body)
+	rw.bytes += n
+	return n, err
+}
+
+func (rw *responseLogger) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
 }
 
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+// Hijack lets websocket upgrades go through the logger.
+func (rw *responseLogger) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not implement http.Hijacker")
+	}
+	rw.statusCode = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// cappedBuffer keeps the first limit bytes written to it and remembers
+// whether anything was left out. A zero limit discards everything.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (cb *cappedBuffer) Write(p []byte) {
+	room := cb.limit - cb.buf.Len()
+	if len(p) > room {
+		p = p[:max(room, 0)]
+		cb.truncated = true
+	}
+	cb.buf.Write(p)
+}
+
+// requestBodyLogger copies what the handler reads from the body, so the body
+// is never buffered beyond the configured size.
+type requestBodyLogger struct {
+	io.ReadCloser
+	body *cappedBuffer
+}
+
+func (rb *requestBodyLogger) Read(p []byte) (int, error) {
+	n, err := rb.ReadCloser.Read(p)
+	rb.body.Write(p[:n])
+	return n, err
+}
+
+func LoggingMiddleware(logger *slog.Logger, cfg LoggingConfig) func(http.Handler) http.Handler {
+	redactor := newRedactor(cfg.RedactFields)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
 
-		var requestBody bytes.Buffer
-		if r.Body != nil {
-			bodyBytes, _ := io.ReadAll(r.Body)
-			requestBody.Write(bodyBytes)
-			r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+			bodyLimit := 0
+			if cfg.LogBodies {
+				bodyLimit = cfg.MaxBodyBytes
+			}
+
+			requestBody := &cappedBuffer{limit: bodyLimit}
+			if cfg.LogBodies && r.Body != nil {
+				r.Body = &requestBodyLogger{ReadCloser: r.Body, body: requestBody}
+			}
+
+			respLogger := &responseLogger{ResponseWriter: w, statusCode: http.StatusOK, body: cappedBuffer{limit: bodyLimit}}
+
+			next.ServeHTTP(respLogger, r)
+
+			attrs := []slog.Attr{
+				slog.String("request_id", requestid.FromContext(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", respLogger.statusCode),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int("bytes", respLogger.bytes),
+				slog.String("remote_addr", r.RemoteAddr),
+			}
+
+			if cfg.LogBodies {
+				attrs = append(attrs,
+					slog.String("request_body", redactor.redact(requestBody)),
+					slog.String("response_body", redactor.redact(&respLogger.body)),
+				)
+			}
+
+			logger.LogAttrs(r.Context(), levelForStatus(respLogger.statusCode), "http request", attrs...)
+		})
+	}
+}
+
+func levelForStatus(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type redactor struct {
+	fields  map[string]struct{}
+	pattern *regexp.Regexp
+}
+
+func newRedactor(fields []string) *redactor {
+	r := &redactor{fields: make(map[string]struct{}, len(fields))}
+	if len(fields) == 0 {
+		return r
+	}
+
+	quoted := make([]string, 0, len(fields))
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(field))
+	}
+
+	// used on bodies that aren't valid JSON, typically because they were truncated
+	r.pattern = regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+
+	return r
+}
+
+func (r *redactor) redact(body *cappedBuffer) string {
+	raw := body.buf.Bytes()
+	if len(raw) == 0 {
+		return ""
+	}
+
+	result := string(raw)
+	if len(r.fields) > 0 {
+		var data interface{}
+		if err := json.Unmarshal(raw, &data); err == nil {
+			if redacted, err := json.Marshal(r.redactValue(data)); err == nil {
+				result = string(redacted)
+			}
+		} else {
+			result = r.pattern.ReplaceAllString(result, `${1}"`+redactedValue+`"`)
 		}
+	}
+
+	if body.truncated {
+		result += "...(truncated)"
+	}
 
-		respLogger := &responseLogger{ResponseWriter: w, statusCode: http.StatusOK}
+	return result
+}
 
-		next.ServeHTTP(respLogger, r)
+func (r *redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if _, ok := r.fields[strings.ToLower(key)]; ok {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = r.redactValue(inner)
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = r.redactValue(inner)
+		}
+	}
 
-		log.Printf("→ %s %s | Body: %s", r.Method, r.URL.Path, requestBody.String())
-		log.Printf("← %d %s | Response: %s | Duration: %s", respLogger.statusCode, http.StatusText(respLogger.statusCode), respLogger.body.String(), time.Since(start))
-	})
+	return value
 }
@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/config"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultLoggingConfig is the logging configuration a server gets by default.
+func defaultLoggingConfig() LoggingConfig {
+	defaults := config.Default().Logging
+	return LoggingConfig{
+		Level:        defaults.Level,
+		Format:       defaults.Format,
+		LogBodies:    defaults.LogBodies,
+		MaxBodyBytes: defaults.MaxBodyBytes,
+		RedactFields: defaults.RedactFields,
+	}
+}
+
+func serveLogged(t *testing.T, cfg LoggingConfig, status int, reqBody string, respBody string) map[string]interface{} {
+	output := &bytes.Buffer{}
+	logger := NewLogger(output, cfg)
+
+	handler := LoggingMiddleware(logger, cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(respBody))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(reqBody))
+	req = req.WithContext(requestid.NewContext(req.Context(), "req-1"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := map[string]interface{}{}
+	if output.Len() > 0 {
+		require.NoError(t, json.Unmarshal(output.Bytes(), &line))
+	}
+
+	return line
+}
+
+func TestLoggingMiddleware_Fields(t *testing.T) {
+	asserts := assert.New(t)
+	cfg := defaultLoggingConfig()
+
+	line := serveLogged(t, cfg, http.StatusCreated, `{"title":"title"}`, `{"id":"1"}`)
+
+	asserts.Equal("INFO", line["level"])
+	asserts.Equal("req-1", line["request_id"])
+	asserts.Equal("POST", line["method"])
+	asserts.Equal("/tasks", line["path"])
+	asserts.Equal(float64(http.StatusCreated), line["status"])
+	asserts.Equal(float64(len(`{"id":"1"}`)), line["bytes"])
+	asserts.Contains(line, "latency_ms")
+	asserts.NotContains(line, "request_body")
+}
+
+func TestLoggingMiddleware_Level(t *testing.T) {
+	asserts := assert.New(t)
+	cfg := defaultLoggingConfig()
+
+	asserts.Equal("ERROR", serveLogged(t, cfg, http.StatusInternalServerError, "", "")["level"])
+	asserts.Equal("WARN", serveLogged(t, cfg, http.StatusNotFound, "", "")["level"])
+
+	cfg.Level = "error"
+	asserts.Empty(serveLogged(t, cfg, http.StatusNotFound, "", ""))
+}
+
+func TestLoggingMiddleware_Bodies_Redacted(t *testing.T) {
+	asserts := assert.New(t)
+	cfg := defaultLoggingConfig()
+	cfg.LogBodies = true
+
+	line := serveLogged(t, cfg, http.StatusOK, `{"title":"title","Password":"hunter2","nested":{"token":"abc"}}`, `{"api_key":"xyz"}`)
+
+	asserts.NotContains(line["request_body"], "hunter2")
+	asserts.NotContains(line["request_body"], "abc")
+	asserts.Contains(line["request_body"], `"title":"title"`)
+	asserts.Equal(`{"api_key":"[REDACTED]"}`, line["response_body"])
+
+	line = serveLogged(t, cfg, http.StatusCreated, `{"name":"ci"}`, `{"name":"ci","key":"tdk_secret"}`)
+	asserts.Equal(`{"key":"[REDACTED]","name":"ci"}`, line["response_body"])
+}
+
+func TestLoggingMiddleware_Bodies_Truncated(t *testing.T) {
+	asserts := assert.New(t)
+	cfg := defaultLoggingConfig()
+	cfg.LogBodies = true
+	cfg.MaxBodyBytes = 30
+
+	line := serveLogged(t, cfg, http.StatusOK, `{"password":"hunter2","description":"a long description"}`, "")
+
+	asserts.Equal(`{"password":"[REDACTED]","descrip...(truncated)`, line["request_body"])
+}
@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+)
+
+// acceptQuality returns the quality the Accept header gives to mediaType,
+// taking the most specific range matching it, and whether it is named
+// explicitly instead of through a wildcard.
+func acceptQuality(accept string, mediaType string) (float64, bool) {
+	quality, specificity := 0.0, -1
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	for _, part := range strings.Split(accept, ",") {
+		accepted, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		var s int
+		switch accepted {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s < specificity {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		quality, specificity = q, s
+	}
+
+	return quality, specificity == 2
+}
+
+// ProblemDetailsMiddleware renders the errors of the clients asking for
+// application/problem+json, at least as much as application/json, as RFC 7807
+// problem details with the request path as instance. The others, wildcards
+// included, keep the legacy ErrorResponse.
+func ProblemDetailsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header.Get("Accept")
+		problem, explicit := acceptQuality(accept, handler_utils.ProblemContentType)
+		legacy, _ := acceptQuality(accept, "application/json")
+		if !explicit || problem == 0 || problem < legacy {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(&handler_utils.ProblemWriter{ResponseWriter: w, Instance: r.URL.Path}, r)
+	})
+}
@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemDetailsMiddleware(t *testing.T) {
+	asserts := assert.New(t)
+
+	fieldErrors := []dtos.FieldError{
+		{Field: "grantee", Reason: "is required"},
+		{Field: "role", Reason: "must be viewer, editor or owner"},
+	}
+	handler := RequestIDMiddleware(ProblemDetailsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// wrapped like the metrics and logging middlewares do
+		recorder := &statusRecorder{ResponseWriter: w}
+		handler_utils.HandlerErrorResponse(recorder, http.StatusBadRequest, error_response.Validation(fieldErrors))
+	})))
+
+	problem := `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Grantee field is required and can't be yourself or the owner","instance":"/tasks/1/shares","request_id":"req-1","errors":[{"field":"grantee","reason":"is required"},{"field":"role","reason":"must be viewer, editor or owner"}]}`
+	legacy := `{"message":"Grantee field is required and can't be yourself or the owner","code":400,"request_id":"req-1"}`
+
+	tests := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+		expectedResponse    string
+	}{
+		{name: "ProblemDetails - No accept", accept: "", expectedContentType: "application/json; charset=utf-8", expectedResponse: legacy},
+		{name: "ProblemDetails - Wildcard", accept: "*/*", expectedContentType: "application/json; charset=utf-8", expectedResponse: legacy},
+		{name: "ProblemDetails - Json", accept: "application/json", expectedContentType: "application/json; charset=utf-8", expectedResponse: legacy},
+		{name: "ProblemDetails - Problem", accept: "application/problem+json", expectedContentType: "application/problem+json", expectedResponse: problem},
+		{name: "ProblemDetails - Both", accept: "application/json, application/problem+json", expectedContentType: "application/problem+json", expectedResponse: problem},
+		{name: "ProblemDetails - Json preferred", accept: "application/problem+json;q=0.5, application/json", expectedContentType: "application/json; charset=utf-8", expectedResponse: legacy},
+		{name: "ProblemDetails - Problem preferred", accept: "application/problem+json, application/*;q=0.8", expectedContentType: "application/problem+json", expectedResponse: problem},
+		{name: "ProblemDetails - Problem refused", accept: "application/problem+json;q=0", expectedContentType: "application/json; charset=utf-8", expectedResponse: legacy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks/1/shares", nil)
+			req.Header.Set("X-Request-ID", "req-1")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			asserts.Equal(http.StatusBadRequest, rr.Code)
+			asserts.Equal(tt.expectedContentType, rr.Header().Get("Content-Type"))
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package middlewares
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+	"github.com/manuelbeos/code-branch-todo-test/internal/ratelimit"
+)
+
+type RateLimitConfig struct {
+	// Default applies to every route without its own limit, all of them share
+	// a single bucket per client.
+	Default ratelimit.Limit
+	// Routes gives a route its own bucket, keyed by method and route template
+	// such as "POST /tasks".
+	Routes map[string]ratelimit.Limit
+	// ClientIPHeader is trusted for the client address when set, e.g.
+	// X-Forwarded-For behind a proxy. Otherwise the remote address is used.
+	ClientIPHeader string
+}
+
+// rateLimitClient identifies the caller by its credentials, anonymous callers
+// by their address.
+func rateLimitClient(r *http.Request, ipHeader string) string {
+	if principal := auth.FromContext(r.Context()); principal != nil {
+		return principal.Method + ":" + principal.Subject
+	}
+	return "ip:" + clientIP(r, ipHeader)
+}
+
+// clientIP returns the address the trusted proxy saw, the right most one of
+// ipHeader: the entries before it come from the client and can be anything.
+// The remote address is used without a header or proxy.
+func clientIP(r *http.Request, ipHeader string) string {
+	if ipHeader != "" {
+		values := r.Header.Values(ipHeader)
+		if len(values) > 0 {
+			entries := strings.Split(values[len(values)-1], ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// RateLimit wraps a route with a token bucket per client. Every response
+// carries the RateLimit-* headers, exhausted buckets answer 429 with
+// Retry-After. The request goes through when the store fails, so an outage
+// of a shared store doesn't take the API down.
+func RateLimit(cfg RateLimitConfig, store ratelimit.Store) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			limit, key := cfg.Default, ""
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route := r.Method + " " + template
+					if routeLimit, ok := cfg.Routes[route]; ok {
+						limit, key = routeLimit, route+" "
+					}
+				}
+			}
+			if limit.Unlimited() {
+				next(w, r)
+				return
+			}
+			key += rateLimitClient(r, cfg.ClientIPHeader)
+
+			if take(w, r, store, key, limit) {
+				next(w, r)
+			}
+		}
+	}
+}
+
+// AuthRateLimit limits the requests carrying credentials per client address.
+// It runs ahead of authentication, so every attempt counts whether the
+// credentials are valid or not and guessing API keys or tokens is bounded.
+// Anonymous requests pass through.
+func AuthRateLimit(limit ratelimit.Limit, ipHeader string, store ratelimit.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey, token := credentials(r)
+			if limit.Unlimited() || (apiKey == "" && token == "" && r.Header.Get("Authorization") == "") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if take(w, r, store, "auth ip:"+clientIP(r, ipHeader), limit) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// take takes a token from the bucket of key and sets the RateLimit-*
+// headers. It answers 429 and returns false when the bucket is empty, a
+// failing store lets the request through.
+func take(w http.ResponseWriter, r *http.Request, store ratelimit.Store, key string, limit ratelimit.Limit) bool {
+	result, err := store.Take(r.Context(), key, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "rate limit store failed, request not limited", "error", err)
+		return true
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+	w.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+ceilSeconds(limit.Period))
+	if !result.Allowed {
+		w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+		handler_utils.HandlerErrorResponse(w, http.StatusTooManyRequests, error_response.ErrTooManyRequests)
+		return false
+	}
+	return true
+}
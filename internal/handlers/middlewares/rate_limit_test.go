@@ -0,0 +1,128 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func newRateLimitedRouter(cfg RateLimitConfig, store ratelimit.Store) http.Handler {
+	authenticator := fakeAuthenticator{
+		"alice": {Subject: "alice", Method: "api_key", Scopes: []string{auth.ScopeTasksWrite}},
+	}
+	limit := RateLimit(cfg, store)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/tasks", limit(ok)).Methods(http.MethodPost, http.MethodGet)
+	router.HandleFunc("/tasks/{id}", limit(ok)).Methods(http.MethodGet)
+	router.Use(AuthenticationMiddleware(authenticator, authenticator))
+	return router
+}
+
+func TestRateLimit(t *testing.T) {
+	asserts := assert.New(t)
+	router := newRateLimitedRouter(RateLimitConfig{
+		Default: ratelimit.Limit{Requests: 2, Period: time.Minute},
+		Routes: map[string]ratelimit.Limit{
+			"POST /tasks": {Requests: 1, Period: time.Minute},
+		},
+		ClientIPHeader: "X-Forwarded-For",
+	}, ratelimit.NewMemoryStore())
+
+	send := func(method string, path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// POST /tasks has its own bucket
+	rr := send(http.MethodPost, "/tasks", nil)
+	asserts.Equal(http.StatusOK, rr.Code)
+	asserts.Equal("1", rr.Header().Get("RateLimit-Limit"))
+	asserts.Equal("0", rr.Header().Get("RateLimit-Remaining"))
+	asserts.Equal("60", rr.Header().Get("RateLimit-Reset"))
+	asserts.Equal("1;w=60", rr.Header().Get("RateLimit-Policy"))
+
+	rr = send(http.MethodPost, "/tasks", nil)
+	asserts.Equal(http.StatusTooManyRequests, rr.Code)
+	asserts.Equal("60", rr.Header().Get("Retry-After"))
+	asserts.Equal(`{"message":"Too many requests, retry later","code":429}`, rr.Body.String())
+
+	// the other routes share the default bucket
+	asserts.Equal(http.StatusOK, send(http.MethodGet, "/tasks", nil).Code)
+	rr = send(http.MethodGet, "/tasks/1", nil)
+	asserts.Equal(http.StatusOK, rr.Code)
+	asserts.Equal("2", rr.Header().Get("RateLimit-Limit"))
+	asserts.Equal(http.StatusTooManyRequests, send(http.MethodGet, "/tasks", nil).Code)
+
+	// authenticated callers and other addresses have their own buckets
+	asserts.Equal(http.StatusOK, send(http.MethodPost, "/tasks", map[string]string{APIKeyHeader: "alice"}).Code)
+	asserts.Equal(http.StatusOK, send(http.MethodPost, "/tasks", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7"}).Code)
+	asserts.Equal(http.StatusTooManyRequests, send(http.MethodPost, "/tasks", map[string]string{"X-Forwarded-For": "203.0.113.7"}).Code)
+	// the entries before the one of the proxy are the client's, changing them
+	// doesn't give a new bucket
+	asserts.Equal(http.StatusTooManyRequests, send(http.MethodPost, "/tasks", map[string]string{"X-Forwarded-For": "192.0.2.99, 203.0.113.7"}).Code)
+}
+
+func TestRateLimit_StoreFailure(t *testing.T) {
+	asserts := assert.New(t)
+	router := newRateLimitedRouter(RateLimitConfig{
+		Default: ratelimit.Limit{Requests: 1, Period: time.Minute},
+	}, failingStore{})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+	asserts.Equal(http.StatusOK, rr.Code)
+	asserts.Empty(rr.Header().Get("RateLimit-Limit"))
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	asserts := assert.New(t)
+	authenticator := fakeAuthenticator{
+		"alice": {Subject: "alice", Method: "api_key", Scopes: []string{auth.ScopeTasksWrite}},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	router.Use(AuthRateLimit(ratelimit.Limit{Requests: 2, Period: time.Minute}, "X-Forwarded-For", ratelimit.NewMemoryStore()))
+	router.Use(AuthenticationMiddleware(authenticator, authenticator))
+
+	send := func(key string, address string) int {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		req.Header.Set("X-Forwarded-For", address)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// failed attempts count, so guessing stops at the limit
+	asserts.Equal(http.StatusUnauthorized, send("guess-1", "203.0.113.7"))
+	asserts.Equal(http.StatusUnauthorized, send("guess-2", "203.0.113.7"))
+	asserts.Equal(http.StatusTooManyRequests, send("alice", "203.0.113.7"))
+
+	// anonymous requests and other addresses aren't affected
+	asserts.Equal(http.StatusOK, send("", "203.0.113.7"))
+	asserts.Equal(http.StatusOK, send("alice", "198.51.100.1"))
+}
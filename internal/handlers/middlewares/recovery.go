@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gorilla/mux"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/requestid"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+	"github.com/manuelbeos/code-branch-todo-test/internal/metrics"
+)
+
+// writeTracker remembers whether the response was started, after that a 500
+// can't be sent anymore.
+type writeTracker struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (wt *writeTracker) WriteHeader(code int) {
+	wt.written = true
+	wt.ResponseWriter.WriteHeader(code)
+}
+
+func (wt *writeTracker) Write(b []byte) (int, error) {
+	wt.written = true
+	return wt.ResponseWriter.Write(b)
+}
+
+func (wt *writeTracker) Unwrap() http.ResponseWriter {
+	return wt.ResponseWriter
+}
+
+func (wt *writeTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := wt.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not implement http.Hijacker")
+	}
+	wt.written = true
+	return hijacker.Hijack()
+}
+
+// RecoveryMiddleware turns a panicking handler into a 500 ErrorResponse,
+// logging the stack trace with the request id and counting it in
+// http_panics_total. Responses already started are left as they are.
+// http.ErrAbortHandler is re-raised, it is how handlers abort on purpose.
+func RecoveryMiddleware(logger *slog.Logger, m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tracker := &writeTracker{ResponseWriter: w}
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				route := "unmatched"
+				if current := mux.CurrentRoute(r); current != nil {
+					if template, err := current.GetPathTemplate(); err == nil {
+						route = template
+					}
+				}
+				m.HTTPPanicsTotal.WithLabelValues(r.Method, route).Inc()
+
+				logger.LogAttrs(r.Context(), slog.LevelError, "panic recovered",
+					slog.String("request_id", requestid.FromContext(r.Context())),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("panic", fmt.Sprint(recovered)),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if !tracker.written {
+					handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrInternalServer)
+				}
+			}()
+
+			next.ServeHTTP(tracker, r)
+		})
+	}
+}
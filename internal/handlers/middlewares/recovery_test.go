@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	asserts := assert.New(t)
+	m := metrics.New()
+	var logs bytes.Buffer
+
+	router := mux.NewRouter()
+	router.HandleFunc("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}).Methods(http.MethodGet)
+	router.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("too late")
+	}).Methods(http.MethodPost)
+	router.Use(RequestIDMiddleware)
+	router.Use(RecoveryMiddleware(NewLogger(&logs, defaultLoggingConfig()), m))
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+	req.Header.Set("X-Request-ID", "panicking-request")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	asserts.Equal(http.StatusInternalServerError, rr.Code)
+	asserts.Equal(`{"message":"Internal server error","code":500,"request_id":"panicking-request"}`, rr.Body.String())
+	asserts.Equal(float64(1), testutil.ToFloat64(m.HTTPPanicsTotal.WithLabelValues(http.MethodGet, "/tasks/{id}")))
+	asserts.Contains(logs.String(), `"request_id":"panicking-request"`)
+	asserts.Contains(logs.String(), `"panic":"boom"`)
+	asserts.Contains(logs.String(), "recovery_test.go")
+
+	// started responses are left as they are
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/tasks", nil))
+
+	asserts.Equal(http.StatusAccepted, rr.Code)
+	asserts.Empty(rr.Body.String())
+	asserts.Equal(float64(1), testutil.ToFloat64(m.HTTPPanicsTotal.WithLabelValues(http.MethodPost, "/tasks")))
+}
+
+func TestRecoveryMiddleware_AbortHandler(t *testing.T) {
+	asserts := assert.New(t)
+	m := metrics.New()
+	handler := RecoveryMiddleware(NewLogger(&bytes.Buffer{}, defaultLoggingConfig()), m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	asserts.PanicsWithValue(http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	})
+	asserts.Equal(float64(0), testutil.ToFloat64(m.HTTPPanicsTotal.WithLabelValues(http.MethodGet, "unmatched")))
+}
@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/requestid"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	asserts := assert.New(t)
+
+	tests := []struct {
+		name       string
+		incomingID string
+		keepsID    bool
+	}{
+		{name: "RequestID - Reuses client id", incomingID: "client-id-1", keepsID: true},
+		{name: "RequestID - Generates when missing", incomingID: "", keepsID: false},
+		{name: "RequestID - Generates when invalid", incomingID: "bad id\n", keepsID: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextID string
+			handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contextID = requestid.FromContext(r.Context())
+				handler_utils.HandlerErrorResponse(w, http.StatusNotFound, error_response.ErrTaskNotFound)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+			if tt.incomingID != "" {
+				req.Header.Set(requestid.Header, tt.incomingID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			responseID := w.Header().Get(requestid.Header)
+			asserts.NotEmpty(responseID)
+			asserts.Equal(responseID, contextID)
+			if tt.keepsID {
+				asserts.Equal(tt.incomingID, responseID)
+			} else {
+				asserts.NotEqual(tt.incomingID, responseID)
+			}
+			asserts.Equal(`{"message":"Task not found","code":404,"request_id":"`+responseID+`"}`, w.Body.String())
+			asserts.Empty(error_response.ErrTaskNotFound.RequestID)
+		})
+	}
+}
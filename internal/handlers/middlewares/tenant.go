@@ -0,0 +1,105 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+)
+
+type TenancyConfig struct {
+	// Header carries the tenant id, empty disables it.
+	Header string
+	// BaseDomain resolves the tenant from the subdomain right below it, e.g.
+	// acme.todo.example.com is tenant acme. Empty disables it.
+	BaseDomain string
+	// BoundOnly takes the tenant from the credentials alone, the header or
+	// subdomain may only repeat it. Set it when authentication is enabled,
+	// otherwise credentials without a tenant could pick any.
+	BoundOnly bool
+}
+
+// subdomainTenant returns the labels in front of base, nested subdomains are
+// returned whole and fail validation.
+func subdomainTenant(host string, base string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	suffix := "." + strings.ToLower(strings.Trim(base, "."))
+
+	if base == "" || !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+	return strings.TrimSuffix(host, suffix)
+}
+
+// TenantMiddleware stores the tenant of the request in its context. A tenant
+// bound to the credentials wins, the header or subdomain may only repeat it;
+// otherwise the header, then the subdomain, are used unless BoundOnly is set.
+// It must run after AuthenticationMiddleware, RequireTenant rejects requests
+// left without one.
+func TenantMiddleware(cfg TenancyConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var fromHeader string
+			if cfg.Header != "" {
+				fromHeader = strings.TrimSpace(r.Header.Get(cfg.Header))
+			}
+			fromHost := subdomainTenant(r.Host, cfg.BaseDomain)
+			if fromHeader != "" && fromHost != "" && fromHeader != fromHost {
+				handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrInvalidTenant)
+				return
+			}
+
+			requested := fromHeader
+			if requested == "" {
+				requested = fromHost
+			}
+
+			tenant := requested
+			principal := auth.FromContext(r.Context())
+			switch {
+			case principal != nil && principal.Tenant != "":
+				if requested != "" && requested != principal.Tenant {
+					handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrTenantMismatch)
+					return
+				}
+				tenant = principal.Tenant
+			case cfg.BoundOnly && principal != nil && requested != "":
+				handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrTenantMismatch)
+				return
+			case cfg.BoundOnly:
+				// anonymous requests are rejected by the guard of the route
+				tenant = ""
+			}
+
+			if tenant == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !identity.ValidTenant(tenant) {
+				handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrInvalidTenant)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(identity.WithTenant(r.Context(), tenant)))
+		})
+	}
+}
+
+// RequireTenant wraps the routes holding tenant data.
+func RequireTenant(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if identity.TenantFromContext(r.Context()) == "" {
+			handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrTenantRequired)
+			return
+		}
+
+		next(w, r)
+	}
+}
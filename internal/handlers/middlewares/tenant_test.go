@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	asserts := assert.New(t)
+
+	authenticator := fakeAuthenticator{
+		"global": {Subject: "apikey:global", Scopes: []string{auth.ScopeTasksRead}},
+		"acme":   {Subject: "apikey:acme", Scopes: []string{auth.ScopeTasksRead}, Tenant: "acme"},
+	}
+	handler := AuthenticationMiddleware(authenticator, authenticator)(TenantMiddleware(TenancyConfig{
+		Header:     "X-Tenant-ID",
+		BaseDomain: "todo.example.com",
+	})(RequireTenant(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(identity.TenantFromContext(r.Context())))
+	})))
+
+	tests := []struct {
+		name               string
+		host               string
+		headers            map[string]string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{name: "TenantMiddleware - Missing tenant", expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"Tenant is required","code":400}`},
+		{name: "TenantMiddleware - Header", headers: map[string]string{"X-Tenant-ID": "acme"}, expectedStatusCode: http.StatusOK, expectedResponse: "acme"},
+		{name: "TenantMiddleware - Subdomain", host: "globex.todo.example.com:8080", expectedStatusCode: http.StatusOK, expectedResponse: "globex"},
+		{name: "TenantMiddleware - Header and subdomain agree", host: "acme.todo.example.com", headers: map[string]string{"X-Tenant-ID": "acme"}, expectedStatusCode: http.StatusOK, expectedResponse: "acme"},
+		{name: "TenantMiddleware - Header and subdomain conflict", host: "globex.todo.example.com", headers: map[string]string{"X-Tenant-ID": "acme"}, expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"Tenant must be lower case letters, digits and dashes","code":400}`},
+		{name: "TenantMiddleware - Invalid tenant", headers: map[string]string{"X-Tenant-ID": "Acme Corp"}, expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"Tenant must be lower case letters, digits and dashes","code":400}`},
+		{name: "TenantMiddleware - Unbound key picks tenant", headers: map[string]string{APIKeyHeader: "global", "X-Tenant-ID": "globex"}, expectedStatusCode: http.StatusOK, expectedResponse: "globex"},
+		{name: "TenantMiddleware - Bound key", headers: map[string]string{APIKeyHeader: "acme"}, expectedStatusCode: http.StatusOK, expectedResponse: "acme"},
+		{name: "TenantMiddleware - Bound key other tenant", headers: map[string]string{APIKeyHeader: "acme", "X-Tenant-ID": "globex"}, expectedStatusCode: http.StatusForbidden, expectedResponse: `{"message":"Credentials are not valid for this tenant","code":403}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+		})
+	}
+}
+
+func TestTenantMiddleware_BoundOnly(t *testing.T) {
+	asserts := assert.New(t)
+
+	authenticator := fakeAuthenticator{
+		"global": {Subject: "apikey:global", Scopes: []string{auth.ScopeTasksRead}},
+		"acme":   {Subject: "apikey:acme", Scopes: []string{auth.ScopeTasksRead}, Tenant: "acme"},
+	}
+	handler := AuthenticationMiddleware(authenticator, authenticator)(TenantMiddleware(TenancyConfig{
+		Header:    "X-Tenant-ID",
+		BoundOnly: true,
+	})(RequireTenant(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(identity.TenantFromContext(r.Context())))
+	})))
+
+	tests := []struct {
+		name               string
+		headers            map[string]string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{name: "TenantMiddleware - Unbound key can't pick tenant", headers: map[string]string{APIKeyHeader: "global", "X-Tenant-ID": "globex"}, expectedStatusCode: http.StatusForbidden, expectedResponse: `{"message":"Credentials are not valid for this tenant","code":403}`},
+		{name: "TenantMiddleware - Unbound key", headers: map[string]string{APIKeyHeader: "global"}, expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"Tenant is required","code":400}`},
+		{name: "TenantMiddleware - Anonymous header ignored", headers: map[string]string{"X-Tenant-ID": "globex"}, expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"Tenant is required","code":400}`},
+		{name: "TenantMiddleware - Bound key", headers: map[string]string{APIKeyHeader: "acme", "X-Tenant-ID": "acme"}, expectedStatusCode: http.StatusOK, expectedResponse: "acme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package public
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/mappers"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+	"github.com/manuelbeos/code-branch-todo-test/internal/validation"
+)
+
+type StatsHandler struct {
+	service *service.TaskStatsService
+	guard   auth.Guard
+}
+
+func NewStatsHandler(service *service.TaskStatsService, guard auth.Guard) *StatsHandler {
+	return &StatsHandler{service: service, guard: guard}
+}
+
+// GetStats reports on the tasks the caller owns in its tenant.
+// @Summary Task statistics
+// @Description Totals, completion rate, average time to complete and counts by period, priority and tag
+// @Tags stats
+// @Produce json
+// @Param group_by query string false "day, week (default) or month"
+// @Param from query string false "First day reported, 2006-01-02"
+// @Param to query string false "Last day reported, 2006-01-02"
+// @Success 200 {object} dtos.TaskStatsResponseDto
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /stats [get]
+func (sh *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query, fieldErrors := parseStatsQuery(r)
+	if len(fieldErrors) > 0 {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.Validation(fieldErrors))
+		return
+	}
+
+	stats, err := sh.service.GetStats(ctx, query)
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrGettingStats)
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusOK, mappers.MapperTaskStatsToResponse(stats))
+}
+
+func parseStatsQuery(r *http.Request) (service.StatsQuery, []dtos.FieldError) {
+	params := r.URL.Query()
+	var query service.StatsQuery
+	var fieldErrors []dtos.FieldError
+
+	switch groupBy := service.StatsPeriod(params.Get("group_by")); groupBy {
+	case "", service.StatsPeriodDay, service.StatsPeriodWeek, service.StatsPeriodMonth:
+		query.GroupBy = groupBy
+	default:
+		fieldErrors = append(fieldErrors, dtos.FieldError{Field: "group_by", Reason: "must be one of day, week, month"})
+	}
+
+	date := func(field string) time.Time {
+		value := params.Get(field)
+		if value == "" {
+			return time.Time{}
+		}
+		parsed, err := time.Parse(validation.DateLayout, value)
+		if err != nil {
+			fieldErrors = append(fieldErrors, dtos.FieldError{Field: field, Reason: "must be a date like " + validation.DateLayout})
+		}
+		return parsed
+	}
+	query.From = date("from")
+	query.To = date("to")
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		fieldErrors = append(fieldErrors, dtos.FieldError{Field: "to", Reason: "can't be before from"})
+	}
+
+	return query, fieldErrors
+}
+
+func (sh *StatsHandler) RegisterEndpoints(r *mux.Router) {
+	r.HandleFunc("/stats", sh.guard(auth.ScopeStatsRead, sh.GetStats)).Methods(http.MethodGet)
+}
@@ -0,0 +1,53 @@
+package public
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/middlewares"
+	"github.com/manuelbeos/code-branch-todo-test/internal/infrastructure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsHandler_GetStats(t *testing.T) {
+	asserts := assert.New(t)
+	created := time.Date(2026, 10, 7, 12, 0, 0, 0, time.UTC)
+	completedAt := created.Add(time.Hour)
+	task := entity.Task{Id: uuid.New(), Title: "title", Priority: entity.PriorityHigh, Tags: []string{"work"}, IsCompleted: true, CompletedAt: &completedAt, CreatedAt: created}
+	repo := infrastructure.NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{task.Id: task})
+
+	tests := []struct {
+		name               string
+		url                string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{name: "GetStats - Success", url: "/stats?group_by=month", expectedStatusCode: http.StatusOK, expectedResponse: `{"total":1,"completed":1,"pending":0,"completion_rate":1,"average_time_to_complete_seconds":3600,"group_by":"month","periods":[{"start":"2026-10-01","created":1,"completed":1}],"by_priority":{"high":{"total":1,"completed":1}},"by_tag":{"work":{"total":1,"completed":1}}}`},
+		{name: "GetStats - Invalid group", url: "/stats?group_by=year", expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"group_by field must be one of day, week, month","code":400}`},
+		{name: "GetStats - Invalid date", url: "/stats?from=yesterday", expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"From field must be a date like 2006-01-02","code":400}`},
+		{name: "GetStats - Reversed range", url: "/stats?from=2026-10-08&to=2026-10-01", expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"To field can't be before from","code":400}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Use(middlewares.AuthenticationDisabledMiddleware)
+			NewStatsHandler(service.NewTaskStatsService(repo.(repository.TaskAggregator)), auth.Open).RegisterEndpoints(router)
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package public
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/mappers"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/transfer"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+)
+
+// calendarFormField is the multipart field holding an uploaded calendar.
+const calendarFormField = "file"
+
+// GetCalendar writes every task the caller can see as an iCalendar feed.
+// @Summary Calendar feed
+// @Description Stream every visible task as a VTODO of an RFC 5545 calendar, the UID of each VTODO is the task id
+// @Tags tasks
+// @Produce text/calendar
+// @Success 200 {string} string
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /tasks.ics [get]
+func (tlh *TodoListHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tasks, err := tlh.service.GetAllTasks(ctx)
+	if err != nil && !errors.Is(err, domain.ErrThereAreNoTasks) {
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrGettingTasks)
+		return
+	}
+
+	w.Header().Set("Content-Type", transfer.CalendarContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="tasks.ics"`)
+	w.WriteHeader(http.StatusOK)
+
+	// the status is sent, a failing client only stops the stream
+	encoder := transfer.NewCalendarEncoder(w)
+	for _, task := range tasks {
+		if err := encoder.Encode(task); err != nil {
+			return
+		}
+	}
+	_ = encoder.Close()
+}
+
+// UploadCalendar creates or updates tasks from the VTODO components of a
+// calendar.
+// @Summary Upload a calendar
+// @Description Create or update tasks from the VTODO components of an RFC 5545 calendar, sent as the body or as the "file" field of a multipart form. A VTODO updates the task whose id is its UID, or the task the caller created from the same UID before, and creates it otherwise. Other components are ignored.
+// @Tags tasks
+// @Accept text/calendar
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file false "Calendar, when sent as a multipart form"
+// @Success 200 {object} dtos.CalendarImportResponseDto
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 413 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /tasks.ics [post]
+func (tlh *TodoListHandler) UploadCalendar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := readCalendar(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			handler_utils.HandlerErrorResponse(w, http.StatusRequestEntityTooLarge, error_response.ErrRequestBodyTooLarge)
+			return
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrReadingRequestBody)
+		return
+	}
+
+	namespace := transfer.CalendarNamespace(identity.TenantFromContext(ctx), identity.OwnerFromContext(ctx))
+	decoded, err := transfer.DecodeCalendar(bytes.NewReader(body), namespace)
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingRequestBody)
+		return
+	}
+
+	rows := make([]service.ImportRow, 0, len(decoded))
+	var unreadable []service.ImportError
+	for _, row := range decoded {
+		if row.Err != nil {
+			unreadable = append(unreadable, service.ImportError{Row: row.Number, Id: row.Task.Id, Field: row.Err.Field, Reason: row.Err.Reason})
+			continue
+		}
+		rows = append(rows, service.ImportRow{Row: row.Number, Task: row.Task})
+	}
+
+	result, err := tlh.service.UpsertTasks(ctx, rows)
+	if err != nil {
+		if errors.Is(err, domain.ErrTaskQuotaExceeded) {
+			handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrTaskQuotaExceeded)
+			return
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrImportingCalendar)
+		return
+	}
+
+	result.Failed += len(unreadable)
+	result.Errors = append(result.Errors, unreadable...)
+	slices.SortStableFunc(result.Errors, func(a, b service.ImportError) int {
+		return a.Row - b.Row
+	})
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusOK, mappers.MapperUpsertResultToResponse(result, len(decoded)))
+}
+
+// readCalendar returns the uploaded calendar, the request body or the file
+// field of a multipart form.
+func readCalendar(r *http.Request) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return io.ReadAll(r.Body)
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			// io.EOF included, the form has no calendar
+			return nil, err
+		}
+		if part.FormName() == calendarFormField {
+			defer part.Close()
+			return io.ReadAll(part)
+		}
+		part.Close()
+	}
+}
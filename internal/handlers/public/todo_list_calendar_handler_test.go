@@ -0,0 +1,84 @@
+package public
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoListHandler_GetCalendar(t *testing.T) {
+	asserts := assert.New(t)
+	created := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	task := entity.Task{Id: uuid.MustParse("6f1c2a4e-0000-4000-8000-000000000001"), Title: "Export, today", Priority: entity.PriorityHigh, Tags: []string{"a", "b"}, DueAt: &created, CreatedAt: created, UpdatedAt: created}
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks.ics", nil)
+	rr := httptest.NewRecorder()
+	newTransferRouter(t, task).ServeHTTP(rr, req)
+
+	asserts.Equal(http.StatusOK, rr.Code)
+	asserts.Equal("text/calendar; charset=utf-8", rr.Header().Get("Content-Type"))
+	asserts.Equal("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//manuelbeos//code-branch-todo-test//EN\r\nCALSCALE:GREGORIAN\r\n"+
+		"BEGIN:VTODO\r\nUID:6f1c2a4e-0000-4000-8000-000000000001\r\nDTSTAMP:20261005T090000Z\r\nCREATED:20261005T090000Z\r\nLAST-MODIFIED:20261005T090000Z\r\n"+
+		"SUMMARY:Export\\, today\r\nSTATUS:NEEDS-ACTION\r\nDUE:20261005T090000Z\r\nPRIORITY:1\r\nCATEGORIES:a,b\r\nEND:VTODO\r\n"+
+		"END:VCALENDAR\r\n", rr.Body.String())
+}
+
+func TestTodoListHandler_UploadCalendar(t *testing.T) {
+	asserts := assert.New(t)
+	existing := entity.Task{Id: uuid.MustParse("6f1c2a4e-0000-4000-8000-000000000001"), Title: "Existing"}
+	calendar := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTODO\r\nUID:6f1c2a4e-0000-4000-8000-000000000001\r\nSUMMARY:Existing renamed\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:groceries@example.com\r\nSUMMARY:Buy milk\r\nDUE;VALUE=DATE:20261102\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nSUMMARY:No uid\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+	expectedResponse := `{"total":3,"created":1,"updated":1,"failed":1,"errors":[{"row":3,"field":"uid","reason":"is required"}]}`
+
+	multipartBody := &bytes.Buffer{}
+	form := multipart.NewWriter(multipartBody)
+	part, _ := form.CreateFormFile("file", "tasks.ics")
+	part.Write([]byte(calendar))
+	form.Close()
+
+	tests := []struct {
+		name               string
+		contentType        string
+		body               []byte
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{name: "UploadCalendar - Body", contentType: "text/calendar", body: []byte(calendar), expectedStatusCode: http.StatusOK, expectedResponse: expectedResponse},
+		{name: "UploadCalendar - Multipart", contentType: form.FormDataContentType(), body: multipartBody.Bytes(), expectedStatusCode: http.StatusOK, expectedResponse: expectedResponse},
+		{name: "UploadCalendar - Multipart without file", contentType: "multipart/form-data; boundary=x", body: []byte("--x--\r\n"), expectedStatusCode: http.StatusBadRequest,
+			expectedResponse: `{"message":"Error reading request body","code":400}`},
+		{name: "UploadCalendar - Malformed", contentType: "text/calendar", body: []byte("BEGIN:VTODO\r\n"), expectedStatusCode: http.StatusBadRequest,
+			expectedResponse: `{"message":"Error parsing request body","code":400}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTransferRouter(t, existing)
+			req := httptest.NewRequest(http.MethodPost, "/tasks.ics", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			// the same calendar again only updates
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/tasks.ics", bytes.NewReader([]byte(calendar))))
+			asserts.Equal(`{"total":3,"created":0,"updated":2,"failed":1,"errors":[{"row":3,"field":"uid","reason":"is required"}]}`, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package public
+
+import (
+	"net/http"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/transfer"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+)
+
+// GetChecklist writes every task the caller can see as a markdown checklist.
+// @Summary Markdown checklist
+// @Description Every visible task as a "- [ ]" or "- [x]" item, with its description indented under it and its subtasks nested
+// @Tags tasks
+// @Produce text/markdown
+// @Success 200 {string} string
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /tasks.md [get]
+func (tlh *TodoListHandler) GetChecklist(w http.ResponseWriter, r *http.Request) {
+	tlh.exportTasks(w, r, transfer.FormatMarkdown)
+}
+
+// UploadChecklist creates the tasks of a markdown checklist.
+// @Summary Upload a markdown checklist
+// @Description Create a task for every "- [ ]" or "- [x]" item, nested items become subtasks and the indented text under an item its description. Errors report the line of the item as row.
+// @Tags tasks
+// @Accept text/markdown
+// @Produce json
+// @Param dry_run query bool false "Report what would be imported without storing anything"
+// @Success 200 {object} dtos.ImportResultResponseDto
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 413 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /tasks.md [post]
+func (tlh *TodoListHandler) UploadChecklist(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.Validation([]dtos.FieldError{*err}))
+		return
+	}
+
+	tlh.importTasks(w, r, transfer.FormatMarkdown, dryRun)
+}
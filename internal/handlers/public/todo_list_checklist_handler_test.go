@@ -0,0 +1,56 @@
+package public
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoListHandler_UploadChecklist_GetChecklist(t *testing.T) {
+	asserts := assert.New(t)
+	checklist := "# Release\n" +
+		"- [x] Write changelog\n" +
+		"  Keep it short\n" +
+		"  - [ ] Check links\n" +
+		"- [ ]\n"
+
+	tests := []struct {
+		name               string
+		url                string
+		expectedStatusCode int
+		expectedResponse   string
+		expectedChecklist  string
+	}{
+		{name: "UploadChecklist - Dry run", url: "/tasks.md?dry_run=true", expectedStatusCode: http.StatusOK,
+			expectedResponse:  `{"dry_run":true,"total":3,"imported":2,"duplicates":0,"failed":1,"errors":[{"row":5,"field":"title","reason":"is required"}]}`,
+			expectedChecklist: ""},
+		{name: "UploadChecklist - Import", url: "/tasks.md", expectedStatusCode: http.StatusOK,
+			expectedResponse:  `{"dry_run":false,"total":3,"imported":2,"duplicates":0,"failed":1,"errors":[{"row":5,"field":"title","reason":"is required"}]}`,
+			expectedChecklist: "- [x] Write changelog\n  Keep it short\n  - [ ] Check links\n"},
+		{name: "UploadChecklist - Invalid dry run", url: "/tasks.md?dry_run=maybe", expectedStatusCode: http.StatusBadRequest,
+			expectedResponse: `{"message":"dry_run field must be true or false","code":400}`, expectedChecklist: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTransferRouter(t)
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(checklist))
+			req.Header.Set("Content-Type", "text/markdown")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/tasks.md", nil))
+			asserts.Equal(http.StatusOK, rr.Code)
+			asserts.Equal("text/markdown; charset=utf-8", rr.Header().Get("Content-Type"))
+			asserts.Equal(`attachment; filename="tasks.md"`, rr.Header().Get("Content-Disposition"))
+			asserts.Equal(tt.expectedChecklist, rr.Body.String())
+		})
+	}
+}
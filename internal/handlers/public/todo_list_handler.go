@@ -1,7 +1,7 @@
 package public
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -9,19 +9,62 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
 	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
 	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
 	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
 	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/mappers"
 	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+	"github.com/manuelbeos/code-branch-todo-test/internal/validation"
 )
 
 type TodoListHandler struct {
-	service *service.TodoListService
+	service    *service.TodoListService
+	guard      auth.Guard
+	idempotent func(http.HandlerFunc) http.HandlerFunc
 }
 
-func NewTodoListHandler(service *service.TodoListService) *TodoListHandler {
-	return &TodoListHandler{service: service}
+type HandlerOption func(*TodoListHandler)
+
+// WithGuard protects every route with the scope it needs, without it the
+// routes are open.
+func WithGuard(guard auth.Guard) HandlerOption {
+	return func(tlh *TodoListHandler) {
+		tlh.guard = guard
+	}
+}
+
+// WithIdempotency makes task creation safe to retry, see
+// middlewares.Idempotency.
+func WithIdempotency(idempotent func(http.HandlerFunc) http.HandlerFunc) HandlerOption {
+	return func(tlh *TodoListHandler) {
+		tlh.idempotent = idempotent
+	}
+}
+
+func NewTodoListHandler(service *service.TodoListService, opts ...HandlerOption) *TodoListHandler {
+	tlh := &TodoListHandler{
+		service:    service,
+		guard:      auth.Open,
+		idempotent: func(next http.HandlerFunc) http.HandlerFunc { return next },
+	}
+	for _, opt := range opts {
+		opt(tlh)
+	}
+
+	return tlh
+}
+
+// parsingErrorResponse reports unknown fields like the other invalid fields.
+func parsingErrorResponse(w http.ResponseWriter, err error) {
+	var fieldErrors validation.Errors
+	if errors.As(err, &fieldErrors) {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.Validation(fieldErrors))
+		return
+	}
+
+	handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingRequestBody)
 }
 
 func (tlh *TodoListHandler) CreateNewTask(w http.ResponseWriter, r *http.Request) {
@@ -29,26 +72,39 @@ func (tlh *TodoListHandler) CreateNewTask(w http.ResponseWriter, r *http.Request
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			handler_utils.HandlerErrorResponse(w, http.StatusRequestEntityTooLarge, error_response.ErrRequestBodyTooLarge)
+			return
+		}
+
 		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrReadingRequestBody)
 		return
 	}
 
 	createNewTaskReq := &dtos.CreateTaskRequestDto{}
-	err = json.Unmarshal(body, createNewTaskReq)
+	err = validation.DecodeJSON(body, createNewTaskReq)
 	if err != nil {
-		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingRequestBody)
+		parsingErrorResponse(w, err)
 		return
 	}
 
-	ok := createNewTaskReq.ValidTitleField()
-
-	if !ok {
-		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrTitleFieldIsRequired)
+	if fieldErrors := createNewTaskReq.Validate(); len(fieldErrors) > 0 {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.Validation(fieldErrors))
 		return
 	}
 
-	task, err := tlh.service.CreateTask(ctx, createNewTaskReq.Title, createNewTaskReq.Description)
+	task, err := tlh.service.CreateTask(ctx, mappers.MapperCreateTaskRequestToTaskEntity(*createNewTaskReq))
 	if err != nil {
+		if errors.Is(err, domain.ErrTaskQuotaExceeded) {
+			handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrTaskQuotaExceeded)
+			return
+		}
+		if invalid, ok := error_response.Invariant(err); ok {
+			handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, invalid)
+			return
+		}
+
 		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrCreatingTask)
 		return
 	}
@@ -101,6 +157,10 @@ func (tlh *TodoListHandler) GetTaskByID(w http.ResponseWriter, r *http.Request)
 			handler_utils.HandlerErrorResponse(w, http.StatusNotFound, error_response.ErrTaskNotFound)
 			return
 		}
+		if errors.Is(err, domain.ErrForbidden) {
+			handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrOperationNotAllowed)
+			return
+		}
 
 		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrGettingTaskByID)
 		return
@@ -121,21 +181,25 @@ func (tlh *TodoListHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			handler_utils.HandlerErrorResponse(w, http.StatusRequestEntityTooLarge, error_response.ErrRequestBodyTooLarge)
+			return
+		}
+
 		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrReadingRequestBody)
 		return
 	}
 
 	updateTaskReq := &dtos.UpdateTaskRequestDto{}
-	err = json.Unmarshal(body, updateTaskReq)
+	err = validation.DecodeJSON(body, updateTaskReq)
 	if err != nil {
-		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingRequestBody)
+		parsingErrorResponse(w, err)
 		return
 	}
 
-	ok := updateTaskReq.ValidTitleField()
-
-	if !ok {
-		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrTitleFieldIsRequired)
+	if fieldErrors := updateTaskReq.Validate(); len(fieldErrors) > 0 {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.Validation(fieldErrors))
 		return
 	}
 
@@ -148,6 +212,14 @@ func (tlh *TodoListHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 			handler_utils.HandlerErrorResponse(w, http.StatusNotFound, error_response.ErrTaskNotFound)
 			return
 		}
+		if errors.Is(err, domain.ErrForbidden) {
+			handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrOperationNotAllowed)
+			return
+		}
+		if invalid, ok := error_response.Invariant(err); ok {
+			handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, invalid)
+			return
+		}
 
 		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrUpdatingTask)
 		return
@@ -173,6 +245,10 @@ func (tlh *TodoListHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 			handler_utils.HandlerErrorResponse(w, http.StatusNotFound, error_response.ErrTaskNotFound)
 			return
 		}
+		if errors.Is(err, domain.ErrForbidden) {
+			handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrOperationNotAllowed)
+			return
+		}
 
 		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrDeletingTask)
 		return
@@ -181,10 +257,84 @@ func (tlh *TodoListHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	handler_utils.HandlerSuccessResponse(w, http.StatusNoContent, nil)
 }
 
+// CompleteTask marks a task as done without resending it.
+// @Summary Complete a task
+// @Description Mark a task as completed, completing it again changes nothing
+// @Tags tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} entity.Task
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /tasks/{id}/complete [post]
+func (tlh *TodoListHandler) CompleteTask(w http.ResponseWriter, r *http.Request) {
+	tlh.changeTask(w, r, tlh.service.CompleteTask)
+}
+
+// ReopenTask puts a completed task back to pending.
+// @Summary Reopen a task
+// @Description Put a completed task back to pending and count the reopening
+// @Tags tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} entity.Task
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 404 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /tasks/{id}/reopen [post]
+func (tlh *TodoListHandler) ReopenTask(w http.ResponseWriter, r *http.Request) {
+	tlh.changeTask(w, r, tlh.service.ReopenTask)
+}
+
+func (tlh *TodoListHandler) changeTask(w http.ResponseWriter, r *http.Request, change func(context.Context, uuid.UUID) (*entity.Task, error)) {
+	ctx := r.Context()
+
+	taskID := mux.Vars(r)["id"]
+	taskIdAsUUID, err := uuid.Parse(taskID)
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingTaskID)
+		return
+	}
+
+	task, err := change(ctx, taskIdAsUUID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			handler_utils.HandlerErrorResponse(w, http.StatusNotFound, error_response.ErrTaskNotFound)
+			return
+		}
+		if errors.Is(err, domain.ErrForbidden) {
+			handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrOperationNotAllowed)
+			return
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrUpdatingTask)
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusOK, task)
+}
+
 func (tlh *TodoListHandler) RegisterEndpoints(r *mux.Router) {
-	r.HandleFunc("/tasks", tlh.CreateNewTask).Methods(http.MethodPost)
-	r.HandleFunc("/tasks", tlh.GetAllTasks).Methods(http.MethodGet)
-	r.HandleFunc("/tasks/{id}", tlh.GetTaskByID).Methods(http.MethodGet)
-	r.HandleFunc("/tasks/{id}", tlh.UpdateTask).Methods(http.MethodPut)
-	r.HandleFunc("/tasks/{id}", tlh.DeleteTask).Methods(http.MethodDelete)
+	r.HandleFunc("/tasks", tlh.guard(auth.ScopeTasksWrite, tlh.idempotent(tlh.CreateNewTask))).Methods(http.MethodPost)
+	r.HandleFunc("/tasks", tlh.guard(auth.ScopeTasksRead, tlh.GetAllTasks)).Methods(http.MethodGet)
+	// registered before /tasks/{id} so export isn't read as an id
+	r.HandleFunc("/tasks/export", tlh.guard(auth.ScopeTasksRead, tlh.ExportTasks)).Methods(http.MethodGet)
+	r.HandleFunc("/tasks/import", tlh.guard(auth.ScopeTasksWrite, tlh.ImportTasks)).Methods(http.MethodPost)
+	r.HandleFunc("/tasks.ics", tlh.guard(auth.ScopeTasksRead, tlh.GetCalendar)).Methods(http.MethodGet)
+	r.HandleFunc("/tasks.ics", tlh.guard(auth.ScopeTasksWrite, tlh.UploadCalendar)).Methods(http.MethodPost)
+	r.HandleFunc("/tasks.md", tlh.guard(auth.ScopeTasksRead, tlh.GetChecklist)).Methods(http.MethodGet)
+	r.HandleFunc("/tasks.md", tlh.guard(auth.ScopeTasksWrite, tlh.UploadChecklist)).Methods(http.MethodPost)
+	r.HandleFunc("/tasks/{id}", tlh.guard(auth.ScopeTasksRead, tlh.GetTaskByID)).Methods(http.MethodGet)
+	r.HandleFunc("/tasks/{id}", tlh.guard(auth.ScopeTasksWrite, tlh.UpdateTask)).Methods(http.MethodPut)
+	r.HandleFunc("/tasks/{id}", tlh.guard(auth.ScopeTasksWrite, tlh.DeleteTask)).Methods(http.MethodDelete)
+	r.HandleFunc("/tasks/{id}/complete", tlh.guard(auth.ScopeTasksWrite, tlh.CompleteTask)).Methods(http.MethodPost)
+	r.HandleFunc("/tasks/{id}/reopen", tlh.guard(auth.ScopeTasksWrite, tlh.ReopenTask)).Methods(http.MethodPost)
+	r.HandleFunc("/tasks/{id}/shares", tlh.guard(auth.ScopeTasksWrite, tlh.ShareTask)).Methods(http.MethodPost)
+	r.HandleFunc("/tasks/{id}/shares", tlh.guard(auth.ScopeTasksRead, tlh.GetTaskGrants)).Methods(http.MethodGet)
+	r.HandleFunc("/shares", tlh.guard(auth.ScopeTasksWrite, tlh.ShareList)).Methods(http.MethodPost)
+	r.HandleFunc("/shares", tlh.guard(auth.ScopeTasksRead, tlh.GetGrants)).Methods(http.MethodGet)
+	r.HandleFunc("/shares/{id}", tlh.guard(auth.ScopeTasksWrite, tlh.RevokeGrant)).Methods(http.MethodDelete)
 }
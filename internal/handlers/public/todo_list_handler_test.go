@@ -7,12 +7,16 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
 	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
 	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/middlewares"
 	"github.com/manuelbeos/code-branch-todo-test/internal/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -30,7 +34,7 @@ func TestTodoListHandler_CreateNewTask(t *testing.T) {
 	asserts := assert.New(t)
 	mockError := errors.New(" mockerror")
 
-	task := entity.NewTask("title", "description")
+	task, _ := entity.NewTask("title", "description")
 
 	tests := []struct {
 		name                    string
@@ -79,6 +83,62 @@ func TestTodoListHandler_CreateNewTask(t *testing.T) {
 			expectedResponse:        `{"message":"Error parsing request body","code":400}`,
 			validateBodyResponse:    true,
 		},
+		{
+			name:                    "CreateNewTask - Error title only whitespace",
+			body:                    `{"title": "   ", "description": "description"}`,
+			expectedStatusCode:      http.StatusBadRequest,
+			setCustomReturnMockRepo: false,
+			expectedResponse:        `{"message":"Title field is required","code":400}`,
+			validateBodyResponse:    true,
+		},
+		{
+			name:                    "CreateNewTask - Error title too long",
+			body:                    `{"title": "` + strings.Repeat("a", 201) + `"}`,
+			expectedStatusCode:      http.StatusBadRequest,
+			setCustomReturnMockRepo: false,
+			expectedResponse:        `{"message":"Title field must be at most 200 characters","code":400}`,
+			validateBodyResponse:    true,
+		},
+		{
+			name:                    "CreateNewTask - Error unknown field",
+			body:                    `{"title": "title", "done": true}`,
+			expectedStatusCode:      http.StatusBadRequest,
+			setCustomReturnMockRepo: false,
+			expectedResponse:        `{"message":"Done field is not allowed","code":400}`,
+			validateBodyResponse:    true,
+		},
+		{
+			name:                    "CreateNewTask - Error field without a name",
+			body:                    `{"title": "title", "": 1}`,
+			expectedStatusCode:      http.StatusBadRequest,
+			setCustomReturnMockRepo: false,
+			expectedResponse:        `{"message":"Request body has a field without a name","code":400}`,
+			validateBodyResponse:    true,
+		},
+		{
+			name:                    "CreateNewTask - Error trailing data",
+			body:                    `{"title": "title"}{"title": "other"}`,
+			expectedStatusCode:      http.StatusBadRequest,
+			setCustomReturnMockRepo: false,
+			expectedResponse:        `{"message":"Error parsing request body","code":400}`,
+			validateBodyResponse:    true,
+		},
+		{
+			name:                    "CreateNewTask - Error invalid priority",
+			body:                    `{"title": "title", "priority": "urgent"}`,
+			expectedStatusCode:      http.StatusBadRequest,
+			setCustomReturnMockRepo: false,
+			expectedResponse:        `{"message":"Priority field must be one of low, normal, high","code":400}`,
+			validateBodyResponse:    true,
+		},
+		{
+			name:                    "CreateNewTask - Error invalid tag",
+			body:                    `{"title": "title", "tags": ["two words"]}`,
+			expectedStatusCode:      http.StatusBadRequest,
+			setCustomReturnMockRepo: false,
+			expectedResponse:        `{"message":"Tags field must be letters, digits, dashes and underscores","code":400}`,
+			validateBodyResponse:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -120,7 +180,7 @@ func TestTodoListHandler_CreateNewTask(t *testing.T) {
 func TestTodoListHandler_UpdateTask(t *testing.T) {
 	asserts := assert.New(t)
 	mockError := errors.New("error mockerror")
-	task := entity.NewTask("title", "description")
+	task, _ := entity.NewTask("title", "description")
 
 	tests := []struct {
 		name                    string
@@ -217,7 +277,7 @@ func TestTodoListHandler_UpdateTask(t *testing.T) {
 			buffer.Write([]byte(tt.body))
 			bodyRequest = io.NopCloser(buffer)
 
-			ctx := context.Background()
+			ctx := identity.WithoutAuthentication(context.Background())
 
 			service := service.NewTodoListService(mockRepo)
 			muxRouter := mux.NewRouter()
@@ -246,3 +306,94 @@ func TestTodoListHandler_UpdateTask(t *testing.T) {
 	}
 
 }
+
+func TestTodoListHandler_CreateNewTask_Error_Body_Too_Large(t *testing.T) {
+	asserts := assert.New(t)
+	mockRepo := mocks.NewTodoListRepository(t)
+	handler := NewTodoListHandler(service.NewTodoListService(mockRepo))
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(`{"title": "title", "description": "description"}`))
+	w := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(w, req.Body, 8)
+
+	handler.CreateNewTask(w, req)
+
+	asserts.Equal(http.StatusRequestEntityTooLarge, w.Code)
+	asserts.Equal(`{"message":"Request body too large","code":413}`, w.Body.String())
+}
+
+func TestTodoListHandler_ShareTask_Errors(t *testing.T) {
+	asserts := assert.New(t)
+
+	tests := []struct {
+		name               string
+		url                string
+		body               string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{name: "ShareTask - Invalid task id", url: "/tasks/not-a-uuid/shares", body: `{"grantee":"bob","role":"viewer"}`, expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"Error parsing task id is not a valid uuid","code":400}`},
+		{name: "ShareTask - Missing grantee", url: "/tasks/" + uuid.NewString() + "/shares", body: `{"role":"viewer"}`, expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"Grantee field is required and can't be yourself or the owner","code":400}`},
+		{name: "ShareTask - Anonymous caller", url: "/tasks/" + uuid.NewString() + "/shares", body: `{"grantee":"bob","role":"viewer"}`, expectedStatusCode: http.StatusForbidden, expectedResponse: `{"message":"Operation not allowed on this task","code":403}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewTodoListRepository(t)
+			router := mux.NewRouter()
+			NewTodoListHandler(service.NewTodoListService(mockRepo)).RegisterEndpoints(router)
+
+			req := httptest.NewRequest(http.MethodPost, tt.url, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+		})
+	}
+}
+
+func TestTodoListHandler_CompleteTask_ReopenTask(t *testing.T) {
+	asserts := assert.New(t)
+	taskID := uuid.New()
+
+	tests := []struct {
+		name               string
+		url                string
+		repoTask           *entity.Task
+		repoGetTaskError   error
+		unchanged          bool
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{name: "CompleteTask - Success", url: "/tasks/" + taskID.String() + "/complete", repoTask: &entity.Task{Id: taskID, Title: "title"}, expectedStatusCode: http.StatusOK},
+		{name: "ReopenTask - Success", url: "/tasks/" + taskID.String() + "/reopen", repoTask: &entity.Task{Id: taskID, Title: "title", IsCompleted: true}, expectedStatusCode: http.StatusOK},
+		{name: "CompleteTask - Already completed", url: "/tasks/" + taskID.String() + "/complete", repoTask: &entity.Task{Id: taskID, Title: "title", IsCompleted: true}, unchanged: true, expectedStatusCode: http.StatusOK},
+		{name: "CompleteTask - Invalid task id", url: "/tasks/not-a-uuid/complete", expectedStatusCode: http.StatusBadRequest, expectedResponse: `{"message":"Error parsing task id is not a valid uuid","code":400}`},
+		{name: "ReopenTask - Not found", url: "/tasks/" + taskID.String() + "/reopen", repoGetTaskError: domain.ErrTaskNotFound, expectedStatusCode: http.StatusNotFound, expectedResponse: `{"message":"Task not found","code":404}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewTodoListRepository(t)
+			router := mux.NewRouter()
+			router.Use(middlewares.AuthenticationDisabledMiddleware)
+			NewTodoListHandler(service.NewTodoListService(mockRepo)).RegisterEndpoints(router)
+
+			req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			if tt.repoTask != nil || tt.repoGetTaskError != nil {
+				mockRepo.On("GetTaskByID", mock.Anything, taskID).Return(tt.repoTask, tt.repoGetTaskError)
+			}
+			if tt.repoTask != nil && !tt.unchanged {
+				mockRepo.On("UpdateTask", mock.Anything, mock.Anything).Return(tt.repoTask, nil)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			if tt.expectedResponse != "" {
+				asserts.Equal(tt.expectedResponse, rr.Body.String())
+			}
+		})
+	}
+}
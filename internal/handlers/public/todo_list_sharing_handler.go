@@ -0,0 +1,161 @@
+package public
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+)
+
+func readShareRequest(w http.ResponseWriter, r *http.Request) (*dtos.ShareRequestDto, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			handler_utils.HandlerErrorResponse(w, http.StatusRequestEntityTooLarge, error_response.ErrRequestBodyTooLarge)
+			return nil, false
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrReadingRequestBody)
+		return nil, false
+	}
+
+	shareReq := &dtos.ShareRequestDto{}
+	err = json.Unmarshal(body, shareReq)
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingRequestBody)
+		return nil, false
+	}
+
+	if fieldErrors := shareReq.Validate(); len(fieldErrors) > 0 {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.Validation(fieldErrors))
+		return nil, false
+	}
+
+	return shareReq, true
+}
+
+// sharingErrorResponse writes the errors shared by every sharing endpoint,
+// it reports false for errors left to the caller.
+func sharingErrorResponse(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, domain.ErrTaskNotFound):
+		handler_utils.HandlerErrorResponse(w, http.StatusNotFound, error_response.ErrTaskNotFound)
+	case errors.Is(err, domain.ErrGrantNotFound):
+		handler_utils.HandlerErrorResponse(w, http.StatusNotFound, error_response.ErrGrantNotFound)
+	case errors.Is(err, domain.ErrForbidden):
+		handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrOperationNotAllowed)
+	case errors.Is(err, domain.ErrInvalidRole):
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrInvalidRole)
+	case errors.Is(err, domain.ErrInvalidGrant):
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrGranteeIsRequired)
+	default:
+		return false
+	}
+	return true
+}
+
+func (tlh *TodoListHandler) ShareTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskIdAsUUID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingTaskID)
+		return
+	}
+
+	shareReq, ok := readShareRequest(w, r)
+	if !ok {
+		return
+	}
+
+	grant, err := tlh.service.ShareTask(ctx, taskIdAsUUID, shareReq.Grantee, shareReq.Role)
+	if err != nil {
+		if !sharingErrorResponse(w, err) {
+			handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrSharing)
+		}
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusCreated, grant)
+}
+
+func (tlh *TodoListHandler) GetTaskGrants(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskIdAsUUID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingTaskID)
+		return
+	}
+
+	grants, err := tlh.service.GetTaskGrants(ctx, taskIdAsUUID)
+	if err != nil {
+		if !sharingErrorResponse(w, err) {
+			handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrGettingGrants)
+		}
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusOK, grants)
+}
+
+func (tlh *TodoListHandler) ShareList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	shareReq, ok := readShareRequest(w, r)
+	if !ok {
+		return
+	}
+
+	grant, err := tlh.service.ShareList(ctx, shareReq.Grantee, shareReq.Role)
+	if err != nil {
+		if !sharingErrorResponse(w, err) {
+			handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrSharing)
+		}
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusCreated, grant)
+}
+
+func (tlh *TodoListHandler) GetGrants(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	given, received, err := tlh.service.GetGrants(ctx)
+	if err != nil {
+		if !sharingErrorResponse(w, err) {
+			handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrGettingGrants)
+		}
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusOK, dtos.GrantsResponseDto{Given: given, Received: received})
+}
+
+func (tlh *TodoListHandler) RevokeGrant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	grantID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingGrantID)
+		return
+	}
+
+	err = tlh.service.RevokeGrant(ctx, grantID)
+	if err != nil {
+		if !sharingErrorResponse(w, err) {
+			handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrRevokingGrant)
+		}
+		return
+	}
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,179 @@
+package public
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/mappers"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/transfer"
+	handler_utils "github.com/manuelbeos/code-branch-todo-test/internal/handlers/utils"
+)
+
+var errInvalidFormat = dtos.FieldError{Field: "format", Reason: "must be one of csv, json, ndjson, markdown"}
+
+// ExportTasks writes every task the caller can see.
+// @Summary Export tasks
+// @Description Stream every visible task as CSV, a JSON array, newline delimited JSON or a markdown checklist
+// @Tags tasks
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce text/markdown
+// @Param format query string false "csv, json (default), ndjson or markdown"
+// @Success 200 {array} entity.Task
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /tasks/export [get]
+func (tlh *TodoListHandler) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	format := transfer.FormatJSON
+	if param := r.URL.Query().Get("format"); param != "" {
+		format = transfer.Format(param)
+	}
+	if !format.Valid() {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.Validation([]dtos.FieldError{errInvalidFormat}))
+		return
+	}
+
+	tlh.exportTasks(w, r, format)
+}
+
+// exportTasks streams the tasks of the caller as an attachment.
+func (tlh *TodoListHandler) exportTasks(w http.ResponseWriter, r *http.Request, format transfer.Format) {
+	ctx := r.Context()
+
+	tasks, err := tlh.service.GetAllTasks(ctx)
+	if err != nil && !errors.Is(err, domain.ErrThereAreNoTasks) {
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrGettingTasks)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="tasks.`+format.Extension()+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	// the status is sent, a failing client only stops the stream
+	encoder := transfer.NewEncoder(w, format)
+	for _, task := range tasks {
+		if err := encoder.Encode(task); err != nil {
+			return
+		}
+	}
+	_ = encoder.Close()
+}
+
+// ImportTasks creates the tasks of an export as tasks of the caller.
+// @Summary Import tasks
+// @Description Import tasks from CSV, a JSON array, newline delimited JSON or a markdown checklist, keeping their ids and timestamps. Invalid rows and duplicated ids are reported and skipped.
+// @Tags tasks
+// @Accept json
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Accept text/markdown
+// @Produce json
+// @Param format query string false "csv, json, ndjson or markdown, read from the Content-Type by default"
+// @Param dry_run query bool false "Report what would be imported without storing anything"
+// @Success 200 {object} dtos.ImportResultResponseDto
+// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 403 {object} dtos.ErrorResponse
+// @Failure 413 {object} dtos.ErrorResponse
+// @Failure 500 {object} dtos.ErrorResponse
+// @Router /tasks/import [post]
+func (tlh *TodoListHandler) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	var fieldErrors []dtos.FieldError
+	format := transfer.FormatOf(r.Header.Get("Content-Type"))
+	if param := r.URL.Query().Get("format"); param != "" {
+		format = transfer.Format(param)
+		if !format.Valid() {
+			fieldErrors = append(fieldErrors, errInvalidFormat)
+		}
+	}
+	dryRun, dryRunErr := parseDryRun(r)
+	if dryRunErr != nil {
+		fieldErrors = append(fieldErrors, *dryRunErr)
+	}
+	if len(fieldErrors) > 0 {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.Validation(fieldErrors))
+		return
+	}
+
+	tlh.importTasks(w, r, format, dryRun)
+}
+
+func parseDryRun(r *http.Request) (bool, *dtos.FieldError) {
+	param := r.URL.Query().Get("dry_run")
+	if param == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(param)
+	if err != nil {
+		return false, &dtos.FieldError{Field: "dry_run", Reason: "must be true or false"}
+	}
+	return dryRun, nil
+}
+
+// importTasks decodes the body and imports its tasks.
+func (tlh *TodoListHandler) importTasks(w http.ResponseWriter, r *http.Request, format transfer.Format, dryRun bool) {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			handler_utils.HandlerErrorResponse(w, http.StatusRequestEntityTooLarge, error_response.ErrRequestBodyTooLarge)
+			return
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrReadingRequestBody)
+		return
+	}
+
+	decoded, err := transfer.Decode(bytes.NewReader(body), format)
+	if err != nil {
+		handler_utils.HandlerErrorResponse(w, http.StatusBadRequest, error_response.ErrParsingRequestBody)
+		return
+	}
+
+	rows := make([]service.ImportRow, 0, len(decoded))
+	var unreadable []service.ImportError
+	for _, row := range decoded {
+		if row.Err != nil {
+			unreadable = append(unreadable, service.ImportError{Row: row.Number, Field: row.Err.Field, Reason: row.Err.Reason})
+			continue
+		}
+		rows = append(rows, service.ImportRow{Row: row.Number, Task: row.Task})
+	}
+
+	result, err := tlh.service.ImportTasks(ctx, rows, dryRun)
+	if err != nil {
+		if errors.Is(err, domain.ErrTaskQuotaExceeded) {
+			handler_utils.HandlerErrorResponse(w, http.StatusForbidden, error_response.ErrTaskQuotaExceeded)
+			return
+		}
+
+		handler_utils.HandlerErrorResponse(w, http.StatusInternalServerError, error_response.ErrImportingTasks)
+		return
+	}
+
+	result.Failed += len(unreadable)
+	result.Errors = append(result.Errors, unreadable...)
+	if format == transfer.FormatMarkdown {
+		// checklist items get new ids, they mean nothing to the caller
+		for i := range result.Errors {
+			result.Errors[i].Id = uuid.Nil
+		}
+	}
+	slices.SortStableFunc(result.Errors, func(a, b service.ImportError) int {
+		return a.Row - b.Row
+	})
+
+	handler_utils.HandlerSuccessResponse(w, http.StatusOK, mappers.MapperImportResultToResponse(result, dryRun, len(decoded)))
+}
@@ -0,0 +1,150 @@
+package public
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/middlewares"
+	"github.com/manuelbeos/code-branch-todo-test/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newTransferRouter serves the handlers with a mocked repository holding
+// tasks, imports and updates change what it holds.
+func newTransferRouter(t *testing.T, tasks ...entity.Task) *mux.Router {
+	stored := make([]*entity.Task, 0, len(tasks))
+	for i := range tasks {
+		stored = append(stored, &tasks[i])
+	}
+	find := func(id uuid.UUID) int {
+		return slices.IndexFunc(stored, func(task *entity.Task) bool { return task.Id == id })
+	}
+
+	mockRepo := mocks.NewTodoListRepository(t)
+	mockRepo.On("GetAllTasks", mock.Anything).Return(func(context.Context) ([]*entity.Task, error) {
+		return slices.Clone(stored), nil
+	}).Maybe()
+	mockRepo.On("GetTaskByID", mock.Anything, mock.Anything).Return(func(_ context.Context, id uuid.UUID) (*entity.Task, error) {
+		if i := find(id); i >= 0 {
+			task := *stored[i]
+			return &task, nil
+		}
+		return nil, domain.ErrTaskNotFound
+	}).Maybe()
+	mockRepo.On("UpdateTask", mock.Anything, mock.Anything).Return(func(_ context.Context, task *entity.Task) (*entity.Task, error) {
+		i := find(task.Id)
+		if i < 0 {
+			return nil, domain.ErrTaskNotFound
+		}
+		updated := *task
+		stored[i] = &updated
+		return task, nil
+	}).Maybe()
+	mockRepo.On("ImportTasks", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, imported []entity.Task, dryRun bool) ([]uuid.UUID, []uuid.UUID, error) {
+		var duplicates []uuid.UUID
+		for _, task := range imported {
+			if find(task.Id) >= 0 {
+				duplicates = append(duplicates, task.Id)
+			}
+		}
+		if dryRun || len(duplicates) > 0 {
+			return duplicates, nil, nil
+		}
+		for _, task := range imported {
+			stored = append(stored, &task)
+		}
+		return nil, nil, nil
+	}).Maybe()
+
+	router := mux.NewRouter()
+	router.Use(middlewares.AuthenticationDisabledMiddleware)
+	NewTodoListHandler(service.NewTodoListService(mockRepo)).RegisterEndpoints(router)
+	return router
+}
+
+func TestTodoListHandler_ExportTasks(t *testing.T) {
+	asserts := assert.New(t)
+	created := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	task := entity.Task{Id: uuid.MustParse("6f1c2a4e-0000-4000-8000-000000000001"), Title: "Export", Priority: entity.PriorityHigh, Tags: []string{"a", "b"}, CreatedAt: created, UpdatedAt: created}
+
+	tests := []struct {
+		name                string
+		url                 string
+		expectedStatusCode  int
+		expectedContentType string
+		expectedResponse    string
+	}{
+		{name: "ExportTasks - CSV", url: "/tasks/export?format=csv", expectedStatusCode: http.StatusOK, expectedContentType: "text/csv; charset=utf-8",
+			expectedResponse: "id,parent_id,title,description,priority,tags,due_at,is_completed,completed_at,reopened_count,created_at,updated_at\n6f1c2a4e-0000-4000-8000-000000000001,,Export,,high,a;b,,false,,0,2026-10-05T09:00:00Z,2026-10-05T09:00:00Z\n"},
+		{name: "ExportTasks - NDJSON", url: "/tasks/export?format=ndjson", expectedStatusCode: http.StatusOK, expectedContentType: "application/x-ndjson",
+			expectedResponse: `{"id":"6f1c2a4e-0000-4000-8000-000000000001","title":"Export","description":"","is_completed":false,"reopened_count":0,"priority":"high","tags":["a","b"],"created_at":"2026-10-05T09:00:00Z","updated_at":"2026-10-05T09:00:00Z"}` + "\n"},
+		{name: "ExportTasks - Invalid format", url: "/tasks/export?format=xml", expectedStatusCode: http.StatusBadRequest, expectedContentType: "application/json; charset=utf-8",
+			expectedResponse: `{"message":"Format field must be one of csv, json, ndjson, markdown","code":400}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+			newTransferRouter(t, task).ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			asserts.Equal(tt.expectedContentType, rr.Header().Get("Content-Type"))
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+		})
+	}
+}
+
+func TestTodoListHandler_ImportTasks(t *testing.T) {
+	asserts := assert.New(t)
+	existing := entity.Task{Id: uuid.MustParse("6f1c2a4e-0000-4000-8000-000000000001"), Title: "Existing"}
+	csv := "id,title,created_at\n" +
+		"6f1c2a4e-0000-4000-8000-000000000002,New,2025-01-02T03:04:05Z\n" +
+		"6f1c2a4e-0000-4000-8000-000000000001,Existing,\n" +
+		",,\n" +
+		"not-a-uuid,Broken,\n"
+
+	tests := []struct {
+		name               string
+		url                string
+		contentType        string
+		body               string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{name: "ImportTasks - CSV dry run", url: "/tasks/import?dry_run=true", contentType: "text/csv", body: csv, expectedStatusCode: http.StatusOK,
+			expectedResponse: `{"dry_run":true,"total":4,"imported":1,"duplicates":1,"failed":2,"errors":[` +
+				`{"row":2,"id":"6f1c2a4e-0000-4000-8000-000000000001","field":"id","reason":"already exists"},` +
+				`{"row":3,"field":"title","reason":"is required"},` +
+				`{"row":4,"field":"id","reason":"must be a uuid"}]}`},
+		{name: "ImportTasks - NDJSON", url: "/tasks/import?format=ndjson", body: `{"id":"6f1c2a4e-0000-4000-8000-000000000002","title":"New"}`, expectedStatusCode: http.StatusOK,
+			expectedResponse: `{"dry_run":false,"total":1,"imported":1,"duplicates":0,"failed":0,"errors":[]}`},
+		{name: "ImportTasks - Malformed JSON", url: "/tasks/import", body: `{"title":"New"}`, expectedStatusCode: http.StatusBadRequest,
+			expectedResponse: `{"message":"Error parsing request body","code":400}`},
+		{name: "ImportTasks - Invalid dry run", url: "/tasks/import?dry_run=maybe", body: `[]`, expectedStatusCode: http.StatusBadRequest,
+			expectedResponse: `{"message":"dry_run field must be true or false","code":400}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+			newTransferRouter(t, existing).ServeHTTP(rr, req)
+
+			asserts.Equal(tt.expectedStatusCode, rr.Code)
+			asserts.Equal(tt.expectedResponse, rr.Body.String())
+		})
+	}
+}
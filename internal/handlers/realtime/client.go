@@ -0,0 +1,310 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/events"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	error_response "github.com/manuelbeos/code-branch-todo-test/internal/handlers/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/mappers"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/requestid"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxMessageSize = 64 * 1024
+	sendBufferSize = 64
+)
+
+type Client struct {
+	id      string
+	hub     *Hub
+	conn    *websocket.Conn
+	service *service.TodoListService
+	send    chan []byte
+	// owner and tenant are the identity the connection was opened with,
+	// events of tasks it can't see are never delivered. everyTask is set
+	// when authentication is disabled, every task is visible then.
+	owner     string
+	tenant    string
+	everyTask bool
+
+	sendMu sync.Mutex
+	closed bool
+
+	mu            sync.RWMutex
+	subscribedAll bool
+	subscriptions map[uuid.UUID]struct{}
+}
+
+// newClient takes its identity from ctx, the context of the upgraded request.
+func newClient(ctx context.Context, hub *Hub, conn *websocket.Conn, service *service.TodoListService) *Client {
+	return &Client{
+		id:            uuid.NewString(),
+		owner:         identity.OwnerFromContext(ctx),
+		tenant:        identity.TenantFromContext(ctx),
+		everyTask:     identity.AuthenticationDisabled(ctx),
+		hub:           hub,
+		conn:          conn,
+		service:       service,
+		send:          make(chan []byte, sendBufferSize),
+		subscriptions: make(map[uuid.UUID]struct{}),
+	}
+}
+
+// trySend queues data without blocking, it reports false when the client's
+// buffer is full or the client is already gone.
+func (c *Client) trySend(data []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	select {
+	case c.send <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+func (c *Client) isSubscribed(event events.TaskEvent) bool {
+	if c.tenant != event.Tenant || (!c.everyTask && !event.Reaches(c.owner)) {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.subscribedAll {
+		return true
+	}
+	_, ok := c.subscriptions[event.TaskID]
+	return ok
+}
+
+func (c *Client) subscribe(ids []uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(ids) == 0 {
+		c.subscribedAll = true
+		return
+	}
+	for _, id := range ids {
+		c.subscriptions[id] = struct{}{}
+	}
+}
+
+func (c *Client) unsubscribe(ids []uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(ids) == 0 {
+		c.subscribedAll = false
+		c.subscriptions = make(map[uuid.UUID]struct{})
+		return
+	}
+	for _, id := range ids {
+		delete(c.subscriptions, id)
+	}
+}
+
+// readPump handles the incoming commands until the connection is closed.
+func (c *Client) readPump(ctx context.Context) {
+	defer func() {
+		c.hub.unregister(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	ctx = events.WithOrigin(ctx, c.id)
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		cmd := Command{}
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			c.reply(errorMessage(ctx, "", error_response.ErrParsingRequestBody))
+			continue
+		}
+
+		if !c.trySend(encode(c.handle(ctx, cmd))) {
+			return
+		}
+	}
+}
+
+// writePump writes queued messages and keeps the connection alive with pings.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) reply(msg Message) {
+	c.trySend(encode(msg))
+}
+
+func isWriteCommand(commandType string) bool {
+	return commandType == CommandCreate || commandType == CommandUpdate || commandType == CommandDelete
+}
+
+// canWrite is true for anonymous connections too, they only exist when
+// authentication is disabled.
+func canWrite(ctx context.Context) bool {
+	principal := auth.FromContext(ctx)
+	return principal == nil || principal.HasScope(auth.ScopeTasksWrite)
+}
+
+func (c *Client) handle(ctx context.Context, cmd Command) Message {
+	ack := Message{ID: cmd.ID, Type: MessageAck}
+
+	if isWriteCommand(cmd.Type) && !canWrite(ctx) {
+		return errorMessage(ctx, cmd.ID, error_response.ErrForbidden)
+	}
+
+	switch cmd.Type {
+	case CommandPing:
+		ack.Type = MessagePong
+		return ack
+
+	case CommandSubscribe:
+		c.subscribe(cmd.TaskIDs)
+		return ack
+
+	case CommandUnsubscribe:
+		c.unsubscribe(cmd.TaskIDs)
+		return ack
+
+	case CommandCreate:
+		createReq := dtos.CreateTaskRequestDto{Title: cmd.Title, Description: cmd.Description, Priority: cmd.Priority, Tags: cmd.Tags, DueAt: cmd.DueAt}
+		if fieldErrors := createReq.Validate(); len(fieldErrors) > 0 {
+			return errorMessage(ctx, cmd.ID, error_response.Validation(fieldErrors))
+		}
+
+		task, err := c.service.CreateTask(ctx, mappers.MapperCreateTaskRequestToTaskEntity(createReq))
+		if err != nil {
+			if errors.Is(err, domain.ErrTaskQuotaExceeded) {
+				return errorMessage(ctx, cmd.ID, error_response.ErrTaskQuotaExceeded)
+			}
+			if invalid, ok := error_response.Invariant(err); ok {
+				return errorMessage(ctx, cmd.ID, invalid)
+			}
+			return errorMessage(ctx, cmd.ID, error_response.ErrCreatingTask)
+		}
+
+		c.subscribe([]uuid.UUID{task.Id})
+		ack.Task = task
+		return ack
+
+	case CommandUpdate:
+		updateReq := dtos.UpdateTaskRequestDto{
+			Id:          cmd.TaskID,
+			Title:       cmd.Title,
+			Description: cmd.Description,
+			IsCompleted: cmd.IsCompleted,
+			Priority:    cmd.Priority,
+			Tags:        cmd.Tags,
+			DueAt:       cmd.DueAt,
+		}
+		if fieldErrors := updateReq.Validate(); len(fieldErrors) > 0 {
+			return errorMessage(ctx, cmd.ID, error_response.Validation(fieldErrors))
+		}
+
+		task, err := c.service.UpdateTask(ctx, mappers.MapperUpdateTaskRequestToTaskEntity(updateReq))
+		if err != nil {
+			if errors.Is(err, domain.ErrTaskNotFound) {
+				return errorMessage(ctx, cmd.ID, error_response.ErrTaskNotFound)
+			}
+			if errors.Is(err, domain.ErrForbidden) {
+				return errorMessage(ctx, cmd.ID, error_response.ErrOperationNotAllowed)
+			}
+			if invalid, ok := error_response.Invariant(err); ok {
+				return errorMessage(ctx, cmd.ID, invalid)
+			}
+			return errorMessage(ctx, cmd.ID, error_response.ErrUpdatingTask)
+		}
+
+		ack.Task = task
+		return ack
+
+	case CommandDelete:
+		err := c.service.DeleteTask(ctx, cmd.TaskID)
+		if err != nil {
+			if errors.Is(err, domain.ErrTaskNotFound) {
+				return errorMessage(ctx, cmd.ID, error_response.ErrTaskNotFound)
+			}
+			if errors.Is(err, domain.ErrForbidden) {
+				return errorMessage(ctx, cmd.ID, error_response.ErrOperationNotAllowed)
+			}
+			return errorMessage(ctx, cmd.ID, error_response.ErrDeletingTask)
+		}
+
+		ack.TaskID = &cmd.TaskID
+		return ack
+	}
+
+	return errorMessage(ctx, cmd.ID, error_response.ErrUnknownCommand)
+}
+
+func errorMessage(ctx context.Context, id string, err *dtos.ErrorResponse) Message {
+	if requestID := requestid.FromContext(ctx); requestID != "" {
+		err = err.WithRequestID(requestID)
+	}
+
+	return Message{ID: id, Type: MessageError, Error: err}
+}
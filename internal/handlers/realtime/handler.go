@@ -0,0 +1,65 @@
+package realtime
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+)
+
+type TaskSocketHandler struct {
+	hub      *Hub
+	service  *service.TodoListService
+	guard    auth.Guard
+	upgrader websocket.Upgrader
+}
+
+type HandlerOption func(*TaskSocketHandler)
+
+// WithGuard requires tasks:read for the handshake, write commands are then
+// checked against the scopes of the connection's principal.
+func WithGuard(guard auth.Guard) HandlerOption {
+	return func(tsh *TaskSocketHandler) {
+		tsh.guard = guard
+	}
+}
+
+func NewTaskSocketHandler(hub *Hub, service *service.TodoListService, opts ...HandlerOption) *TaskSocketHandler {
+	tsh := &TaskSocketHandler{
+		hub:     hub,
+		service: service,
+		guard:   auth.Open,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+	}
+	for _, opt := range opts {
+		opt(tsh)
+	}
+
+	return tsh
+}
+
+// ServeWS upgrades the connection and starts the client pumps. The upgrader
+// writes the error response itself when the handshake fails.
+func (tsh *TaskSocketHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := tsh.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	client := newClient(r.Context(), tsh.hub, conn, tsh.service)
+	tsh.hub.register(client)
+
+	go client.writePump()
+	// the request context is canceled once ServeWS returns, keep only its values
+	go client.readPump(context.WithoutCancel(r.Context()))
+}
+
+func (tsh *TaskSocketHandler) RegisterEndpoints(r *mux.Router) {
+	r.HandleFunc("/ws/tasks", tsh.guard(auth.ScopeTasksRead, tsh.ServeWS)).Methods(http.MethodGet)
+}
@@ -0,0 +1,79 @@
+package realtime
+
+import (
+	"sync"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/events"
+)
+
+// Hub keeps track of the connected clients and fans out task events to the
+// ones subscribed to the changed task.
+type Hub struct {
+	mu          sync.RWMutex
+	clients     map[*Client]struct{}
+	unsubscribe func()
+}
+
+func NewHub(bus *events.Bus) *Hub {
+	hub := &Hub{clients: make(map[*Client]struct{})}
+	hub.unsubscribe = bus.Subscribe(hub.broadcast)
+
+	return hub
+}
+
+func (h *Hub) register(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+}
+
+func (h *Hub) unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		c.closeSend()
+	}
+}
+
+func (h *Hub) broadcast(event events.TaskEvent) {
+	id := event.TaskID
+	data := encode(Message{Type: string(event.Type), TaskID: &id, Task: event.Task})
+
+	h.mu.RLock()
+	var slow []*Client
+	for c := range h.clients {
+		if c.id == event.Origin || !c.isSubscribed(event) {
+			continue
+		}
+		if !c.trySend(data) {
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	// a client that can't keep up is disconnected instead of blocking everyone else
+	for _, c := range slow {
+		h.unregister(c)
+	}
+}
+
+// ClientCount returns the number of connected clients.
+func (h *Hub) ClientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Close stops listening for events and disconnects every client.
+func (h *Hub) Close() {
+	h.unsubscribe()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		delete(h.clients, c)
+		c.closeSend()
+	}
+}
@@ -0,0 +1,156 @@
+package realtime
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/events"
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/middlewares"
+	"github.com/manuelbeos/code-branch-todo-test/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func dial(t *testing.T, server *httptest.Server) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/tasks"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) Message {
+	msg := Message{}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	require.NoError(t, conn.ReadJSON(&msg))
+
+	return msg
+}
+
+func TestTaskSocketHandler_BroadcastsToOtherClients(t *testing.T) {
+	asserts := assert.New(t)
+	task, _ := entity.NewTask("title", "description")
+
+	mockRepo := mocks.NewTodoListRepository(t)
+	mockRepo.On("CreateTask", mock.Anything, mock.Anything).Return(&task, nil)
+
+	bus := events.NewBus()
+	hub := NewHub(bus)
+	defer hub.Close()
+	todoListService := service.NewTodoListService(mockRepo, service.WithEventBus(bus))
+
+	router := mux.NewRouter()
+	router.Use(middlewares.AuthenticationDisabledMiddleware)
+	NewTaskSocketHandler(hub, todoListService).RegisterEndpoints(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	watcher := dial(t, server)
+	editor := dial(t, server)
+
+	require.NoError(t, watcher.WriteJSON(Command{ID: "1", Type: CommandSubscribe}))
+	asserts.Equal(MessageAck, readMessage(t, watcher).Type)
+
+	require.NoError(t, editor.WriteJSON(Command{ID: "2", Type: CommandCreate, Title: "title", Description: "description"}))
+	ack := readMessage(t, editor)
+	asserts.Equal(MessageAck, ack.Type)
+	asserts.Equal("2", ack.ID)
+	asserts.Equal(task.Id, ack.Task.Id)
+
+	broadcast := readMessage(t, watcher)
+	asserts.Equal(string(events.TaskCreated), broadcast.Type)
+	asserts.Equal(task.Id, broadcast.Task.Id)
+
+	// the editor only gets the acknowledgement, not its own change
+	require.NoError(t, editor.WriteJSON(Command{ID: "3", Type: CommandPing}))
+	asserts.Equal(MessagePong, readMessage(t, editor).Type)
+}
+
+func TestTaskSocketHandler_Create_Error_Title_Required(t *testing.T) {
+	asserts := assert.New(t)
+	mockRepo := mocks.NewTodoListRepository(t)
+	hub := NewHub(events.NewBus())
+	defer hub.Close()
+
+	router := mux.NewRouter()
+	router.Use(middlewares.AuthenticationDisabledMiddleware)
+	NewTaskSocketHandler(hub, service.NewTodoListService(mockRepo)).RegisterEndpoints(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn := dial(t, server)
+	require.NoError(t, conn.WriteJSON(Command{ID: "1", Type: CommandCreate}))
+
+	msg := readMessage(t, conn)
+	asserts.Equal(MessageError, msg.Type)
+	asserts.Equal("Title field is required", msg.Error.Message)
+}
+
+func TestHub_DisconnectsSlowConsumers(t *testing.T) {
+	asserts := assert.New(t)
+	bus := events.NewBus()
+	hub := NewHub(bus)
+	defer hub.Close()
+
+	client := newClient(identity.WithoutAuthentication(context.Background()), hub, nil, nil)
+	client.subscribe(nil)
+	hub.register(client)
+
+	for i := 0; i < sendBufferSize; i++ {
+		asserts.True(client.trySend([]byte("{}")))
+	}
+
+	bus.Publish(events.TaskEvent{Type: events.TaskDeleted})
+
+	asserts.Equal(0, hub.ClientCount())
+	asserts.False(client.trySend([]byte("{}")))
+}
+
+func TestHub_Broadcast_Only_To_Task_Owner(t *testing.T) {
+	asserts := assert.New(t)
+	bus := events.NewBus()
+	hub := NewHub(bus)
+	defer hub.Close()
+
+	alice := newClient(identity.WithOwner(context.Background(), "alice"), hub, nil, nil)
+	alice.subscribe(nil)
+	hub.register(alice)
+	bob := newClient(identity.WithOwner(context.Background(), "bob"), hub, nil, nil)
+	bob.subscribe(nil)
+	hub.register(bob)
+
+	bus.Publish(events.TaskEvent{Type: events.TaskCreated, TaskID: uuid.New(), Audience: []string{"alice", "carol"}})
+
+	asserts.Len(alice.send, 1)
+	asserts.Len(bob.send, 0)
+}
+
+func TestHub_Broadcast_Only_To_Same_Tenant(t *testing.T) {
+	asserts := assert.New(t)
+	bus := events.NewBus()
+	hub := NewHub(bus)
+	defer hub.Close()
+
+	acme := newClient(identity.WithTenant(identity.WithOwner(context.Background(), "alice"), "acme"), hub, nil, nil)
+	acme.subscribe(nil)
+	hub.register(acme)
+	globex := newClient(identity.WithTenant(identity.WithOwner(context.Background(), "alice"), "globex"), hub, nil, nil)
+	globex.subscribe(nil)
+	hub.register(globex)
+
+	bus.Publish(events.TaskEvent{Type: events.TaskCreated, TaskID: uuid.New(), Tenant: "acme", Audience: []string{"alice"}})
+
+	asserts.Len(acme.send, 1)
+	asserts.Len(globex.send, 0)
+}
@@ -0,0 +1,53 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+)
+
+const (
+	CommandSubscribe   = "subscribe"
+	CommandUnsubscribe = "unsubscribe"
+	CommandCreate      = "create"
+	CommandUpdate      = "update"
+	CommandDelete      = "delete"
+	CommandPing        = "ping"
+
+	MessageAck   = "ack"
+	MessageError = "error"
+	MessagePong  = "pong"
+)
+
+// Command is a message sent by a client. ID is an optional correlation id
+// echoed back in the acknowledgement.
+type Command struct {
+	ID          string      `json:"id,omitempty"`
+	Type        string      `json:"type"`
+	TaskIDs     []uuid.UUID `json:"task_ids,omitempty"`
+	TaskID      uuid.UUID   `json:"task_id,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	IsCompleted bool        `json:"is_completed,omitempty"`
+	Priority    string      `json:"priority,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	DueAt       *time.Time  `json:"due_at,omitempty"`
+}
+
+// Message is sent by the server, either as a reply to a command or as a
+// broadcast of a change made by someone else.
+type Message struct {
+	ID     string              `json:"id,omitempty"`
+	Type   string              `json:"type"`
+	TaskID *uuid.UUID          `json:"task_id,omitempty"`
+	Task   *entity.Task        `json:"task,omitempty"`
+	Error  *dtos.ErrorResponse `json:"error,omitempty"`
+}
+
+func encode(msg Message) []byte {
+	data, _ := json.Marshal(msg)
+	return data
+}
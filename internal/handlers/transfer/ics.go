@@ -0,0 +1,381 @@
+package transfer
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/validation"
+)
+
+// CalendarContentType is the media type of RFC 5545 calendars.
+const CalendarContentType = "text/calendar; charset=utf-8"
+
+const (
+	calendarProductID = "-//manuelbeos//code-branch-todo-test//EN"
+	// calendarLineOctets is the longest content line, longer ones are folded.
+	calendarLineOctets = 75
+	dateTimeLayout     = "20060102T150405Z"
+	localTimeLayout    = "20060102T150405"
+	dateLayout         = "20060102"
+)
+
+// calendarNamespace turns UIDs that aren't task ids into stable task ids, so
+// uploading the same calendar again updates the tasks it created.
+var calendarNamespace = uuid.MustParse("0b7d2a61-5b1e-4c3e-9a59-1f1b4f2f6c0e")
+
+// CalendarNamespace returns the namespace of the UIDs uploaded by owner in
+// tenant. Every caller gets its own, so the same UID uploaded by two users
+// makes two tasks instead of one taking the other's id.
+func CalendarNamespace(tenant string, owner string) uuid.UUID {
+	// tenants never hold a NUL, the pair can't be read another way
+	return uuid.NewSHA1(calendarNamespace, []byte(tenant+"\x00"+owner))
+}
+
+// TaskIDOf returns the task id of a VTODO UID: the UID itself when it is a
+// uuid, a uuid derived from it in namespace otherwise.
+func TaskIDOf(namespace uuid.UUID, uid string) uuid.UUID {
+	if id, err := uuid.Parse(uid); err == nil {
+		return id
+	}
+	return uuid.NewSHA1(namespace, []byte(uid))
+}
+
+// NewCalendarEncoder writes tasks as the VTODO components of a VCALENDAR.
+func NewCalendarEncoder(w io.Writer) Encoder {
+	return &calendarEncoder{w: bufio.NewWriter(w)}
+}
+
+type calendarEncoder struct {
+	w             *bufio.Writer
+	headerWritten bool
+}
+
+func (e *calendarEncoder) line(name string, value string) {
+	content := name + ":" + value
+	// folded lines start with a space, so they hold one octet less
+	limit := calendarLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		e.w.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+		limit = calendarLineOctets - 1
+	}
+	e.w.WriteString(content + "\r\n")
+}
+
+func (e *calendarEncoder) writeHeader() {
+	if e.headerWritten {
+		return
+	}
+	e.headerWritten = true
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", calendarProductID)
+	e.line("CALSCALE", "GREGORIAN")
+}
+
+func (e *calendarEncoder) Encode(task *entity.Task) error {
+	e.writeHeader()
+
+	e.line("BEGIN", "VTODO")
+	e.line("UID", task.Id.String())
+	e.line("DTSTAMP", formatDateTime(task.UpdatedAt))
+	e.line("CREATED", formatDateTime(task.CreatedAt))
+	e.line("LAST-MODIFIED", formatDateTime(task.UpdatedAt))
+	e.line("SUMMARY", escapeText(task.Title))
+	if task.Description != "" {
+		e.line("DESCRIPTION", escapeText(task.Description))
+	}
+	if task.IsCompleted {
+		e.line("STATUS", "COMPLETED")
+	} else {
+		e.line("STATUS", "NEEDS-ACTION")
+	}
+	if task.CompletedAt != nil {
+		e.line("COMPLETED", formatDateTime(*task.CompletedAt))
+	}
+	if task.DueAt != nil {
+		e.line("DUE", formatDateTime(*task.DueAt))
+	}
+	e.line("PRIORITY", strconv.Itoa(calendarPriorities[task.EffectivePriority()]))
+	if len(task.Tags) > 0 {
+		escaped := make([]string, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			escaped = append(escaped, escapeText(tag))
+		}
+		e.line("CATEGORIES", strings.Join(escaped, ","))
+	}
+	e.line("END", "VTODO")
+
+	return e.w.Flush()
+}
+
+func (e *calendarEncoder) Close() error {
+	e.writeHeader()
+	e.line("END", "VCALENDAR")
+	return e.w.Flush()
+}
+
+// calendarPriorities maps priorities to the RFC 5545 scale, 1 is the
+// highest and 9 the lowest.
+var calendarPriorities = map[entity.Priority]int{entity.PriorityHigh: 1, entity.PriorityNormal: 5, entity.PriorityLow: 9}
+
+func priorityOf(value int) entity.Priority {
+	switch {
+	case value >= 1 && value <= 4:
+		return entity.PriorityHigh
+	case value == 5:
+		return entity.PriorityNormal
+	case value >= 6 && value <= 9:
+		return entity.PriorityLow
+	}
+	// 0 means undefined
+	return ""
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+func unescapeText(text string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped && (r == 'n' || r == 'N'):
+			b.WriteRune('\n')
+		case escaped:
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+	return b.String()
+}
+
+// splitText splits a list of escaped texts on the commas that aren't
+// escaped.
+func splitText(text string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// contentLine is a property of a component, its parameter names are upper
+// cased.
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseContentLine(line string) (contentLine, bool) {
+	// the value starts at the first colon outside of quoted parameters
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return contentLine{}, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	parsed := contentLine{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		parsed.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return parsed, true
+}
+
+// unfoldLines reads the content lines of a calendar, joining folded ones.
+func unfoldLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineBytes)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// DecodeCalendar reads the VTODO components of a calendar, other components
+// are ignored. UIDs that aren't uuids get a task id in namespace, see
+// CalendarNamespace. Row numbers count the VTODO components from 1.
+func DecodeCalendar(r io.Reader, namespace uuid.UUID) ([]Row, error) {
+	lines, err := unfoldLines(r)
+	if err != nil || len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, ErrMalformed
+	}
+
+	var rows []Row
+	var todo []contentLine
+	inTodo := false
+	// nested components, e.g. the VALARM of a VTODO, are skipped
+	depth := 0
+	for _, line := range lines[1:] {
+		property, ok := parseContentLine(line)
+		if !ok {
+			return nil, ErrMalformed
+		}
+		value := strings.ToUpper(property.value)
+
+		switch {
+		case property.name == "BEGIN" && value == "VTODO" && depth == 0:
+			inTodo, todo = true, nil
+		case property.name == "END" && value == "VTODO" && depth == 0:
+			if !inTodo {
+				return nil, ErrMalformed
+			}
+			inTodo = false
+			rows = append(rows, todoRow(len(rows)+1, todo, namespace))
+		case property.name == "BEGIN":
+			depth++
+		case property.name == "END" && depth > 0:
+			depth--
+		case property.name == "END" && value == "VCALENDAR":
+			if inTodo {
+				return nil, ErrMalformed
+			}
+			return rows, nil
+		case inTodo && depth == 0:
+			todo = append(todo, property)
+		}
+	}
+
+	return nil, ErrMalformed
+}
+
+func todoRow(number int, properties []contentLine, namespace uuid.UUID) Row {
+	row := Row{Number: number}
+	invalid := func(field string, reason string) Row {
+		row.Err = &validation.FieldError{Field: field, Reason: reason}
+		return row
+	}
+
+	task := &row.Task
+	status := ""
+	for _, property := range properties {
+		var err error
+		switch property.name {
+		case "UID":
+			task.Id = TaskIDOf(namespace, property.value)
+		case "SUMMARY":
+			task.Title = unescapeText(property.value)
+		case "DESCRIPTION":
+			task.Description = unescapeText(property.value)
+		case "STATUS":
+			status = strings.ToUpper(property.value)
+		case "PRIORITY":
+			priority, err := strconv.Atoi(property.value)
+			if err != nil || priority < 0 || priority > 9 {
+				return invalid("priority", "must be a number from 0 to 9")
+			}
+			task.Priority = priorityOf(priority)
+		case "CATEGORIES":
+			for _, category := range splitText(property.value) {
+				// calendar apps allow spaces in categories, tags don't
+				if category = strings.Join(strings.Fields(unescapeText(category)), "-"); category != "" {
+					task.Tags = append(task.Tags, category)
+				}
+			}
+		case "DUE":
+			task.DueAt, err = parseCalendarTime(property)
+		case "COMPLETED":
+			task.CompletedAt, err = parseCalendarTime(property)
+		case "CREATED":
+			var created *time.Time
+			if created, err = parseCalendarTime(property); created != nil {
+				task.CreatedAt = *created
+			}
+		case "LAST-MODIFIED":
+			var modified *time.Time
+			if modified, err = parseCalendarTime(property); modified != nil {
+				task.UpdatedAt = *modified
+			}
+		}
+		if err != nil {
+			return invalid(strings.ToLower(property.name), "must be a date or a date-time")
+		}
+	}
+	if task.Id == uuid.Nil {
+		return invalid("uid", "is required")
+	}
+
+	task.IsCompleted = status == "COMPLETED" || (status == "" && task.CompletedAt != nil)
+	if !task.IsCompleted {
+		task.CompletedAt = nil
+	}
+	return row
+}
+
+// parseCalendarTime reads UTC date-times, local ones in their TZID (UTC when
+// unknown) and dates, as midnight UTC.
+func parseCalendarTime(property contentLine) (*time.Time, error) {
+	var parsed time.Time
+	var err error
+	switch {
+	case strings.EqualFold(property.params["VALUE"], "DATE") || len(property.value) == len(dateLayout):
+		parsed, err = time.Parse(dateLayout, property.value)
+	case strings.HasSuffix(property.value, "Z"):
+		parsed, err = time.Parse(dateTimeLayout, property.value)
+	default:
+		location := time.UTC
+		if tzid := property.params["TZID"]; tzid != "" {
+			if loaded, loadErr := time.LoadLocation(tzid); loadErr == nil {
+				location = loaded
+			}
+		}
+		parsed, err = time.ParseInLocation(localTimeLayout, property.value, location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parsed = parsed.UTC()
+	return &parsed, nil
+}
@@ -0,0 +1,120 @@
+package transfer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_Encode_Decode_Round_Trip(t *testing.T) {
+	asserts := assert.New(t)
+	created := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	completedAt := created.Add(time.Hour)
+	tasks := []*entity.Task{
+		{Id: uuid.New(), Title: "First; with, separators", Description: "two\nlines " + strings.Repeat("é", 80), Priority: entity.PriorityHigh, Tags: []string{"home", "work"}, DueAt: &completedAt, IsCompleted: true, CompletedAt: &completedAt, CreatedAt: created, UpdatedAt: completedAt},
+		{Id: uuid.New(), Title: "Second", Priority: entity.PriorityLow, CreatedAt: created, UpdatedAt: created},
+	}
+
+	buffer := &bytes.Buffer{}
+	encoder := NewCalendarEncoder(buffer)
+	for _, task := range tasks {
+		asserts.Nil(encoder.Encode(task))
+	}
+	asserts.Nil(encoder.Close())
+
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\r\n"), "\r\n") {
+		asserts.LessOrEqual(len(line), calendarLineOctets)
+	}
+
+	rows, err := DecodeCalendar(buffer, CalendarNamespace("", ""))
+
+	asserts.Nil(err)
+	asserts.Len(rows, len(tasks))
+	for i, row := range rows {
+		asserts.Nil(row.Err)
+		asserts.Equal(i+1, row.Number)
+		asserts.Equal(*tasks[i], row.Task)
+	}
+}
+
+func TestCalendar_Encode_Empty(t *testing.T) {
+	asserts := assert.New(t)
+	buffer := &bytes.Buffer{}
+
+	asserts.Nil(NewCalendarEncoder(buffer).Close())
+
+	asserts.Equal("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:"+calendarProductID+"\r\nCALSCALE:GREGORIAN\r\nEND:VCALENDAR\r\n", buffer.String())
+}
+
+func TestDecodeCalendar_Rows(t *testing.T) {
+	asserts := assert.New(t)
+	due := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	berlinDue := time.Date(2026, 11, 2, 8, 30, 0, 0, time.UTC)
+	completed := time.Date(2026, 10, 30, 12, 0, 0, 0, time.UTC)
+	namespace := CalendarNamespace("acme", "alice")
+
+	tests := []struct {
+		name     string
+		todo     string
+		expected Row
+	}{
+		{name: "DecodeCalendar - Date due, other UID", todo: "UID:groceries@example.com\nSUMMARY:Buy milk\nDUE;VALUE=DATE:20261102\nPRIORITY:3\nCATEGORIES:Home Chores,errands",
+			expected: Row{Number: 1, Task: entity.Task{Id: TaskIDOf(namespace, "groceries@example.com"), Title: "Buy milk", DueAt: &due, Priority: entity.PriorityHigh, Tags: []string{"Home-Chores", "errands"}}}},
+		{name: "DecodeCalendar - TZID due, completed", todo: "UID:6f1c2a4e-0000-4000-8000-000000000001\nSUMMARY:Call\nDUE;TZID=Europe/Berlin:20261102T093000\nCOMPLETED:20261030T120000Z",
+			expected: Row{Number: 1, Task: entity.Task{Id: uuid.MustParse("6f1c2a4e-0000-4000-8000-000000000001"), Title: "Call", DueAt: &berlinDue, IsCompleted: true, CompletedAt: &completed}}},
+		{name: "DecodeCalendar - Reopened", todo: "UID:a\nSUMMARY:Again\nSTATUS:NEEDS-ACTION\nCOMPLETED:20261030T120000Z\nPRIORITY:0",
+			expected: Row{Number: 1, Task: entity.Task{Id: TaskIDOf(namespace, "a"), Title: "Again"}}},
+		{name: "DecodeCalendar - Alarm skipped", todo: "UID:a\nSUMMARY:Outer\nBEGIN:VALARM\nSUMMARY:Inner\nEND:VALARM",
+			expected: Row{Number: 1, Task: entity.Task{Id: TaskIDOf(namespace, "a"), Title: "Outer"}}},
+		{name: "DecodeCalendar - Missing UID", todo: "SUMMARY:No uid",
+			expected: Row{Number: 1, Task: entity.Task{Title: "No uid"}, Err: &validation.FieldError{Field: "uid", Reason: "is required"}}},
+		{name: "DecodeCalendar - Invalid priority", todo: "UID:a\nPRIORITY:high",
+			expected: Row{Number: 1, Task: entity.Task{Id: TaskIDOf(namespace, "a")}, Err: &validation.FieldError{Field: "priority", Reason: "must be a number from 0 to 9"}}},
+		{name: "DecodeCalendar - Invalid due", todo: "UID:a\nDUE:tomorrow",
+			expected: Row{Number: 1, Task: entity.Task{Id: TaskIDOf(namespace, "a")}, Err: &validation.FieldError{Field: "due", Reason: "must be a date or a date-time"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Meeting\nEND:VEVENT\nBEGIN:VTODO\n" + tt.todo + "\nEND:VTODO\nEND:VCALENDAR\n"
+
+			rows, err := DecodeCalendar(strings.NewReader(calendar), namespace)
+
+			asserts.Nil(err)
+			asserts.Equal([]Row{tt.expected}, rows)
+		})
+	}
+}
+
+func TestDecodeCalendar_Malformed(t *testing.T) {
+	asserts := assert.New(t)
+
+	for _, calendar := range []string{
+		"",
+		"BEGIN:VTODO\nEND:VTODO\n",
+		"BEGIN:VCALENDAR\nBEGIN:VTODO\nUID:a\n",
+		"BEGIN:VCALENDAR\nBEGIN:VTODO\nnot a property\nEND:VTODO\nEND:VCALENDAR\n",
+	} {
+		_, err := DecodeCalendar(strings.NewReader(calendar), CalendarNamespace("", ""))
+		asserts.ErrorIs(err, ErrMalformed)
+	}
+}
+
+func TestTaskIDOf(t *testing.T) {
+	asserts := assert.New(t)
+	id := uuid.New()
+
+	alice := CalendarNamespace("acme", "alice")
+
+	asserts.Equal(id, TaskIDOf(alice, id.String()))
+	asserts.Equal(TaskIDOf(alice, "groceries@example.com"), TaskIDOf(CalendarNamespace("acme", "alice"), "groceries@example.com"))
+	asserts.NotEqual(TaskIDOf(alice, "groceries@example.com"), TaskIDOf(alice, "laundry@example.com"))
+	asserts.NotEqual(TaskIDOf(alice, "groceries@example.com"), TaskIDOf(CalendarNamespace("acme", "bob"), "groceries@example.com"))
+	asserts.NotEqual(TaskIDOf(alice, "groceries@example.com"), TaskIDOf(CalendarNamespace("globex", "alice"), "groceries@example.com"))
+}
@@ -0,0 +1,179 @@
+package transfer
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+)
+
+// markdownIndent nests subtasks under the text of their parent item.
+const markdownIndent = "  "
+
+// tabWidth is the number of spaces a tab counts for in indents.
+const tabWidth = 4
+
+var (
+	// listItem matches a bullet or an ordered list item, without its indent.
+	listItem = regexp.MustCompile(`^(?:[-*+]|\d{1,9}[.)])(?:[ \t]+(.*))?$`)
+	checkbox = regexp.MustCompile(`^\[([ xX])\](?:[ \t]+(.*))?$`)
+)
+
+// markdownEncoder keeps the tasks until Close, subtasks are written under
+// their parent wherever they come.
+type markdownEncoder struct {
+	w     io.Writer
+	tasks []*entity.Task
+}
+
+func (e *markdownEncoder) Encode(task *entity.Task) error {
+	e.tasks = append(e.tasks, task)
+	return nil
+}
+
+func (e *markdownEncoder) Close() error {
+	ids := make(map[uuid.UUID]bool, len(e.tasks))
+	for _, task := range e.tasks {
+		ids[task.Id] = true
+	}
+	children := map[uuid.UUID][]*entity.Task{}
+	var roots []*entity.Task
+	for _, task := range e.tasks {
+		// subtasks whose parent isn't exported are written at the top
+		if task.ParentId != nil && ids[*task.ParentId] {
+			children[*task.ParentId] = append(children[*task.ParentId], task)
+		} else {
+			roots = append(roots, task)
+		}
+	}
+
+	w := bufio.NewWriter(e.w)
+	written := make(map[uuid.UUID]bool, len(e.tasks))
+	var write func(task *entity.Task, indent string)
+	write = func(task *entity.Task, indent string) {
+		written[task.Id] = true
+		box := "[ ]"
+		if task.IsCompleted {
+			box = "[x]"
+		}
+		w.WriteString(indent + "- " + box + " " + task.Title + "\n")
+		if task.Description != "" {
+			for _, line := range strings.Split(task.Description, "\n") {
+				if line = strings.TrimSpace(line); line == "" {
+					w.WriteString("\n")
+					continue
+				}
+				// a line looking like an item would come back as a subtask
+				if strings.HasPrefix(line, `\`) || listItem.MatchString(line) {
+					line = `\` + line
+				}
+				w.WriteString(indent + markdownIndent + line + "\n")
+			}
+		}
+		for _, child := range children[task.Id] {
+			if !written[child.Id] {
+				write(child, indent+markdownIndent)
+			}
+		}
+	}
+	for _, task := range roots {
+		write(task, "")
+	}
+	// tasks in a parent cycle have no root to be written under
+	for _, task := range e.tasks {
+		if !written[task.Id] {
+			write(task, "")
+		}
+	}
+
+	return w.Flush()
+}
+
+// openItem is an item whose text and subtasks may still follow.
+type openItem struct {
+	indent int
+	row    int
+}
+
+// decodeMarkdown reads the checklist items of a markdown document: "- [ ]"
+// and "- [x]" items, at any depth, and any item nested under one of them are
+// tasks. Nested items are subtasks and the indented text under an item is its
+// description. The rest of the document is ignored. Tasks get new ids and
+// their row number is their line in the document.
+func decodeMarkdown(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineBytes)
+
+	var rows []Row
+	var open []openItem
+	blank := false
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" {
+			blank = true
+			continue
+		}
+
+		indent, text := splitIndent(line)
+		for len(open) > 0 && open[len(open)-1].indent >= indent {
+			open = open[:len(open)-1]
+		}
+
+		if title, completed, ok := checklistItem(text, len(open) > 0); ok {
+			task := entity.Task{Id: uuid.New(), Title: title, IsCompleted: completed}
+			if len(open) > 0 {
+				parent := rows[open[len(open)-1].row].Task.Id
+				task.ParentId = &parent
+			}
+			open = append(open, openItem{indent: indent, row: len(rows)})
+			rows = append(rows, Row{Number: number, Task: task})
+		} else if len(open) > 0 {
+			task := &rows[open[len(open)-1].row].Task
+			switch {
+			case task.Description == "":
+			case blank:
+				task.Description += "\n\n"
+			default:
+				task.Description += "\n"
+			}
+			task.Description += strings.TrimPrefix(text, `\`)
+		}
+		blank = false
+	}
+	if scanner.Err() != nil {
+		return nil, ErrMalformed
+	}
+
+	return rows, nil
+}
+
+func splitIndent(line string) (int, string) {
+	indent := 0
+	for i, r := range line {
+		switch r {
+		case ' ':
+			indent++
+		case '\t':
+			indent += tabWidth - indent%tabWidth
+		default:
+			return indent, line[i:]
+		}
+	}
+	return indent, ""
+}
+
+// checklistItem reads a list item, which is a task when it has a checkbox or
+// is nested under a task.
+func checklistItem(text string, nested bool) (title string, completed bool, ok bool) {
+	item := listItem.FindStringSubmatch(text)
+	if item == nil {
+		return "", false, false
+	}
+	if box := checkbox.FindStringSubmatch(item[1]); box != nil {
+		return box[2], box[1] != " ", true
+	}
+	return item[1], false, nested
+}
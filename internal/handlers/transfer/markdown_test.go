@@ -0,0 +1,111 @@
+package transfer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdown_Encode(t *testing.T) {
+	asserts := assert.New(t)
+	completedAt := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	groceries := &entity.Task{Id: uuid.New(), Title: "Groceries", Description: "For the weekend\n\n- not a subtask"}
+	milk := &entity.Task{Id: uuid.New(), Title: "Milk", IsCompleted: true, CompletedAt: &completedAt, ParentId: &groceries.Id}
+	oat := &entity.Task{Id: uuid.New(), Title: "Oat", ParentId: &milk.Id}
+	orphan := &entity.Task{Id: uuid.New(), Title: "Orphan", ParentId: &uuid.Nil}
+
+	buffer := &bytes.Buffer{}
+	encoder := NewEncoder(buffer, FormatMarkdown)
+	// subtasks go under their parent whatever the order
+	for _, task := range []*entity.Task{oat, milk, groceries, orphan} {
+		asserts.Nil(encoder.Encode(task))
+	}
+	asserts.Nil(encoder.Close())
+
+	asserts.Equal("- [ ] Groceries\n"+
+		"  For the weekend\n"+
+		"\n"+
+		"  \\- not a subtask\n"+
+		"  - [x] Milk\n"+
+		"    - [ ] Oat\n"+
+		"- [ ] Orphan\n", buffer.String())
+}
+
+func TestMarkdown_Encode_Decode_Round_Trip(t *testing.T) {
+	asserts := assert.New(t)
+	completedAt := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	parent := &entity.Task{Id: uuid.New(), Title: "Parent", Description: "first\n\nsecond\n\\ backslash\n1. numbered", IsCompleted: true, CompletedAt: &completedAt}
+	child := &entity.Task{Id: uuid.New(), Title: "Child", Description: "under the child", ParentId: &parent.Id}
+	grandchild := &entity.Task{Id: uuid.New(), Title: "Grandchild", IsCompleted: true, CompletedAt: &completedAt, ParentId: &child.Id}
+	sibling := &entity.Task{Id: uuid.New(), Title: "Sibling"}
+
+	buffer := &bytes.Buffer{}
+	encoder := NewEncoder(buffer, FormatMarkdown)
+	for _, task := range []*entity.Task{parent, child, grandchild, sibling} {
+		asserts.Nil(encoder.Encode(task))
+	}
+	asserts.Nil(encoder.Close())
+
+	rows, err := Decode(buffer, FormatMarkdown)
+
+	asserts.Nil(err)
+	asserts.Len(rows, 4)
+	for i, expected := range []*entity.Task{parent, child, grandchild, sibling} {
+		asserts.Nil(rows[i].Err)
+		asserts.Equal(expected.Title, rows[i].Task.Title)
+		asserts.Equal(expected.Description, rows[i].Task.Description)
+		asserts.Equal(expected.IsCompleted, rows[i].Task.IsCompleted)
+	}
+	asserts.Nil(rows[0].Task.ParentId)
+	asserts.Equal(&rows[0].Task.Id, rows[1].Task.ParentId)
+	asserts.Equal(&rows[1].Task.Id, rows[2].Task.ParentId)
+	asserts.Nil(rows[3].Task.ParentId)
+}
+
+func TestDecode_Markdown(t *testing.T) {
+	asserts := assert.New(t)
+	document := strings.Join([]string{
+		"# Release",                  // 1
+		"Some notes, ignored.",       // 2
+		"- plain bullet, ignored",    // 3
+		"* [X] Write changelog",      // 4
+		"    - Check links",          // 5
+		"\t1. [ ] Proofread",         // 6
+		"       Twice.",              // 7
+		"  More about the changelog", // 8
+		"",                           // 9
+		"## Later",                   // 10
+		"  - [ ]",                    // 11
+		"not a description",          // 12
+	}, "\n")
+
+	rows, err := Decode(strings.NewReader(document), FormatMarkdown)
+
+	asserts.Nil(err)
+	asserts.Len(rows, 4)
+	asserts.Equal([]int{4, 5, 6, 11}, []int{rows[0].Number, rows[1].Number, rows[2].Number, rows[3].Number})
+
+	changelog := rows[0].Task
+	asserts.Equal("Write changelog", changelog.Title)
+	asserts.True(changelog.IsCompleted)
+	asserts.Equal("More about the changelog", changelog.Description)
+	asserts.Nil(changelog.ParentId)
+
+	asserts.Equal("Check links", rows[1].Task.Title)
+	asserts.False(rows[1].Task.IsCompleted)
+	asserts.Equal(&changelog.Id, rows[1].Task.ParentId)
+
+	asserts.Equal("Proofread", rows[2].Task.Title)
+	asserts.Equal("Twice.", rows[2].Task.Description)
+	asserts.Equal(&changelog.Id, rows[2].Task.ParentId)
+
+	// an empty item is reported by the import, as a missing title
+	asserts.Equal("", rows[3].Task.Title)
+	asserts.Equal("", rows[3].Task.Description)
+	asserts.Nil(rows[3].Task.ParentId)
+}
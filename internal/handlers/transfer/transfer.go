@@ -0,0 +1,413 @@
+// Package transfer reads and writes tasks in the formats of the export and
+// import endpoints: CSV, a JSON array, newline delimited JSON, markdown
+// checklists and iCalendar.
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/validation"
+)
+
+type Format string
+
+const (
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatMarkdown Format = "markdown"
+)
+
+var contentTypes = map[Format]string{
+	FormatCSV:      "text/csv; charset=utf-8",
+	FormatJSON:     "application/json; charset=utf-8",
+	FormatNDJSON:   "application/x-ndjson",
+	FormatMarkdown: "text/markdown; charset=utf-8",
+}
+
+func (f Format) Valid() bool {
+	_, ok := contentTypes[f]
+	return ok
+}
+
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// Extension is the file extension of the format, without the dot.
+func (f Format) Extension() string {
+	if f == FormatMarkdown {
+		return "md"
+	}
+	return string(f)
+}
+
+// FormatOf returns the format of a Content-Type, JSON when it isn't CSV,
+// NDJSON or markdown.
+func FormatOf(contentType string) Format {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "text/csv":
+		return FormatCSV
+	case "application/x-ndjson", "application/ndjson":
+		return FormatNDJSON
+	case "text/markdown", "text/x-markdown":
+		return FormatMarkdown
+	}
+	return FormatJSON
+}
+
+// Header lists the CSV columns. Tags are separated by semicolons and
+// timestamps are RFC 3339.
+var Header = []string{"id", "parent_id", "title", "description", "priority", "tags", "due_at", "is_completed", "completed_at", "reopened_count", "created_at", "updated_at"}
+
+const tagSeparator = ";"
+
+// formulaPrefixes start the CSV cells spreadsheets would run as formulas.
+// Exported cells starting with one of them, or with formulaEscape, get
+// formulaEscape in front, which imports take back off.
+const (
+	formulaPrefixes = "=+-@\t\r"
+	formulaEscape   = "'"
+)
+
+// MaxLineBytes is the longest NDJSON line read.
+const MaxLineBytes = 1 << 20
+
+// Encoder writes tasks one by one, Close ends the document.
+type Encoder interface {
+	Encode(task *entity.Task) error
+	Close() error
+}
+
+func NewEncoder(w io.Writer, format Format) Encoder {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}
+	case FormatNDJSON:
+		return &ndjsonEncoder{w: w}
+	case FormatMarkdown:
+		return &markdownEncoder{w: w}
+	}
+	return &jsonEncoder{w: w}
+}
+
+type csvEncoder struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return e.w.Write(Header)
+}
+
+func (e *csvEncoder) Encode(task *entity.Task) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	return e.w.Write([]string{
+		task.Id.String(),
+		formatParent(task.ParentId),
+		escapeFormula(task.Title),
+		escapeFormula(task.Description),
+		string(task.EffectivePriority()),
+		escapeFormula(strings.Join(task.Tags, tagSeparator)),
+		formatOptional(task.DueAt),
+		strconv.FormatBool(task.IsCompleted),
+		formatOptional(task.CompletedAt),
+		strconv.Itoa(task.ReopenedCount),
+		task.CreatedAt.Format(time.RFC3339Nano),
+		task.UpdatedAt.Format(time.RFC3339Nano),
+	})
+}
+
+// escapeFormula keeps spreadsheets opening an export from running a cell
+// written by a user as a formula.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes+formulaEscape, rune(cell[0])) {
+		return formulaEscape + cell
+	}
+	return cell
+}
+
+// unescapeFormula takes back what escapeFormula added.
+func unescapeFormula(cell string) string {
+	if rest, ok := strings.CutPrefix(cell, formulaEscape); ok && rest != "" && strings.ContainsRune(formulaPrefixes+formulaEscape, rune(rest[0])) {
+		return rest
+	}
+	return cell
+}
+
+func formatParent(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func formatOptional(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Encode(task *entity.Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if e.count == 0 {
+		separator = "[\n"
+	}
+	e.count++
+	_, err = io.WriteString(e.w, separator+string(data))
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+type ndjsonEncoder struct {
+	w io.Writer
+}
+
+func (e *ndjsonEncoder) Encode(task *entity.Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// Row is a task read from an import. Number is its position in the import
+// from 1, the CSV header and blank lines aside. Err is set instead of Task
+// when the task couldn't be read.
+type Row struct {
+	Number int
+	Task   entity.Task
+	Err    *validation.FieldError
+}
+
+// ErrMalformed is returned when the document itself can't be read, e.g. a
+// broken JSON array or a CSV file without a title column.
+var ErrMalformed = errors.New("malformed import")
+
+// Decode reads every task of an import. Tasks that can't be read are
+// reported in their row, the error is reserved to malformed documents.
+func Decode(r io.Reader, format Format) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	case FormatMarkdown:
+		return decodeMarkdown(r)
+	}
+	return decodeJSON(r)
+}
+
+func decodeJSON(r io.Reader) ([]Row, error) {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, ErrMalformed
+	}
+
+	var rows []Row
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, ErrMalformed
+		}
+		rows = append(rows, jsonRow(len(rows)+1, raw))
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, ErrMalformed
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, ErrMalformed
+	}
+
+	return rows, nil
+}
+
+func decodeNDJSON(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineBytes)
+
+	var rows []Row
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		rows = append(rows, jsonRow(len(rows)+1, line))
+	}
+	if scanner.Err() != nil {
+		return nil, ErrMalformed
+	}
+
+	return rows, nil
+}
+
+func jsonRow(number int, data []byte) Row {
+	row := Row{Number: number}
+	if err := json.Unmarshal(data, &row.Task); err != nil {
+		field := "task"
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			field = typeErr.Field
+		}
+		row.Err = &validation.FieldError{Field: field, Reason: "is not valid"}
+	}
+	return row
+}
+
+func decodeCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, ErrMalformed
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		rows = append(rows, csvRow(len(rows)+1, columns, record))
+	}
+}
+
+func csvRow(number int, columns map[string]int, record []string) Row {
+	row := Row{Number: number}
+	value := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(unescapeFormula(record[i]))
+	}
+	invalid := func(field string, reason string) Row {
+		row.Err = &validation.FieldError{Field: field, Reason: reason}
+		return row
+	}
+
+	task := &row.Task
+	task.Title = value("title")
+	task.Description = value("description")
+	task.Priority = entity.Priority(value("priority"))
+	for _, tag := range strings.Split(value("tags"), tagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+
+	var err error
+	if id := value("id"); id != "" {
+		if task.Id, err = uuid.Parse(id); err != nil {
+			return invalid("id", "must be a uuid")
+		}
+	}
+	if parent := value("parent_id"); parent != "" {
+		parentID, err := uuid.Parse(parent)
+		if err != nil {
+			return invalid("parent_id", "must be a uuid")
+		}
+		task.ParentId = &parentID
+	}
+	if completed := value("is_completed"); completed != "" {
+		if task.IsCompleted, err = strconv.ParseBool(completed); err != nil {
+			return invalid("is_completed", "must be true or false")
+		}
+	}
+	if count := value("reopened_count"); count != "" {
+		if task.ReopenedCount, err = strconv.Atoi(count); err != nil {
+			return invalid("reopened_count", "must be a number")
+		}
+	}
+	for _, timestamp := range []struct {
+		field  string
+		target *time.Time
+	}{
+		{"created_at", &task.CreatedAt},
+		{"updated_at", &task.UpdatedAt},
+	} {
+		if text := value(timestamp.field); text != "" {
+			if *timestamp.target, err = time.Parse(time.RFC3339Nano, text); err != nil {
+				return invalid(timestamp.field, "must be a RFC 3339 timestamp")
+			}
+		}
+	}
+	for _, timestamp := range []struct {
+		field  string
+		target **time.Time
+	}{
+		{"due_at", &task.DueAt},
+		{"completed_at", &task.CompletedAt},
+	} {
+		if text := value(timestamp.field); text != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return invalid(timestamp.field, "must be a RFC 3339 timestamp")
+			}
+			*timestamp.target = &parsed
+		}
+	}
+
+	return row
+}
@@ -0,0 +1,122 @@
+package transfer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode_Decode_Round_Trip(t *testing.T) {
+	asserts := assert.New(t)
+	created := time.Date(2026, 10, 5, 9, 0, 0, 123, time.UTC)
+	completedAt := created.Add(time.Hour)
+	tasks := []*entity.Task{
+		{Id: uuid.New(), Title: "First, with a comma", Description: "two\nlines", Priority: entity.PriorityHigh, Tags: []string{"home", "work"}, DueAt: &completedAt, IsCompleted: true, CompletedAt: &completedAt, ReopenedCount: 2, CreatedAt: created, UpdatedAt: completedAt},
+		{Id: uuid.New(), Title: "Second", Priority: entity.PriorityNormal, CreatedAt: created, UpdatedAt: created},
+	}
+	tasks[1].ParentId = &tasks[0].Id
+
+	for _, format := range []Format{FormatCSV, FormatJSON, FormatNDJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			encoder := NewEncoder(buffer, format)
+			for _, task := range tasks {
+				asserts.Nil(encoder.Encode(task))
+			}
+			asserts.Nil(encoder.Close())
+
+			rows, err := Decode(buffer, format)
+
+			asserts.Nil(err)
+			asserts.Len(rows, len(tasks))
+			for i, row := range rows {
+				asserts.Nil(row.Err)
+				asserts.Equal(i+1, row.Number)
+				asserts.Equal(*tasks[i], row.Task)
+			}
+		})
+	}
+}
+
+func TestEncode_CSV_Formulas(t *testing.T) {
+	asserts := assert.New(t)
+	created := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	task := &entity.Task{Id: uuid.New(), Title: "=HYPERLINK(\"http://evil\")", Description: "'quoted", Priority: entity.PriorityNormal, Tags: []string{"-x"}, CreatedAt: created, UpdatedAt: created}
+	buffer := &bytes.Buffer{}
+	encoder := NewEncoder(buffer, FormatCSV)
+	asserts.Nil(encoder.Encode(task))
+	asserts.Nil(encoder.Close())
+
+	asserts.Contains(buffer.String(), `,"'=HYPERLINK(""http://evil"")",''quoted,normal,'-x,`)
+
+	rows, err := Decode(buffer, FormatCSV)
+	asserts.Nil(err)
+	asserts.Len(rows, 1)
+	asserts.Equal(*task, rows[0].Task)
+
+	// a quote not escaping anything is kept
+	rows, err = Decode(strings.NewReader("title,description\n'hello,'+1\n"), FormatCSV)
+	asserts.Nil(err)
+	asserts.Equal("'hello", rows[0].Task.Title)
+	asserts.Equal("+1", rows[0].Task.Description)
+}
+
+func TestEncode_Empty(t *testing.T) {
+	asserts := assert.New(t)
+
+	for format, expected := range map[Format]string{FormatCSV: strings.Join(Header, ",") + "\n", FormatJSON: "[]\n", FormatNDJSON: ""} {
+		buffer := &bytes.Buffer{}
+		asserts.Nil(NewEncoder(buffer, format).Close())
+		asserts.Equal(expected, buffer.String())
+	}
+}
+
+func TestDecode_Rows(t *testing.T) {
+	asserts := assert.New(t)
+
+	tests := []struct {
+		name     string
+		format   Format
+		body     string
+		expected []*validation.FieldError
+		err      error
+	}{
+		{name: "Decode - CSV invalid values", format: FormatCSV, body: "title,id,is_completed,created_at\nok,,,\nbad id,nope,,\nbad bool,,maybe,\nbad date,,,yesterday\n", expected: []*validation.FieldError{
+			nil,
+			{Field: "id", Reason: "must be a uuid"},
+			{Field: "is_completed", Reason: "must be true or false"},
+			{Field: "created_at", Reason: "must be a RFC 3339 timestamp"},
+		}},
+		{name: "Decode - CSV without title", format: FormatCSV, body: "id,description\n", err: ErrMalformed},
+		{name: "Decode - JSON invalid row", format: FormatJSON, body: `[{"title": "ok"}, {"title": 5}]`, expected: []*validation.FieldError{nil, {Field: "title", Reason: "is not valid"}}},
+		{name: "Decode - JSON not an array", format: FormatJSON, body: `{"title": "ok"}`, err: ErrMalformed},
+		{name: "Decode - JSON broken array", format: FormatJSON, body: `[{"title": "ok"},`, err: ErrMalformed},
+		{name: "Decode - NDJSON invalid line", format: FormatNDJSON, body: "{\"title\": \"ok\"}\n\nnot json\n", expected: []*validation.FieldError{nil, {Field: "task", Reason: "is not valid"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Decode(strings.NewReader(tt.body), tt.format)
+
+			asserts.Equal(tt.err, err)
+			asserts.Len(rows, len(tt.expected))
+			for i, row := range rows {
+				asserts.Equal(tt.expected[i], row.Err)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	asserts := assert.New(t)
+
+	asserts.Equal(FormatCSV, FormatOf("text/csv; charset=utf-8"))
+	asserts.Equal(FormatNDJSON, FormatOf("application/x-ndjson"))
+	asserts.Equal(FormatJSON, FormatOf(""))
+}
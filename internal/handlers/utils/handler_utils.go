@@ -1,18 +1,66 @@
 package handler_utils
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 
 	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/dtos"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/requestid"
 )
 
+const ProblemContentType = "application/problem+json"
+
+// ProblemWriter marks the responses of clients accepting problem details,
+// HandlerErrorResponse finds it through the Unwrap method of the writers
+// wrapping it. Instance identifies the request in the problem.
+type ProblemWriter struct {
+	http.ResponseWriter
+	Instance string
+}
+
+func (pw *ProblemWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
+
+// Hijack lets websocket upgrades go through the writer.
+func (pw *ProblemWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := pw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not implement http.Hijacker")
+	}
+	return hijacker.Hijack()
+}
+
+func problemWriter(rw http.ResponseWriter) *ProblemWriter {
+	for {
+		if pw, ok := rw.(*ProblemWriter); ok {
+			return pw
+		}
+		unwrapper, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		rw = unwrapper.Unwrap()
+	}
+}
+
 func HandlerErrorResponse(rw http.ResponseWriter, statusCode int, err error) {
+	if pw := problemWriter(rw); pw != nil {
+		handlerProblemResponse(rw, pw.Instance, statusCode, err)
+		return
+	}
+
 	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
 
 	switch err.(type) {
 	case *dtos.ErrorResponse:
 		errorResponse := err.(*dtos.ErrorResponse)
+		if requestID := rw.Header().Get(requestid.Header); requestID != "" {
+			errorResponse = errorResponse.WithRequestID(requestID)
+		}
 		jsonData, err := json.Marshal(errorResponse)
 		if err != nil {
 			http.Error(rw, "Internal Server Error", http.StatusInternalServerError)
@@ -35,6 +83,32 @@ func HandlerErrorResponse(rw http.ResponseWriter, statusCode int, err error) {
 	}
 }
 
+func handlerProblemResponse(rw http.ResponseWriter, instance string, statusCode int, err error) {
+	problem := dtos.ProblemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    err.Error(),
+		Instance:  instance,
+		RequestID: rw.Header().Get(requestid.Header),
+	}
+	var errorResponse *dtos.ErrorResponse
+	if errors.As(err, &errorResponse) {
+		problem.Detail = errorResponse.Message
+		problem.Errors = errorResponse.Errors
+	}
+
+	jsonData, err := json.Marshal(problem)
+	if err != nil {
+		http.Error(rw, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", ProblemContentType)
+	rw.WriteHeader(statusCode)
+	_, _ = rw.Write(jsonData)
+}
+
 func HandlerSuccessResponse(rw http.ResponseWriter, statusCode int, data interface{}) {
 	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
 	jsonData, err := json.Marshal(data)
@@ -0,0 +1,130 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrKeyReused is returned when a key is sent again with another request.
+var ErrKeyReused = errors.New("idempotency key reused with another request")
+
+// Response is the stored response replayed to the retries of a request.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type entry struct {
+	fingerprint string
+	// done is closed once the first request finished or was aborted.
+	done     chan struct{}
+	response *Response
+	expires  time.Time
+}
+
+// MemoryStore remembers the responses of idempotent requests for ttl.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*entry), ttl: ttl, now: time.Now}
+}
+
+// Start returns the response stored for key, waiting for it while the first
+// request is in flight. When there is none yet key is reserved, returning a
+// nil response, and the caller must Finish or Abort it. fingerprint tells the
+// requests apart, a different one for the same key is ErrKeyReused.
+func (ms *MemoryStore) Start(ctx context.Context, key string, fingerprint string) (*Response, error) {
+	for {
+		ms.mu.Lock()
+		e, ok := ms.entries[key]
+		if ok && e.response != nil && !e.expires.After(ms.now()) {
+			delete(ms.entries, key)
+			ok = false
+		}
+		if !ok {
+			ms.entries[key] = &entry{fingerprint: fingerprint, done: make(chan struct{})}
+			ms.mu.Unlock()
+			return nil, nil
+		}
+		ms.mu.Unlock()
+
+		if e.fingerprint != fingerprint {
+			return nil, ErrKeyReused
+		}
+
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.response != nil {
+			return e.response, nil
+		}
+		// the first request was aborted, this one takes over
+	}
+}
+
+// Finish stores the response of the request that reserved key and wakes up
+// its duplicates.
+func (ms *MemoryStore) Finish(key string, response Response) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	e, ok := ms.entries[key]
+	if !ok || e.response != nil {
+		return
+	}
+	e.response = &response
+	e.expires = ms.now().Add(ms.ttl)
+	close(e.done)
+}
+
+// Abort releases key without a response, so it can be retried.
+func (ms *MemoryStore) Abort(key string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	e, ok := ms.entries[key]
+	if !ok || e.response != nil {
+		return
+	}
+	delete(ms.entries, key)
+	close(e.done)
+}
+
+// Purge forgets the expired responses.
+func (ms *MemoryStore) Purge() {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := ms.now()
+	for key, e := range ms.entries {
+		if e.response != nil && !e.expires.After(now) {
+			delete(ms.entries, key)
+		}
+	}
+}
+
+// Run purges the store every interval until ctx is done.
+func (ms *MemoryStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ms.Purge()
+		}
+	}
+}
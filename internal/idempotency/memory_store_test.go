@@ -0,0 +1,101 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Replay(t *testing.T) {
+	asserts := assert.New(t)
+	store := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	stored, err := store.Start(ctx, "key", "body")
+	asserts.Nil(err)
+	asserts.Nil(stored)
+
+	store.Finish("key", Response{StatusCode: http.StatusCreated, Body: []byte("created")})
+
+	stored, err = store.Start(ctx, "key", "body")
+	asserts.Nil(err)
+	asserts.Equal(http.StatusCreated, stored.StatusCode)
+	asserts.Equal([]byte("created"), stored.Body)
+
+	_, err = store.Start(ctx, "key", "other body")
+	asserts.ErrorIs(err, ErrKeyReused)
+}
+
+func TestMemoryStore_Waits_For_In_Flight(t *testing.T) {
+	asserts := assert.New(t)
+	store := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	_, _ = store.Start(ctx, "key", "body")
+
+	replayed := make(chan *Response)
+	go func() {
+		stored, _ := store.Start(ctx, "key", "body")
+		replayed <- stored
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	store.Finish("key", Response{StatusCode: http.StatusCreated})
+
+	asserts.Equal(http.StatusCreated, (<-replayed).StatusCode)
+
+	// waiting stops with the context
+	_, _ = store.Start(ctx, "other", "body")
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := store.Start(canceled, "other", "body")
+	asserts.ErrorIs(err, context.Canceled)
+}
+
+func TestMemoryStore_Abort(t *testing.T) {
+	asserts := assert.New(t)
+	store := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	_, _ = store.Start(ctx, "key", "body")
+
+	takeover := make(chan error)
+	go func() {
+		stored, err := store.Start(ctx, "key", "body")
+		asserts.Nil(stored)
+		takeover <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	store.Abort("key")
+
+	// the duplicate reserved the key in turn
+	asserts.Nil(<-takeover)
+	store.Finish("key", Response{StatusCode: http.StatusCreated})
+	stored, _ := store.Start(ctx, "key", "body")
+	asserts.Equal(http.StatusCreated, stored.StatusCode)
+}
+
+func TestMemoryStore_Expiration(t *testing.T) {
+	asserts := assert.New(t)
+	now := time.Now()
+	store := NewMemoryStore(time.Minute)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, _ = store.Start(ctx, "key", "body")
+	store.Finish("key", Response{StatusCode: http.StatusCreated})
+	_, _ = store.Start(ctx, "in flight", "body")
+
+	now = now.Add(time.Minute)
+	store.Purge()
+	asserts.Len(store.entries, 1)
+
+	// expired keys can be used with another request
+	stored, err := store.Start(ctx, "key", "other body")
+	asserts.Nil(err)
+	asserts.Nil(stored)
+}
@@ -0,0 +1,64 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+)
+
+// NewFileAPIKeyRepository keeps the keys in memory and rewrites the JSON file
+// at path after every change. The file is replaced atomically so a crash
+// never leaves it half written.
+func NewFileAPIKeyRepository(path string) (repository.APIKeyRepository, error) {
+	keys := []entity.APIKey{}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading api keys file: %w", err)
+	default:
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return nil, fmt.Errorf("parsing api keys file %s: %w", path, err)
+		}
+	}
+
+	repo := newMemoryAPIKeyRepository(keys)
+	repo.onChange = func(keys []entity.APIKey) error {
+		return writeJSONFile(path, keys)
+	}
+
+	return repo, nil
+}
+
+func writeJSONFile(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,48 @@
+package infrastructure
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileAPIKeyRepository_Persists_Keys(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	repo, err := NewFileAPIKeyRepository(path)
+	asserts.Nil(err)
+
+	key, err := repo.CreateAPIKey(ctx, entity.NewAPIKey("ci", "", []string{"tasks:read"}, "tdk_abcdef", "hash"))
+	asserts.Nil(err)
+	key.Revoke()
+	_, err = repo.UpdateAPIKey(ctx, key)
+	asserts.Nil(err)
+
+	info, err := os.Stat(path)
+	asserts.Nil(err)
+	asserts.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := NewFileAPIKeyRepository(path)
+	asserts.Nil(err)
+
+	stored, err := reopened.GetAPIKeyByHash(ctx, "hash")
+	asserts.Nil(err)
+	asserts.Equal(key.Id, stored.Id)
+	asserts.True(stored.IsRevoked())
+}
+
+func TestFileAPIKeyRepository_Invalid_File(t *testing.T) {
+	asserts := assert.New(t)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	_ = os.WriteFile(path, []byte("not json"), 0o600)
+
+	_, err := NewFileAPIKeyRepository(path)
+
+	asserts.ErrorContains(err, "parsing api keys file")
+}
@@ -0,0 +1,95 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+	"github.com/manuelbeos/code-branch-todo-test/internal/metrics"
+)
+
+// InstrumentedTodoListRepository records the duration of every call made to
+// the wrapped repository.
+type InstrumentedTodoListRepository struct {
+	next    repository.TodoListRepository
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedTodoListRepository(next repository.TodoListRepository, m *metrics.Metrics) repository.TodoListRepository {
+	return &InstrumentedTodoListRepository{next: next, metrics: m}
+}
+
+func (ir *InstrumentedTodoListRepository) observe(operation string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	ir.metrics.RepositoryOperationDuration.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
+
+func (ir *InstrumentedTodoListRepository) CreateTask(ctx context.Context, newTask entity.Task) (*entity.Task, error) {
+	start := time.Now()
+	task, err := ir.next.CreateTask(ctx, newTask)
+	ir.observe("CreateTask", start, err)
+
+	return task, err
+}
+
+func (ir *InstrumentedTodoListRepository) GetAllTasks(ctx context.Context) ([]*entity.Task, error) {
+	start := time.Now()
+	tasks, err := ir.next.GetAllTasks(ctx)
+	ir.observe("GetAllTasks", start, err)
+
+	return tasks, err
+}
+
+func (ir *InstrumentedTodoListRepository) GetTaskByID(ctx context.Context, id uuid.UUID) (*entity.Task, error) {
+	start := time.Now()
+	task, err := ir.next.GetTaskByID(ctx, id)
+	ir.observe("GetTaskByID", start, err)
+
+	return task, err
+}
+
+func (ir *InstrumentedTodoListRepository) UpdateTask(ctx context.Context, updatedTask *entity.Task) (*entity.Task, error) {
+	start := time.Now()
+	task, err := ir.next.UpdateTask(ctx, updatedTask)
+	ir.observe("UpdateTask", start, err)
+
+	return task, err
+}
+
+func (ir *InstrumentedTodoListRepository) DeleteTask(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	err := ir.next.DeleteTask(ctx, id)
+	ir.observe("DeleteTask", start, err)
+
+	return err
+}
+
+func (ir *InstrumentedTodoListRepository) ImportTasks(ctx context.Context, tasks []entity.Task, dryRun bool) ([]uuid.UUID, []uuid.UUID, error) {
+	start := time.Now()
+	duplicates, hidden, err := ir.next.ImportTasks(ctx, tasks, dryRun)
+	ir.observe("ImportTasks", start, err)
+
+	return duplicates, hidden, err
+}
+
+func (ir *InstrumentedTodoListRepository) CountTasks(ctx context.Context) (int, error) {
+	start := time.Now()
+	count, err := ir.next.CountTasks(ctx)
+	ir.observe("CountTasks", start, err)
+
+	return count, err
+}
+
+func (ir *InstrumentedTodoListRepository) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := ir.next.Ping(ctx)
+	ir.observe("Ping", start, err)
+
+	return err
+}
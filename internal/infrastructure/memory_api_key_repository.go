@@ -0,0 +1,118 @@
+package infrastructure
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+)
+
+type MemoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[uuid.UUID]entity.APIKey
+	// onChange is called with every key after each write, it lets the file
+	// repository persist the same state.
+	onChange func([]entity.APIKey) error
+}
+
+func NewMemoryAPIKeyRepository() repository.APIKeyRepository {
+	return newMemoryAPIKeyRepository(nil)
+}
+
+func newMemoryAPIKeyRepository(keys []entity.APIKey) *MemoryAPIKeyRepository {
+	mr := &MemoryAPIKeyRepository{keys: make(map[uuid.UUID]entity.APIKey, len(keys))}
+	for _, key := range keys {
+		mr.keys[key.Id] = key
+	}
+	return mr
+}
+
+func (mr *MemoryAPIKeyRepository) CreateAPIKey(ctx context.Context, key entity.APIKey) (*entity.APIKey, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	mr.keys[key.Id] = key
+	if err := mr.changed(); err != nil {
+		delete(mr.keys, key.Id)
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (mr *MemoryAPIKeyRepository) GetAPIKeyByID(ctx context.Context, id uuid.UUID) (*entity.APIKey, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	key, ok := mr.keys[id]
+	if !ok {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	return &key, nil
+}
+
+func (mr *MemoryAPIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	for _, key := range mr.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+func (mr *MemoryAPIKeyRepository) GetAllAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	keys := make([]*entity.APIKey, 0, len(mr.keys))
+	for _, key := range mr.snapshot() {
+		keys = append(keys, &key)
+	}
+
+	return keys, nil
+}
+
+func (mr *MemoryAPIKeyRepository) UpdateAPIKey(ctx context.Context, updatedKey *entity.APIKey) (*entity.APIKey, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	previous, ok := mr.keys[updatedKey.Id]
+	if !ok {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	mr.keys[updatedKey.Id] = *updatedKey
+	if err := mr.changed(); err != nil {
+		mr.keys[updatedKey.Id] = previous
+		return nil, err
+	}
+
+	return updatedKey, nil
+}
+
+// snapshot returns the keys sorted by creation date, callers hold the lock.
+func (mr *MemoryAPIKeyRepository) snapshot() []entity.APIKey {
+	keys := make([]entity.APIKey, 0, len(mr.keys))
+	for _, key := range mr.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	return keys
+}
+
+func (mr *MemoryAPIKeyRepository) changed() error {
+	if mr.onChange == nil {
+		return nil
+	}
+	return mr.onChange(mr.snapshot())
+}
@@ -0,0 +1,86 @@
+package infrastructure
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+)
+
+type MemoryGrantRepository struct {
+	mu     sync.RWMutex
+	grants map[uuid.UUID]entity.Grant
+}
+
+func NewMemoryGrantRepository() repository.GrantRepository {
+	return &MemoryGrantRepository{grants: make(map[uuid.UUID]entity.Grant)}
+}
+
+func (mr *MemoryGrantRepository) CreateGrant(ctx context.Context, grant entity.Grant) (*entity.Grant, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	for id, existing := range mr.grants {
+		if existing.SameTarget(&grant) {
+			existing.Role = grant.Role
+			mr.grants[id] = existing
+			return &existing, nil
+		}
+	}
+	mr.grants[grant.Id] = grant
+
+	return &grant, nil
+}
+
+func (mr *MemoryGrantRepository) GetGrantByID(ctx context.Context, id uuid.UUID) (*entity.Grant, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	grant, ok := mr.grants[id]
+	if !ok || !identity.InTenant(ctx, grant.Tenant) {
+		return nil, domain.ErrGrantNotFound
+	}
+
+	return &grant, nil
+}
+
+func (mr *MemoryGrantRepository) GetGrantsByGrantee(ctx context.Context, grantee string) ([]*entity.Grant, error) {
+	return mr.filter(ctx, func(g entity.Grant) bool { return g.Grantee == grantee }), nil
+}
+
+func (mr *MemoryGrantRepository) GetGrantsByOwner(ctx context.Context, owner string) ([]*entity.Grant, error) {
+	return mr.filter(ctx, func(g entity.Grant) bool { return g.Owner == owner }), nil
+}
+
+func (mr *MemoryGrantRepository) DeleteGrant(ctx context.Context, id uuid.UUID) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if grant, ok := mr.grants[id]; !ok || !identity.InTenant(ctx, grant.Tenant) {
+		return domain.ErrGrantNotFound
+	}
+	delete(mr.grants, id)
+
+	return nil
+}
+
+// filter returns the grants of the tenant in ctx accepted by keep.
+func (mr *MemoryGrantRepository) filter(ctx context.Context, keep func(entity.Grant) bool) []*entity.Grant {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	grants := []*entity.Grant{}
+	for _, grant := range mr.grants {
+		if identity.InTenant(ctx, grant.Tenant) && keep(grant) {
+			grants = append(grants, &grant)
+		}
+	}
+	sort.Slice(grants, func(i, j int) bool { return grants[i].CreatedAt.Before(grants[j].CreatedAt) })
+
+	return grants
+}
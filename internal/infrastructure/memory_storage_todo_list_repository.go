@@ -2,24 +2,96 @@ package infrastructure
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
 	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
 	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
 	"github.com/manuelbeos/code-branch-todo-test/internal/utils"
 )
 
 type MemoryStorageTodoListRepository struct {
+	mu          sync.RWMutex
 	memoryTasks map[uuid.UUID]entity.Task
+	// aggregates holds the statistics of each owner in each tenant, updated
+	// with the tasks.
+	aggregates map[aggregateKey]*entity.TaskAggregate
+	// subtasks holds the ids of the tasks under each parent.
+	subtasks map[uuid.UUID]map[uuid.UUID]bool
+}
+
+type aggregateKey struct {
+	tenant string
+	owner  string
 }
 
 func NewMemoryStorageTodoListRepository(tasks map[uuid.UUID]entity.Task) repository.TodoListRepository {
-	return &MemoryStorageTodoListRepository{memoryTasks: tasks}
+	mr := &MemoryStorageTodoListRepository{
+		memoryTasks: tasks,
+		aggregates:  map[aggregateKey]*entity.TaskAggregate{},
+		subtasks:    map[uuid.UUID]map[uuid.UUID]bool{},
+	}
+	for _, task := range tasks {
+		mr.aggregate(&task).Add(&task)
+		mr.index(&task)
+	}
+
+	return mr
+}
+
+// aggregate returns the aggregate counting task, mu must be held for writing.
+func (mr *MemoryStorageTodoListRepository) aggregate(task *entity.Task) *entity.TaskAggregate {
+	key := aggregateKey{tenant: task.Tenant, owner: task.Owner}
+	aggregate, ok := mr.aggregates[key]
+	if !ok {
+		aggregate = entity.NewTaskAggregate()
+		mr.aggregates[key] = aggregate
+	}
+	return aggregate
+}
+
+// index adds task under its parent, mu must be held for writing.
+func (mr *MemoryStorageTodoListRepository) index(task *entity.Task) {
+	if task.ParentId == nil {
+		return
+	}
+	subtasks, ok := mr.subtasks[*task.ParentId]
+	if !ok {
+		subtasks = map[uuid.UUID]bool{}
+		mr.subtasks[*task.ParentId] = subtasks
+	}
+	subtasks[task.Id] = true
+}
+
+// unindex takes task back from under its parent, mu must be held for writing.
+func (mr *MemoryStorageTodoListRepository) unindex(task *entity.Task) {
+	if task.ParentId == nil {
+		return
+	}
+	delete(mr.subtasks[*task.ParentId], task.Id)
+	if len(mr.subtasks[*task.ParentId]) == 0 {
+		delete(mr.subtasks, *task.ParentId)
+	}
+}
+
+// store replaces the task with the same id, mu must be held for writing.
+func (mr *MemoryStorageTodoListRepository) store(task entity.Task) {
+	if existing, ok := mr.memoryTasks[task.Id]; ok {
+		mr.aggregate(&existing).Remove(&existing)
+		mr.unindex(&existing)
+	}
+	mr.memoryTasks[task.Id] = task
+	mr.aggregate(&task).Add(&task)
+	mr.index(&task)
 }
 
 func (mr *MemoryStorageTodoListRepository) CreateTask(ctx context.Context, newTask entity.Task) (*entity.Task, error) {
+	// a task is always stored in the tenant of the request creating it
+	newTask.Tenant = identity.TenantFromContext(ctx)
+
 	chanResponse := make(chan entity.Task)
 
 	go func() {
@@ -33,7 +105,9 @@ func (mr *MemoryStorageTodoListRepository) CreateTask(ctx context.Context, newTa
 
 	taskCreated := <-chanResponse
 
-	mr.memoryTasks[newTask.Id] = taskCreated
+	mr.mu.Lock()
+	mr.store(taskCreated)
+	mr.mu.Unlock()
 
 	return &newTask, nil
 }
@@ -42,10 +116,16 @@ func (mr *MemoryStorageTodoListRepository) GetAllTasks(ctx context.Context) ([]*
 	chanResponse := make(chan []*entity.Task)
 
 	go func() {
+		mr.mu.RLock()
 		tasks := make([]*entity.Task, 0, len(mr.memoryTasks))
 		for _, task := range mr.memoryTasks {
+			if !identity.CanAccess(ctx, &task) {
+				continue
+			}
 			tasks = append(tasks, &task)
 		}
+		mr.mu.RUnlock()
+
 		sleepTime := time.Duration(utils.RandomNumber(500, 2000)) * time.Millisecond
 		time.Sleep(sleepTime)
 
@@ -63,8 +143,12 @@ func (mr *MemoryStorageTodoListRepository) GetAllTasks(ctx context.Context) ([]*
 }
 
 func (mr *MemoryStorageTodoListRepository) GetTaskByID(ctx context.Context, id uuid.UUID) (*entity.Task, error) {
+	mr.mu.RLock()
 	task, ok := mr.memoryTasks[id]
-	if !ok {
+	mr.mu.RUnlock()
+
+	// tasks the caller can't see are reported as missing so their ids don't leak
+	if !ok || !identity.CanAccess(ctx, &task) {
 		return nil, domain.ErrTaskNotFound
 	}
 
@@ -72,12 +156,140 @@ func (mr *MemoryStorageTodoListRepository) GetTaskByID(ctx context.Context, id u
 }
 
 func (mr *MemoryStorageTodoListRepository) UpdateTask(ctx context.Context, updatedTask *entity.Task) (*entity.Task, error) {
-	mr.memoryTasks[updatedTask.Id] = *updatedTask
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if existing, ok := mr.memoryTasks[updatedTask.Id]; ok {
+		if !identity.CanAccess(ctx, &existing) {
+			return nil, domain.ErrTaskNotFound
+		}
+		// the owner never changes on update
+		updatedTask.Owner = existing.Owner
+	}
+	mr.store(*updatedTask)
+
 	return updatedTask, nil
 }
 
 func (mr *MemoryStorageTodoListRepository) DeleteTask(ctx context.Context, id uuid.UUID) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	existing, ok := mr.memoryTasks[id]
+	if !ok {
+		return nil
+	}
+	if !identity.CanAccess(ctx, &existing) {
+		return domain.ErrTaskNotFound
+	}
 	delete(mr.memoryTasks, id)
+	mr.aggregate(&existing).Remove(&existing)
+	mr.unindex(&existing)
 
 	return nil
 }
+
+// GetSubtaskIDs reads the index of the subtasks, without the simulated
+// delay of GetAllTasks.
+func (mr *MemoryStorageTodoListRepository) GetSubtaskIDs(ctx context.Context, parent uuid.UUID) ([]uuid.UUID, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	var ids []uuid.UUID
+	for id := range mr.subtasks[parent] {
+		if task := mr.memoryTasks[id]; identity.CanAccess(ctx, &task) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (mr *MemoryStorageTodoListRepository) ImportTasks(ctx context.Context, tasks []entity.Task, dryRun bool) ([]uuid.UUID, []uuid.UUID, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	var duplicates, hidden []uuid.UUID
+	for _, task := range tasks {
+		if existing, ok := mr.memoryTasks[task.Id]; ok {
+			if identity.CanAccess(ctx, &existing) {
+				duplicates = append(duplicates, task.Id)
+			} else {
+				hidden = append(hidden, task.Id)
+			}
+		}
+	}
+	if dryRun || len(duplicates) > 0 || len(hidden) > 0 {
+		return duplicates, hidden, nil
+	}
+
+	for _, task := range tasks {
+		mr.store(task)
+	}
+	return nil, nil, nil
+}
+
+func (mr *MemoryStorageTodoListRepository) CountTasks(ctx context.Context) (int, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	count := 0
+	for _, task := range mr.memoryTasks {
+		if identity.InTenant(ctx, task.Tenant) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Ping only fails when the context is already done, the memory storage is
+// always reachable.
+func (mr *MemoryStorageTodoListRepository) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// CountTasksByState counts the stored tasks without the simulated delay, it
+// is cheap enough to be called on every metrics scrape.
+func (mr *MemoryStorageTodoListRepository) CountTasksByState() (completed int, pending int) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	for _, task := range mr.memoryTasks {
+		if task.IsCompleted {
+			completed++
+		} else {
+			pending++
+		}
+	}
+
+	return completed, pending
+}
+
+// AggregateTasks returns the statistics of the caller's tasks in the tenant
+// in the context, or of the whole tenant when authentication is disabled, as
+// they were kept up to date by the writes, without the simulated delay.
+func (mr *MemoryStorageTodoListRepository) AggregateTasks(ctx context.Context) (*entity.TaskAggregate, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	tenant := identity.TenantFromContext(ctx)
+	if owner := identity.OwnerFromContext(ctx); owner != "" {
+		aggregate, ok := mr.aggregates[aggregateKey{tenant: tenant, owner: owner}]
+		if !ok {
+			return entity.NewTaskAggregate(), nil
+		}
+		return aggregate.Clone(), nil
+	}
+
+	total := entity.NewTaskAggregate()
+	if !identity.AuthenticationDisabled(ctx) {
+		return total, nil
+	}
+	for key, aggregate := range mr.aggregates {
+		if key.tenant == tenant {
+			total.Merge(aggregate)
+		}
+	}
+	return total, nil
+}
@@ -3,10 +3,12 @@ package infrastructure
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
 	domain "github.com/manuelbeos/code-branch-todo-test/internal/domain/errors"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/identity"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,7 +33,7 @@ func TestNewMemoryStorageTodoListRepository_Success(t *testing.T) {
 func TestMemoryStorageTodoListRepository_CreateTask_Success(t *testing.T) {
 	asserts := assert.New(t)
 	memory := getTestMemory()
-	ctx := context.Background()
+	ctx := identity.WithoutAuthentication(context.Background())
 	memoryRepo := NewMemoryStorageTodoListRepository(memory)
 	asserts.NotNil(memoryRepo)
 
@@ -52,7 +54,7 @@ func TestMemoryStorageTodoListRepository_CreateTask_Success(t *testing.T) {
 func TestMemoryStorageTodoListRepository_GetAllTasks_Success(t *testing.T) {
 	asserts := assert.New(t)
 	memory := getTestMemory()
-	ctx := context.Background()
+	ctx := identity.WithoutAuthentication(context.Background())
 	memoryRepo := NewMemoryStorageTodoListRepository(memory)
 	asserts.NotNil(memoryRepo)
 
@@ -65,7 +67,7 @@ func TestMemoryStorageTodoListRepository_GetAllTasks_Success(t *testing.T) {
 func TestMemoryStorageTodoListRepository_GetAllTasks_Empty(t *testing.T) {
 	asserts := assert.New(t)
 	memory := make(map[uuid.UUID]entity.Task)
-	ctx := context.Background()
+	ctx := identity.WithoutAuthentication(context.Background())
 	memoryRepo := NewMemoryStorageTodoListRepository(memory)
 	asserts.NotNil(memoryRepo)
 
@@ -78,7 +80,7 @@ func TestMemoryStorageTodoListRepository_GetAllTasks_Empty(t *testing.T) {
 func TestMemoryStorageTodoListRepository_GetTaskByID_Success(t *testing.T) {
 	asserts := assert.New(t)
 	memory := getTestMemory()
-	ctx := context.Background()
+	ctx := identity.WithoutAuthentication(context.Background())
 	memoryRepo := NewMemoryStorageTodoListRepository(memory)
 	asserts.NotNil(memoryRepo)
 
@@ -103,7 +105,7 @@ func TestMemoryStorageTodoListRepository_GetTaskByID_Success(t *testing.T) {
 func TestMemoryStorageTodoListRepository_GetTaskByID_NotFound(t *testing.T) {
 	asserts := assert.New(t)
 	memory := getTestMemory()
-	ctx := context.Background()
+	ctx := identity.WithoutAuthentication(context.Background())
 	memoryRepo := NewMemoryStorageTodoListRepository(memory)
 	asserts.NotNil(memoryRepo)
 
@@ -116,7 +118,7 @@ func TestMemoryStorageTodoListRepository_GetTaskByID_NotFound(t *testing.T) {
 func TestMemoryStorageTodoListRepository_DeleteTask_Success(t *testing.T) {
 	asserts := assert.New(t)
 	memory := getTestMemory()
-	ctx := context.Background()
+	ctx := identity.WithoutAuthentication(context.Background())
 	memoryRepo := NewMemoryStorageTodoListRepository(memory)
 	asserts.NotNil(memoryRepo)
 
@@ -143,7 +145,7 @@ func TestMemoryStorageTodoListRepository_DeleteTask_Success(t *testing.T) {
 func TestMemoryStorageTodoListRepository_UpdateTask_Success(t *testing.T) {
 	asserts := assert.New(t)
 	memory := getTestMemory()
-	ctx := context.Background()
+	ctx := identity.WithoutAuthentication(context.Background())
 	memoryRepo := NewMemoryStorageTodoListRepository(memory)
 	asserts.NotNil(memoryRepo)
 
@@ -174,3 +176,219 @@ func TestMemoryStorageTodoListRepository_UpdateTask_Success(t *testing.T) {
 	asserts.Equal(taskUpdated.Title, taskByID.Title)
 	asserts.Equal(taskUpdated.Description, taskByID.Description)
 }
+
+func TestMemoryStorageTodoListRepository_Ping(t *testing.T) {
+	asserts := assert.New(t)
+	memoryRepo := NewMemoryStorageTodoListRepository(getTestMemory())
+
+	asserts.Nil(memoryRepo.Ping(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	asserts.ErrorIs(memoryRepo.Ping(ctx), context.Canceled)
+}
+
+func TestMemoryStorageTodoListRepository_Scoped_By_Owner(t *testing.T) {
+	asserts := assert.New(t)
+	aliceTask := entity.Task{Id: uuid.New(), Title: "Alice", Owner: "alice"}
+	bobTask := entity.Task{Id: uuid.New(), Title: "Bob", Owner: "bob"}
+	memoryRepo := NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{
+		aliceTask.Id: aliceTask,
+		bobTask.Id:   bobTask,
+	})
+	aliceCtx := identity.WithOwner(context.Background(), "alice")
+
+	tasks, err := memoryRepo.GetAllTasks(aliceCtx)
+	asserts.Nil(err)
+	asserts.Len(tasks, 1)
+	asserts.Equal(aliceTask.Id, tasks[0].Id)
+
+	_, err = memoryRepo.GetTaskByID(aliceCtx, bobTask.Id)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+
+	_, err = memoryRepo.UpdateTask(aliceCtx, &entity.Task{Id: bobTask.Id, Title: "Stolen"})
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+
+	asserts.ErrorIs(memoryRepo.DeleteTask(aliceCtx, bobTask.Id), domain.ErrTaskNotFound)
+
+	updated, err := memoryRepo.UpdateTask(aliceCtx, &entity.Task{Id: aliceTask.Id, Title: "Renamed"})
+	asserts.Nil(err)
+	asserts.Equal("alice", updated.Owner)
+
+	all, err := memoryRepo.GetAllTasks(identity.WithoutAuthentication(context.Background()))
+	asserts.Nil(err)
+	asserts.Len(all, 2)
+
+	// without a caller nothing is visible unless authentication is disabled
+	_, err = memoryRepo.GetTaskByID(context.Background(), aliceTask.Id)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func TestMemoryStorageTodoListRepository_Scoped_By_Tenant(t *testing.T) {
+	asserts := assert.New(t)
+	acmeTask := entity.Task{Id: uuid.New(), Title: "Acme", Tenant: "acme"}
+	globexTask := entity.Task{Id: uuid.New(), Title: "Globex", Tenant: "globex"}
+	memoryRepo := NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{
+		acmeTask.Id:   acmeTask,
+		globexTask.Id: globexTask,
+	})
+	acmeCtx := identity.WithTenant(identity.WithoutAuthentication(context.Background()), "acme")
+
+	tasks, err := memoryRepo.GetAllTasks(acmeCtx)
+	asserts.Nil(err)
+	asserts.Len(tasks, 1)
+	asserts.Equal(acmeTask.Id, tasks[0].Id)
+
+	_, err = memoryRepo.GetTaskByID(acmeCtx, globexTask.Id)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+
+	_, err = memoryRepo.UpdateTask(acmeCtx, &entity.Task{Id: globexTask.Id, Title: "Stolen"})
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+
+	asserts.ErrorIs(memoryRepo.DeleteTask(acmeCtx, globexTask.Id), domain.ErrTaskNotFound)
+
+	created, err := memoryRepo.CreateTask(acmeCtx, entity.Task{Id: uuid.New(), Title: "New"})
+	asserts.Nil(err)
+	asserts.Equal("acme", created.Tenant)
+
+	count, err := memoryRepo.CountTasks(acmeCtx)
+	asserts.Nil(err)
+	asserts.Equal(2, count)
+
+	count, err = memoryRepo.CountTasks(identity.WithTenant(context.Background(), "globex"))
+	asserts.Nil(err)
+	asserts.Equal(1, count)
+}
+
+func TestMemoryStorageTodoListRepository_AggregateTasks(t *testing.T) {
+	asserts := assert.New(t)
+	acmeTask := entity.Task{Id: uuid.New(), Title: "Acme", Tenant: "acme", Tags: []string{"work"}}
+	globexTask := entity.Task{Id: uuid.New(), Title: "Globex", Tenant: "globex"}
+	memoryRepo := NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{
+		acmeTask.Id:   acmeTask,
+		globexTask.Id: globexTask,
+	}).(*MemoryStorageTodoListRepository)
+	acmeCtx := identity.WithTenant(identity.WithoutAuthentication(context.Background()), "acme")
+
+	aggregate, err := memoryRepo.AggregateTasks(acmeCtx)
+	asserts.Nil(err)
+	asserts.Equal(entity.TaskCount{Total: 1}, aggregate.Tasks)
+	asserts.Equal(entity.TaskCount{Total: 1}, aggregate.ByTag["work"])
+	asserts.Equal(map[entity.Priority]entity.TaskCount{entity.PriorityNormal: {Total: 1}}, aggregate.ByPriority)
+
+	completedAt := time.Now()
+	completed := acmeTask
+	completed.IsCompleted = true
+	completed.CompletedAt = &completedAt
+	completed.Tags = []string{"home"}
+	completed.Priority = entity.PriorityHigh
+	_, err = memoryRepo.UpdateTask(acmeCtx, &completed)
+	asserts.Nil(err)
+
+	aggregate, err = memoryRepo.AggregateTasks(acmeCtx)
+	asserts.Nil(err)
+	asserts.Equal(entity.TaskCount{Total: 1, Completed: 1}, aggregate.Tasks)
+	asserts.Equal(map[string]entity.TaskCount{"home": {Total: 1, Completed: 1}}, aggregate.ByTag)
+	asserts.Equal(map[entity.Priority]entity.TaskCount{entity.PriorityHigh: {Total: 1, Completed: 1}}, aggregate.ByPriority)
+
+	asserts.Nil(memoryRepo.DeleteTask(acmeCtx, acmeTask.Id))
+	aggregate, err = memoryRepo.AggregateTasks(acmeCtx)
+	asserts.Nil(err)
+	asserts.Equal(entity.NewTaskAggregate(), aggregate)
+
+	aggregate, err = memoryRepo.AggregateTasks(identity.WithTenant(identity.WithoutAuthentication(context.Background()), "globex"))
+	asserts.Nil(err)
+	asserts.Equal(1, aggregate.Tasks.Total)
+}
+
+func TestMemoryStorageTodoListRepository_AggregateTasks_Owner(t *testing.T) {
+	asserts := assert.New(t)
+	aliceTask := entity.Task{Id: uuid.New(), Title: "Alice", Owner: "alice", Tags: []string{"work"}}
+	bobTask := entity.Task{Id: uuid.New(), Title: "Bob", Owner: "bob", Tags: []string{"secret"}, IsCompleted: true}
+	memoryRepo := NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{
+		aliceTask.Id: aliceTask,
+		bobTask.Id:   bobTask,
+	}).(*MemoryStorageTodoListRepository)
+
+	aggregate, err := memoryRepo.AggregateTasks(identity.WithOwner(context.Background(), "alice"))
+	asserts.Nil(err)
+	asserts.Equal(entity.TaskCount{Total: 1}, aggregate.Tasks)
+	asserts.Equal(map[string]entity.TaskCount{"work": {Total: 1}}, aggregate.ByTag)
+
+	aggregate, err = memoryRepo.AggregateTasks(identity.WithOwner(context.Background(), "carol"))
+	asserts.Nil(err)
+	asserts.Equal(entity.NewTaskAggregate(), aggregate)
+
+	aggregate, err = memoryRepo.AggregateTasks(identity.WithoutAuthentication(context.Background()))
+	asserts.Nil(err)
+	asserts.Equal(entity.TaskCount{Total: 2, Completed: 1}, aggregate.Tasks)
+	asserts.Equal(map[string]entity.TaskCount{"work": {Total: 1}, "secret": {Total: 1, Completed: 1}}, aggregate.ByTag)
+
+	aggregate, err = memoryRepo.AggregateTasks(context.Background())
+	asserts.Nil(err)
+	asserts.Equal(entity.NewTaskAggregate(), aggregate)
+}
+
+func TestMemoryStorageTodoListRepository_GetSubtaskIDs(t *testing.T) {
+	asserts := assert.New(t)
+	parent := entity.Task{Id: uuid.New(), Title: "Parent", Owner: "alice"}
+	child := entity.Task{Id: uuid.New(), Title: "Child", Owner: "alice", ParentId: &parent.Id}
+	hidden := entity.Task{Id: uuid.New(), Title: "Hidden", Owner: "bob", ParentId: &parent.Id}
+	memoryRepo := NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{
+		parent.Id: parent,
+		child.Id:  child,
+		hidden.Id: hidden,
+	}).(*MemoryStorageTodoListRepository)
+	ctx := identity.WithOwner(context.Background(), "alice")
+
+	ids, err := memoryRepo.GetSubtaskIDs(ctx, parent.Id)
+	asserts.Nil(err)
+	asserts.Equal([]uuid.UUID{child.Id}, ids)
+
+	moved := child
+	moved.ParentId = nil
+	_, err = memoryRepo.UpdateTask(ctx, &moved)
+	asserts.Nil(err)
+	ids, err = memoryRepo.GetSubtaskIDs(ctx, parent.Id)
+	asserts.Nil(err)
+	asserts.Empty(ids)
+
+	_, _, err = memoryRepo.ImportTasks(ctx, []entity.Task{{Id: uuid.New(), Title: "Imported", Owner: "alice", ParentId: &child.Id}}, false)
+	asserts.Nil(err)
+	ids, err = memoryRepo.GetSubtaskIDs(ctx, child.Id)
+	asserts.Nil(err)
+	asserts.Len(ids, 1)
+
+	asserts.Nil(memoryRepo.DeleteTask(ctx, ids[0]))
+	ids, err = memoryRepo.GetSubtaskIDs(ctx, child.Id)
+	asserts.Nil(err)
+	asserts.Empty(ids)
+}
+
+func TestMemoryStorageTodoListRepository_ImportTasks_Taken(t *testing.T) {
+	asserts := assert.New(t)
+	mine := entity.Task{Id: uuid.New(), Title: "Mine", Owner: "alice"}
+	other := entity.Task{Id: uuid.New(), Title: "Other", Owner: "bob"}
+	memoryRepo := NewMemoryStorageTodoListRepository(map[uuid.UUID]entity.Task{
+		mine.Id:  mine,
+		other.Id: other,
+	})
+	ctx := identity.WithOwner(context.Background(), "alice")
+	fresh := entity.Task{Id: uuid.New(), Title: "Fresh", Owner: "alice"}
+
+	duplicates, hidden, err := memoryRepo.ImportTasks(ctx, []entity.Task{fresh, {Id: mine.Id}, {Id: other.Id}}, false)
+
+	asserts.Nil(err)
+	asserts.Equal([]uuid.UUID{mine.Id}, duplicates)
+	asserts.Equal([]uuid.UUID{other.Id}, hidden)
+	// nothing is stored when an id is taken
+	_, err = memoryRepo.GetTaskByID(ctx, fresh.Id)
+	asserts.ErrorIs(err, domain.ErrTaskNotFound)
+
+	duplicates, hidden, err = memoryRepo.ImportTasks(ctx, []entity.Task{fresh}, false)
+	asserts.Nil(err)
+	asserts.Empty(duplicates)
+	asserts.Empty(hidden)
+	_, err = memoryRepo.GetTaskByID(ctx, fresh.Id)
+	asserts.Nil(err)
+}
@@ -0,0 +1,107 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/entity"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+	"github.com/manuelbeos/code-branch-todo-test/internal/tracing"
+)
+
+// TracedTodoListRepository wraps every call to the repository in a span.
+type TracedTodoListRepository struct {
+	next repository.TodoListRepository
+}
+
+func NewTracedTodoListRepository(next repository.TodoListRepository) repository.TodoListRepository {
+	return &TracedTodoListRepository{next: next}
+}
+
+func (tr *TracedTodoListRepository) CreateTask(ctx context.Context, newTask entity.Task) (*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TodoListRepository.CreateTask")
+	defer span.End()
+	span.SetAttribute("task.id", newTask.Id.String())
+
+	task, err := tr.next.CreateTask(ctx, newTask)
+	span.RecordError(err)
+
+	return task, err
+}
+
+func (tr *TracedTodoListRepository) GetAllTasks(ctx context.Context) ([]*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TodoListRepository.GetAllTasks")
+	defer span.End()
+
+	tasks, err := tr.next.GetAllTasks(ctx)
+	span.RecordError(err)
+	span.SetAttribute("tasks.count", len(tasks))
+
+	return tasks, err
+}
+
+func (tr *TracedTodoListRepository) GetTaskByID(ctx context.Context, id uuid.UUID) (*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TodoListRepository.GetTaskByID")
+	defer span.End()
+	span.SetAttribute("task.id", id.String())
+
+	task, err := tr.next.GetTaskByID(ctx, id)
+	span.RecordError(err)
+
+	return task, err
+}
+
+func (tr *TracedTodoListRepository) UpdateTask(ctx context.Context, updatedTask *entity.Task) (*entity.Task, error) {
+	ctx, span := tracing.Start(ctx, "TodoListRepository.UpdateTask")
+	defer span.End()
+	span.SetAttribute("task.id", updatedTask.Id.String())
+
+	task, err := tr.next.UpdateTask(ctx, updatedTask)
+	span.RecordError(err)
+
+	return task, err
+}
+
+func (tr *TracedTodoListRepository) DeleteTask(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "TodoListRepository.DeleteTask")
+	defer span.End()
+	span.SetAttribute("task.id", id.String())
+
+	err := tr.next.DeleteTask(ctx, id)
+	span.RecordError(err)
+
+	return err
+}
+
+func (tr *TracedTodoListRepository) ImportTasks(ctx context.Context, tasks []entity.Task, dryRun bool) ([]uuid.UUID, []uuid.UUID, error) {
+	ctx, span := tracing.Start(ctx, "TodoListRepository.ImportTasks")
+	defer span.End()
+
+	duplicates, hidden, err := tr.next.ImportTasks(ctx, tasks, dryRun)
+	span.RecordError(err)
+	span.SetAttribute("tasks.count", len(tasks))
+	span.SetAttribute("tasks.duplicates", len(duplicates)+len(hidden))
+
+	return duplicates, hidden, err
+}
+
+func (tr *TracedTodoListRepository) CountTasks(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "TodoListRepository.CountTasks")
+	defer span.End()
+
+	count, err := tr.next.CountTasks(ctx)
+	span.RecordError(err)
+	span.SetAttribute("tasks.count", count)
+
+	return count, err
+}
+
+func (tr *TracedTodoListRepository) Ping(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "TodoListRepository.Ping")
+	defer span.End()
+
+	err := tr.next.Ping(ctx)
+	span.RecordError(err)
+
+	return err
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics groups every metric exposed by the API.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	HTTPRequestsTotal    *prometheus.CounterVec
+	HTTPRequestDuration  *prometheus.HistogramVec
+	HTTPRequestsInFlight prometheus.Gauge
+	HTTPPanicsTotal      *prometheus.CounterVec
+
+	RepositoryOperationDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	registry := prometheus.NewRegistry()
+	factory := promauto.With(registry)
+
+	return &Metrics{
+		Registry: registry,
+		HTTPRequestsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests by route template and status code.",
+		}, []string{"method", "route", "status"}),
+		HTTPRequestDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route template and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		HTTPRequestsInFlight: factory.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served.",
+		}),
+		HTTPPanicsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_panics_total",
+			Help: "Total number of handler panics recovered by route template.",
+		}, []string{"method", "route"}),
+		RepositoryOperationDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "Time spent in repository operations, including the simulated delay.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation", "result"}),
+	}
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+// RegisterTaskCounts exposes the number of tasks by completion state, computed
+// by count on every scrape.
+func (m *Metrics) RegisterTaskCounts(count func() (completed int, pending int)) {
+	m.Registry.MustRegister(&taskCounts{
+		desc:  prometheus.NewDesc("tasks", "Current number of tasks by completion state.", []string{"state"}, nil),
+		count: count,
+	})
+}
+
+// taskCounts reports both states from a single call to count.
+type taskCounts struct {
+	desc  *prometheus.Desc
+	count func() (completed int, pending int)
+}
+
+func (tc *taskCounts) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tc.desc
+}
+
+func (tc *taskCounts) Collect(ch chan<- prometheus.Metric) {
+	completed, pending := tc.count()
+	ch <- prometheus.MustNewConstMetric(tc.desc, prometheus.GaugeValue, float64(completed), "completed")
+	ch <- prometheus.MustNewConstMetric(tc.desc, prometheus.GaugeValue, float64(pending), "pending")
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Gather(t *testing.T) {
+	m := New()
+	m.HTTPRequestsTotal.WithLabelValues(http.MethodGet, "/tasks/{id}", "200").Inc()
+	m.HTTPRequestsTotal.WithLabelValues(http.MethodPost, "/tasks", "201").Add(2)
+	m.HTTPRequestsInFlight.Inc()
+	m.RegisterTaskCounts(func() (int, int) { return 1, 3 })
+
+	expected := `# HELP http_requests_total Total number of HTTP requests by route template and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/tasks/{id}",status="200"} 1
+http_requests_total{method="POST",route="/tasks",status="201"} 2
+# HELP http_requests_in_flight Number of HTTP requests currently being served.
+# TYPE http_requests_in_flight gauge
+http_requests_in_flight 1
+# HELP tasks Current number of tasks by completion state.
+# TYPE tasks gauge
+tasks{state="completed"} 1
+tasks{state="pending"} 3
+`
+	require.NoError(t, testutil.GatherAndCompare(m.Registry, strings.NewReader(expected), "http_requests_total", "http_requests_in_flight", "tasks"))
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	asserts := assert.New(t)
+	m := New()
+	m.HTTPRequestsTotal.WithLabelValues(http.MethodGet, "bad \"quote\"\n", "404").Inc()
+	m.RepositoryOperationDuration.WithLabelValues("CreateTask", "ok").Observe(0.75)
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(rr.Body)
+	require.NoError(t, err)
+
+	requests := families["http_requests_total"].GetMetric()
+	require.Len(t, requests, 1)
+	asserts.Equal(float64(1), requests[0].GetCounter().GetValue())
+	for _, label := range requests[0].GetLabel() {
+		if label.GetName() == "route" {
+			asserts.Equal("bad \"quote\"\n", label.GetValue())
+		}
+	}
+
+	durations := families["repository_operation_duration_seconds"].GetMetric()
+	require.Len(t, durations, 1)
+	asserts.Equal(uint64(1), durations[0].GetHistogram().GetSampleCount())
+	asserts.Equal(0.75, durations[0].GetHistogram().GetSampleSum())
+}
@@ -16,6 +16,34 @@ type TodoListRepository struct {
 	mock.Mock
 }
 
+// CountTasks provides a mock function with given fields: _a0
+func (_m *TodoListRepository) CountTasks(_a0 context.Context) (int, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountTasks")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateTask provides a mock function with given fields: _a0, _a1
 func (_m *TodoListRepository) CreateTask(_a0 context.Context, _a1 entity.Task) (*entity.Task, error) {
 	ret := _m.Called(_a0, _a1)
@@ -124,6 +152,63 @@ func (_m *TodoListRepository) GetTaskByID(_a0 context.Context, _a1 uuid.UUID) (*
 	return r0, r1
 }
 
+// ImportTasks provides a mock function with given fields: ctx, tasks, dryRun
+func (_m *TodoListRepository) ImportTasks(ctx context.Context, tasks []entity.Task, dryRun bool) ([]uuid.UUID, []uuid.UUID, error) {
+	ret := _m.Called(ctx, tasks, dryRun)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportTasks")
+	}
+
+	var r0 []uuid.UUID
+	var r1 []uuid.UUID
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, []entity.Task, bool) ([]uuid.UUID, []uuid.UUID, error)); ok {
+		return rf(ctx, tasks, dryRun)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []entity.Task, bool) []uuid.UUID); ok {
+		r0 = rf(ctx, tasks, dryRun)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []entity.Task, bool) []uuid.UUID); ok {
+		r1 = rf(ctx, tasks, dryRun)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, []entity.Task, bool) error); ok {
+		r2 = rf(ctx, tasks, dryRun)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Ping provides a mock function with given fields: _a0
+func (_m *TodoListRepository) Ping(_a0 context.Context) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ping")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateTask provides a mock function with given fields: _a0, _a1
 func (_m *TodoListRepository) UpdateTask(_a0 context.Context, _a1 *entity.Task) (*entity.Task, error) {
 	ret := _m.Called(_a0, _a1)
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket is full again and can be forgotten.
+	full time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (ms *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	capacity := limit.Capacity()
+	if limit.Unlimited() {
+		return Result{Allowed: true, Limit: capacity, Remaining: capacity}, nil
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := ms.now()
+	interval := limit.interval()
+
+	b, ok := ms.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(capacity), last: now}
+		ms.buckets[key] = b
+	}
+
+	b.tokens += float64(now.Sub(b.last)) / float64(interval)
+	if b.tokens > float64(capacity) {
+		b.tokens = float64(capacity)
+	}
+	b.last = now
+
+	result := Result{Limit: capacity}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((float64(capacity) - b.tokens) * float64(interval))
+	b.full = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// Purge forgets the buckets that are full again, they behave like new ones.
+func (ms *MemoryStore) Purge() {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := ms.now()
+	for key, b := range ms.buckets {
+		if !b.full.After(now) {
+			delete(ms.buckets, key)
+		}
+	}
+}
+
+// Run purges the store every interval until ctx is done.
+func (ms *MemoryStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ms.Purge()
+		}
+	}
+}
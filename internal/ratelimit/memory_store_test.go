@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore(now *time.Time) *MemoryStore {
+	store := NewMemoryStore()
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	asserts := assert.New(t)
+	now := time.Now()
+	store := newTestStore(&now)
+	limit := Limit{Requests: 2, Period: time.Minute, Burst: 3}
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		result, err := store.Take(context.Background(), "alice", limit)
+		asserts.Nil(err)
+		asserts.True(result.Allowed)
+		asserts.Equal(3, result.Limit)
+		asserts.Equal(remaining, result.Remaining)
+	}
+
+	result, err := store.Take(context.Background(), "alice", limit)
+	asserts.Nil(err)
+	asserts.False(result.Allowed)
+	asserts.Equal(0, result.Remaining)
+	asserts.Equal(30*time.Second, result.RetryAfter)
+	asserts.Equal(90*time.Second, result.Reset)
+
+	// other clients have their own bucket
+	result, _ = store.Take(context.Background(), "bob", limit)
+	asserts.True(result.Allowed)
+
+	// a token is refilled every 30 seconds
+	now = now.Add(30 * time.Second)
+	result, _ = store.Take(context.Background(), "alice", limit)
+	asserts.True(result.Allowed)
+	asserts.Equal(0, result.Remaining)
+}
+
+func TestMemoryStore_Unlimited(t *testing.T) {
+	asserts := assert.New(t)
+	store := NewMemoryStore()
+
+	for i := 0; i < 10; i++ {
+		result, err := store.Take(context.Background(), "alice", Limit{})
+		asserts.Nil(err)
+		asserts.True(result.Allowed)
+	}
+	asserts.Empty(store.buckets)
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	asserts := assert.New(t)
+	now := time.Now()
+	store := newTestStore(&now)
+	limit := Limit{Requests: 1, Period: time.Minute}
+
+	_, _ = store.Take(context.Background(), "alice", limit)
+	store.Purge()
+	asserts.Len(store.buckets, 1)
+
+	now = now.Add(time.Minute)
+	store.Purge()
+	asserts.Empty(store.buckets)
+}
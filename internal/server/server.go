@@ -2,79 +2,372 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	_ "github.com/manuelbeos/code-branch-todo-test/docs" // docs is generated by Swaggo
+	"github.com/manuelbeos/code-branch-todo-test/internal/application/events"
 	"github.com/manuelbeos/code-branch-todo-test/internal/application/service"
+	"github.com/manuelbeos/code-branch-todo-test/internal/auth"
+	"github.com/manuelbeos/code-branch-todo-test/internal/background"
+	"github.com/manuelbeos/code-branch-todo-test/internal/config"
+	"github.com/manuelbeos/code-branch-todo-test/internal/domain/repository"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/admin"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/health"
 	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/middlewares"
 	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/public"
+	"github.com/manuelbeos/code-branch-todo-test/internal/handlers/realtime"
+	"github.com/manuelbeos/code-branch-todo-test/internal/idempotency"
 	"github.com/manuelbeos/code-branch-todo-test/internal/infrastructure"
+	"github.com/manuelbeos/code-branch-todo-test/internal/metrics"
+	"github.com/manuelbeos/code-branch-todo-test/internal/ratelimit"
 	"github.com/manuelbeos/code-branch-todo-test/internal/store"
+	"github.com/manuelbeos/code-branch-todo-test/internal/tracing"
+	"github.com/manuelbeos/code-branch-todo-test/internal/version"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 type Server struct {
-	httpServer *http.Server
-	router     *mux.Router
+	config        *config.Config
+	httpServer    *http.Server
+	router        *mux.Router
+	health        *health.HealthHandler
+	logger        *slog.Logger
+	loggingConfig middlewares.LoggingConfig
 }
 
-func NewServer() *Server {
+func NewServer(cfg *config.Config) *Server {
+	loggingConfig := middlewares.LoggingConfig{
+		Level:        cfg.Logging.Level,
+		Format:       cfg.Logging.Format,
+		LogBodies:    cfg.Logging.LogBodies,
+		MaxBodyBytes: cfg.Logging.MaxBodyBytes,
+		RedactFields: cfg.Logging.RedactFields,
+	}
+	logger := middlewares.NewLogger(os.Stdout, loggingConfig)
+	slog.SetDefault(logger)
+
 	r := mux.NewRouter()
 
+	// kept for clients of the old health check, /healthz and /readyz replace it
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("¡Server up!"))
 	})
 
-	r.PathPrefix("/docs/").Handler(httpSwagger.WrapHandler)
+	healthHandler := health.NewHealthHandler(version.Get())
+	healthHandler.RegisterEndpoints(r)
+
+	if cfg.Features.Swagger {
+		r.PathPrefix("/docs/").Handler(httpSwagger.WrapHandler)
+	}
 
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: r,
+		Addr:              cfg.Server.Address,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+
+	return &Server{config: cfg, httpServer: srv, router: r, health: healthHandler, logger: logger, loggingConfig: loggingConfig}
+}
+
+func newAPIKeyRepository(cfg config.AuthConfig) (repository.APIKeyRepository, error) {
+	switch cfg.KeyStore {
+	case config.KeyStoreMemory:
+		return infrastructure.NewMemoryAPIKeyRepository(), nil
+	case config.KeyStoreFile:
+		return infrastructure.NewFileAPIKeyRepository(cfg.KeyFile)
+	}
+
+	return nil, fmt.Errorf("unsupported api key store %q", cfg.KeyStore)
+}
+
+func newRepository(cfg config.StorageConfig) (repository.TodoListRepository, error) {
+	switch cfg.Backend {
+	case config.StorageMemory:
+		return infrastructure.NewMemoryStorageTodoListRepository(store.TasksDB), nil
+	}
+
+	return nil, fmt.Errorf("unsupported storage backend %q", cfg.Backend)
+}
+
+func newRateLimitConfig(cfg config.RateLimitConfig) middlewares.RateLimitConfig {
+	limit := func(rule config.RateLimitRule) ratelimit.Limit {
+		return ratelimit.Limit{Requests: rule.Requests, Period: rule.Period, Burst: rule.Burst}
+	}
+
+	routes := make(map[string]ratelimit.Limit, len(cfg.Routes))
+	for route, rule := range cfg.Routes {
+		routes[route] = limit(rule)
 	}
 
-	return &Server{httpServer: srv, router: r}
+	return middlewares.RateLimitConfig{
+		Default:        limit(cfg.Default),
+		Routes:         routes,
+		ClientIPHeader: cfg.ClientIPHeader,
+	}
 }
 
-func (s *Server) Run() error {
+// Run serves until ctx is canceled or the process receives SIGINT or SIGTERM,
+// then shuts down gracefully. Errors are returned instead of exiting.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workers := background.NewGroup()
+
+	traceExporter, err := tracing.NewExporter(tracing.Config{
+		Exporter:     s.config.Tracing.Exporter,
+		FilePath:     s.config.Tracing.FilePath,
+		OTLPEndpoint: s.config.Tracing.OTLPEndpoint,
+		ServiceName:  s.config.Tracing.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+	if traceExporter != nil {
+		tracing.SetGlobal(tracing.NewTracer(traceExporter))
+		// registered first so it runs last and gets the spans of the shutdown
+		workers.OnStop(traceExporter.Shutdown)
+	}
 
 	// dependency injection
-	memoryStorageRepo := infrastructure.NewMemoryStorageTodoListRepository(store.TasksDB)
-	todoListService := service.NewTodoListService(memoryStorageRepo)
+	appMetrics := metrics.New()
+	storageRepo, err := newRepository(s.config.Storage)
+	if err != nil {
+		return errors.Join(err, workers.Stop(context.Background()))
+	}
+	s.health.AddCheck("repository", storageRepo.Ping)
+	if counter, ok := storageRepo.(repository.TaskStateCounter); ok {
+		appMetrics.RegisterTaskCounts(counter.CountTasksByState)
+	}
+	if flusher, ok := storageRepo.(repository.Flusher); ok {
+		workers.OnStop(flusher.Flush)
+	}
+	instrumentedRepo := infrastructure.NewInstrumentedTodoListRepository(storageRepo, appMetrics)
+	tracedRepo := infrastructure.NewTracedTodoListRepository(instrumentedRepo)
+
+	eventBus := events.NewBus()
+	serviceOptions := []service.Option{
+		service.WithEventBus(eventBus),
+		service.WithGrantRepository(infrastructure.NewMemoryGrantRepository()),
+	}
+	if finder, ok := storageRepo.(repository.SubtaskFinder); ok {
+		serviceOptions = append(serviceOptions, service.WithSubtaskFinder(finder))
+	}
+	if s.config.Tenancy.Enabled {
+		serviceOptions = append(serviceOptions, service.WithTaskQuota(s.config.Tenancy.TaskQuota))
+	}
+	todoListService := service.NewTodoListService(tracedRepo, serviceOptions...)
+	hub := realtime.NewHub(eventBus)
+	workers.OnStop(func(context.Context) error {
+		hub.Close()
+		return nil
+	})
+
+	guard := auth.Guard(auth.Open)
+	var apiKeyService *service.APIKeyService
+	var authentication func(http.Handler) http.Handler
+	if s.config.Auth.Enabled {
+		apiKeyRepo, err := newAPIKeyRepository(s.config.Auth)
+		if err != nil {
+			return errors.Join(err, workers.Stop(context.Background()))
+		}
+		apiKeyService = service.NewAPIKeyService(apiKeyRepo)
+		if s.config.Auth.BootstrapKey != "" {
+			if err := apiKeyService.EnsureAPIKey(ctx, "bootstrap", s.config.Auth.BootstrapKey, s.config.Auth.BootstrapTenant, auth.Scopes); err != nil {
+				return errors.Join(err, workers.Stop(context.Background()))
+			}
+		}
+
+		var tokens middlewares.TokenVerifier
+		if jwtConfig := s.config.Auth.JWT; jwtConfig.Enabled() {
+			verifierConfig := auth.JWTConfig{
+				HMACSecret: jwtConfig.HMACSecret,
+				JWKSFile:   jwtConfig.JWKSFile,
+				Issuer:     jwtConfig.Issuer,
+				Audience:   jwtConfig.Audience,
+				Leeway:     jwtConfig.Leeway,
+			}
+			if s.config.Tenancy.Enabled {
+				verifierConfig.TenantClaim = s.config.Tenancy.Claim
+			}
+			verifier, err := auth.NewJWTVerifier(verifierConfig)
+			if err != nil {
+				return errors.Join(err, workers.Stop(context.Background()))
+			}
+			tokens = verifier
+		}
+
+		authentication = middlewares.AuthenticationMiddleware(apiKeyService, tokens)
+		guard = middlewares.RequireScope
+	}
+
+	// route limits run after authentication, to key callers by principal, so
+	// credentials get their own limit ahead of it: otherwise failed attempts
+	// would never be counted
+	var authLimit func(http.Handler) http.Handler
+	if s.config.RateLimit.Enabled {
+		limitStore := ratelimit.NewMemoryStore()
+		workers.Go(func(ctx context.Context) {
+			limitStore.Run(ctx, time.Minute)
+		})
+		limitConfig := newRateLimitConfig(s.config.RateLimit)
+		if authentication != nil {
+			authRule := s.config.RateLimit.Auth
+			authLimit = middlewares.AuthRateLimit(ratelimit.Limit{Requests: authRule.Requests, Period: authRule.Period, Burst: authRule.Burst}, limitConfig.ClientIPHeader, limitStore)
+		}
+		limit := middlewares.RateLimit(limitConfig, limitStore)
+		scoped := guard
+		guard = func(scope string, next http.HandlerFunc) http.HandlerFunc {
+			return limit(scoped(scope, next))
+		}
+	}
+
+	// task routes also need a tenant, the key admin routes are global
+	taskGuard := guard
+	if s.config.Tenancy.Enabled {
+		taskGuard = func(scope string, next http.HandlerFunc) http.HandlerFunc {
+			return guard(scope, middlewares.RequireTenant(next))
+		}
+	}
+
+	handlerOptions := []public.HandlerOption{public.WithGuard(taskGuard)}
+	if s.config.Idempotency.Enabled {
+		idempotencyStore := idempotency.NewMemoryStore(s.config.Idempotency.TTL)
+		workers.Go(func(ctx context.Context) {
+			idempotencyStore.Run(ctx, time.Minute)
+		})
+		handlerOptions = append(handlerOptions, public.WithIdempotency(middlewares.Idempotency(idempotencyStore)))
+	}
 
 	// handlers
-	public.NewTodoListHandler(todoListService).RegisterEndpoints(s.router)
+	public.NewTodoListHandler(todoListService, handlerOptions...).RegisterEndpoints(s.router)
+	if aggregator, ok := storageRepo.(repository.TaskAggregator); ok {
+		public.NewStatsHandler(service.NewTaskStatsService(aggregator), taskGuard).RegisterEndpoints(s.router)
+	}
+	if s.config.Features.WebSocket {
+		realtime.NewTaskSocketHandler(hub, todoListService, realtime.WithGuard(taskGuard)).RegisterEndpoints(s.router)
+	}
+	if apiKeyService != nil {
+		admin.NewAPIKeyHandler(apiKeyService, guard).RegisterEndpoints(s.router)
+	}
+	if s.config.Features.Metrics {
+		s.router.Handle("/metrics", appMetrics.Handler()).Methods(http.MethodGet)
+	}
 
 	//middlewares
-	s.router.Use(middlewares.LoggingMiddleware)
+	s.router.Use(middlewares.RequestIDMiddleware)
+	s.router.Use(middlewares.ProblemDetailsMiddleware)
+	s.router.Use(middlewares.TracingMiddleware)
+	s.router.Use(middlewares.MetricsMiddleware(appMetrics))
+	s.router.Use(middlewares.LoggingMiddleware(s.logger, s.loggingConfig))
+	s.router.Use(middlewares.RecoveryMiddleware(s.logger, appMetrics))
+	s.router.Use(middlewares.MaxBodyBytesMiddleware(s.config.Server.MaxBodyBytes))
+	if authLimit != nil {
+		s.router.Use(authLimit)
+	}
+	if authentication != nil {
+		s.router.Use(authentication)
+	} else {
+		s.router.Use(middlewares.AuthenticationDisabledMiddleware)
+	}
+	if s.config.Tenancy.Enabled {
+		s.router.Use(middlewares.TenantMiddleware(middlewares.TenancyConfig{
+			Header:     s.config.Tenancy.Header,
+			BaseDomain: s.config.Tenancy.BaseDomain,
+			BoundOnly:  authentication != nil,
+		}))
+	}
 
-	s.router.Use(mux.CORSMethodMiddleware(s.router))
+	s.httpServer.Handler = middlewares.CORSMiddleware(middlewares.CORSConfig{
+		AllowedOrigins:   s.config.CORS.AllowedOrigins,
+		AllowedMethods:   s.config.CORS.AllowedMethods,
+		AllowedHeaders:   s.config.CORS.AllowedHeaders,
+		ExposedHeaders:   s.config.CORS.ExposedHeaders,
+		AllowCredentials: s.config.CORS.AllowCredentials,
+		MaxAge:           s.config.CORS.MaxAge,
+	})(s.router)
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
+	tlsConfig := s.config.Server.TLS
+	if tlsConfig.Enabled() {
+		reloader, err := newCertReloader(tlsConfig.CertFile, tlsConfig.KeyFile)
+		if err != nil {
+			return errors.Join(err, workers.Stop(context.Background()))
+		}
+		s.httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+		if tlsConfig.ReloadInterval > 0 {
+			workers.Go(func(ctx context.Context) {
+				reloader.watch(ctx, tlsConfig.ReloadInterval)
+			})
+		}
+	}
 
+	serveErr := make(chan error, 1)
 	go func() {
-		log.Println("Server initialized on http://localhost:8080")
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Error trying to start the server: %v", err)
+		var err error
+		if tlsConfig.Enabled() {
+			log.Printf("Server initialized on %s (TLS)", s.httpServer.Addr)
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			log.Printf("Server initialized on %s", s.httpServer.Addr)
+			err = s.httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			serveErr <- fmt.Errorf("error trying to start the server: %w", err)
 		}
 	}()
 
-	<-stop
+	s.health.SetReady(true)
+
+	select {
+	case err := <-serveErr:
+		s.health.SetReady(false)
+		return errors.Join(err, workers.Stop(context.Background()))
+	case <-ctx.Done():
+	}
+
+	return s.shutdown(workers)
+}
+
+// shutdown stops advertising readiness, waits DrainDelay so load balancers
+// notice, then drains in-flight requests and background workers within
+// ShutdownTimeout.
+func (s *Server) shutdown(workers *background.Group) error {
 	log.Println("Server is shutting down...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	s.health.SetReady(false)
+	if s.config.Server.DrainDelay > 0 {
+		time.Sleep(s.config.Server.DrainDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout)
 	defer cancel()
 
+	var errs []error
 	if err := s.httpServer.Shutdown(ctx); err != nil {
-		log.Fatalf("Error trying to shutdown the server: %v", err)
+		errs = append(errs, fmt.Errorf("error trying to shutdown the server: %w", err))
+	}
+	if err := workers.Stop(ctx); err != nil {
+		errs = append(errs, err)
 	}
 
 	log.Println("Server stopped")
 
-	return nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manuelbeos/code-branch-todo-test/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Run_GracefulShutdown(t *testing.T) {
+	asserts := assert.New(t)
+	cfg := config.Default()
+	cfg.Server.Address = "127.0.0.1:0"
+	srv := NewServer(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- srv.Run(ctx) }()
+
+	require.Eventually(t, srv.health.Ready, time.Second, 5*time.Millisecond)
+
+	cancel()
+
+	select {
+	case err := <-result:
+		asserts.Nil(err)
+	case <-time.After(cfg.Server.ShutdownTimeout):
+		t.Fatal("server didn't stop")
+	}
+	asserts.False(srv.health.Ready())
+}
+
+func TestServer_Run_Error_Listen(t *testing.T) {
+	asserts := assert.New(t)
+	cfg := config.Default()
+	cfg.Server.Address = "127.0.0.1:99999"
+	srv := NewServer(cfg)
+
+	err := srv.Run(context.Background())
+
+	asserts.ErrorContains(err, "error trying to start the server")
+	asserts.False(srv.health.Ready())
+}
+
+func TestServer_Run_Error_Repository(t *testing.T) {
+	asserts := assert.New(t)
+	cfg := config.Default()
+	cfg.Server.Address = "127.0.0.1:0"
+	cfg.Storage.Backend = "unknown"
+	srv := NewServer(cfg)
+
+	err := srv.Run(context.Background())
+
+	asserts.ErrorContains(err, `unsupported storage backend "unknown"`)
+	asserts.False(srv.health.Ready())
+}
+
+// runServer starts srv and returns a function serving requests with its
+// handler, the server is stopped when the test ends.
+func runServer(t *testing.T, srv *Server) func(*http.Request) *httptest.ResponseRecorder {
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- srv.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-result
+	})
+	require.Eventually(t, srv.health.Ready, time.Second, 5*time.Millisecond)
+
+	return func(req *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+}
+
+func newTenantRequest(method string, target string, key string, tenant string, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-API-Key", key)
+	if tenant != "" {
+		req.Header.Set("X-Tenant-ID", tenant)
+	}
+	return req
+}
+
+func TestServer_Run_Tenancy_Bootstrap(t *testing.T) {
+	asserts := assert.New(t)
+	cfg := config.Default()
+	cfg.Server.Address = "127.0.0.1:0"
+	cfg.Auth.Enabled = true
+	cfg.Auth.BootstrapKey = "bootstrap-secret"
+	cfg.Tenancy.Enabled = true
+	serve := runServer(t, NewServer(cfg))
+
+	// the bootstrap key isn't bound to a tenant, it can only create the keys
+	response := serve(newTenantRequest(http.MethodPost, "/tasks", cfg.Auth.BootstrapKey, "acme", `{"title": "Task"}`))
+	asserts.Equal(http.StatusForbidden, response.Code)
+	response = serve(newTenantRequest(http.MethodPost, "/tasks", cfg.Auth.BootstrapKey, "", `{"title": "Task"}`))
+	asserts.Equal(http.StatusBadRequest, response.Code)
+
+	response = serve(newTenantRequest(http.MethodPost, "/admin/keys", cfg.Auth.BootstrapKey, "", `{"name": "acme", "tenant": "acme", "scopes": ["tasks:read", "tasks:write"]}`))
+	require.Equal(t, http.StatusCreated, response.Code)
+	var key struct {
+		Key string `json:"key"`
+	}
+	require.Nil(t, json.NewDecoder(response.Body).Decode(&key))
+
+	response = serve(newTenantRequest(http.MethodPost, "/tasks", key.Key, "acme", `{"title": "Task"}`))
+	require.Equal(t, http.StatusCreated, response.Code)
+	var task struct {
+		Id string `json:"id"`
+	}
+	require.Nil(t, json.NewDecoder(response.Body).Decode(&task))
+
+	response = serve(newTenantRequest(http.MethodGet, "/tasks/"+task.Id, key.Key, "", ""))
+	asserts.Equal(http.StatusOK, response.Code)
+	response = serve(newTenantRequest(http.MethodGet, "/tasks/"+task.Id, key.Key, "globex", ""))
+	asserts.Equal(http.StatusForbidden, response.Code)
+}
+
+func TestServer_Run_Tenancy_Bootstrap_Tenant(t *testing.T) {
+	asserts := assert.New(t)
+	cfg := config.Default()
+	cfg.Server.Address = "127.0.0.1:0"
+	cfg.Auth.Enabled = true
+	cfg.Auth.BootstrapKey = "bootstrap-secret"
+	cfg.Auth.BootstrapTenant = "acme"
+	cfg.Tenancy.Enabled = true
+	serve := runServer(t, NewServer(cfg))
+
+	response := serve(newTenantRequest(http.MethodPost, "/tasks", cfg.Auth.BootstrapKey, "acme", `{"title": "Task"}`))
+	asserts.Equal(http.StatusCreated, response.Code)
+	response = serve(newTenantRequest(http.MethodPost, "/tasks", cfg.Auth.BootstrapKey, "globex", `{"title": "Task"}`))
+	asserts.Equal(http.StatusForbidden, response.Code)
+	response = serve(newTenantRequest(http.MethodPost, "/admin/keys", cfg.Auth.BootstrapKey, "acme", `{"name": "ci", "tenant": "acme", "scopes": ["tasks:read"]}`))
+	asserts.Equal(http.StatusCreated, response.Code)
+}
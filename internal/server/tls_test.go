@@ -0,0 +1,88 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCertificate(t *testing.T, dir string, commonName string, modTime time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, cr *certReloader) string {
+	cert, err := cr.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_ReloadsChangedFiles(t *testing.T) {
+	asserts := assert.New(t)
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+
+	certFile, keyFile := writeCertificate(t, dir, "first", start)
+	reloader, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	asserts.Equal("first", commonName(t, reloader))
+	asserts.False(reloader.changed())
+
+	writeCertificate(t, dir, "second", start.Add(time.Second))
+	asserts.True(reloader.changed())
+	require.NoError(t, reloader.reload())
+	asserts.Equal("second", commonName(t, reloader))
+}
+
+func TestCertReloader_KeepsCertificateOnBadReload(t *testing.T) {
+	asserts := assert.New(t)
+	dir := t.TempDir()
+
+	certFile, keyFile := writeCertificate(t, dir, "first", time.Now().Add(-time.Minute))
+	reloader, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+
+	asserts.True(reloader.changed())
+	asserts.Error(reloader.reload())
+	asserts.Equal("first", commonName(t, reloader))
+}
+
+func TestNewCertReloader_Error_Missing_Files(t *testing.T) {
+	_, err := newCertReloader("missing.pem", "missing-key.pem")
+	assert.Error(t, err)
+}
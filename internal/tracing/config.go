@@ -0,0 +1,37 @@
+package tracing
+
+import "fmt"
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+type Config struct {
+	// Exporter is one of none, stdout, file or otlp.
+	Exporter     string
+	FilePath     string
+	OTLPEndpoint string
+	ServiceName  string
+}
+
+// NewExporter builds the exporter selected in cfg, nil means tracing is off.
+func NewExporter(cfg Config) (Exporter, error) {
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return NewStdoutExporter(), nil
+	case ExporterFile:
+		return NewFileExporter(cfg.FilePath)
+	case ExporterOTLP:
+		if cfg.OTLPEndpoint == "" {
+			return nil, fmt.Errorf("tracing: otlp exporter requires an endpoint")
+		}
+		return NewOTLPExporter(cfg.OTLPEndpoint, cfg.ServiceName), nil
+	}
+
+	return nil, fmt.Errorf("tracing: unknown exporter %q", cfg.Exporter)
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// Exporter receives finished spans. ExportSpan is called on the request path
+// so implementations must not block for long.
+type Exporter interface {
+	ExportSpan(SpanData)
+	Shutdown(context.Context) error
+}
+
+// WriterExporter writes every span as a JSON line, meant for local use.
+type WriterExporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{encoder: json.NewEncoder(w)}
+}
+
+func NewStdoutExporter() *WriterExporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+// NewFileExporter appends spans to the file at path, creating it if needed.
+func NewFileExporter(path string) (*WriterExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter := NewWriterExporter(file)
+	exporter.closer = file
+
+	return exporter, nil
+}
+
+func (we *WriterExporter) ExportSpan(span SpanData) {
+	we.mu.Lock()
+	defer we.mu.Unlock()
+	_ = we.encoder.Encode(span)
+}
+
+func (we *WriterExporter) Shutdown(context.Context) error {
+	we.mu.Lock()
+	defer we.mu.Unlock()
+
+	if we.closer == nil {
+		return nil
+	}
+	return we.closer.Close()
+}
@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	otlpBatchSize     = 256
+	otlpQueueSize     = 2048
+	otlpFlushInterval = 5 * time.Second
+)
+
+// OTLPExporter batches spans and sends them to an OTLP/HTTP collector using
+// the JSON encoding. Spans are dropped when the queue is full rather than
+// slowing requests down.
+type OTLPExporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+
+	queue chan SpanData
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// NewOTLPExporter sends spans to endpoint, e.g. http://localhost:4318. The
+// /v1/traces path is appended unless already present.
+func NewOTLPExporter(endpoint string, serviceName string) *OTLPExporter {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+
+	exporter := &OTLPExporter{
+		url:         url,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		queue:       make(chan SpanData, otlpQueueSize),
+		done:        make(chan struct{}),
+	}
+
+	exporter.wg.Add(1)
+	go exporter.run()
+
+	return exporter
+}
+
+func (oe *OTLPExporter) ExportSpan(span SpanData) {
+	select {
+	case oe.queue <- span:
+	default:
+	}
+}
+
+func (oe *OTLPExporter) run() {
+	defer oe.wg.Done()
+
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, otlpBatchSize)
+	send := func() {
+		if len(batch) > 0 {
+			_ = oe.send(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case span := <-oe.queue:
+			batch = append(batch, span)
+			if len(batch) == otlpBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case <-oe.done:
+			for {
+				select {
+				case span := <-oe.queue:
+					batch = append(batch, span)
+				default:
+					send()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown sends the queued spans and stops the exporter.
+func (oe *OTLPExporter) Shutdown(ctx context.Context) error {
+	oe.once.Do(func() { close(oe.done) })
+
+	finished := make(chan struct{})
+	go func() {
+		oe.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (oe *OTLPExporter) send(batch []SpanData) error {
+	body, err := json.Marshal(oe.payload(batch))
+	if err != nil {
+		return err
+	}
+
+	resp, err := oe.client.Post(oe.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("otlp collector answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+func toOTLPAttributes(attrs map[string]interface{}) []otlpAttribute {
+	result := make([]otlpAttribute, 0, len(attrs))
+	for key, value := range attrs {
+		attr := otlpAttribute{Key: key}
+		switch v := value.(type) {
+		case bool:
+			attr.Value.BoolValue = &v
+		case int:
+			s := strconv.Itoa(v)
+			attr.Value.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			attr.Value.IntValue = &s
+		case float64:
+			attr.Value.DoubleValue = &v
+		default:
+			s := fmt.Sprint(v)
+			attr.Value.StringValue = &s
+		}
+		result = append(result, attr)
+	}
+	return result
+}
+
+func (oe *OTLPExporter) payload(batch []SpanData) map[string]interface{} {
+	spans := make([]map[string]interface{}, 0, len(batch))
+	for _, span := range batch {
+		spans = append(spans, map[string]interface{}{
+			"traceId":           span.TraceID,
+			"spanId":            span.SpanID,
+			"parentSpanId":      span.ParentSpanID,
+			"name":              span.Name,
+			"kind":              span.Kind,
+			"startTimeUnixNano": strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			"attributes":        toOTLPAttributes(span.Attributes),
+			"status":            map[string]interface{}{"code": span.StatusCode, "message": span.StatusMessage},
+		})
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": toOTLPAttributes(map[string]interface{}{"service.name": oe.serviceName}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": oe.serviceName},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	}
+}
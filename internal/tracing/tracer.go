@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const TraceparentHeader = "traceparent"
+
+// Tracer creates spans and sends the finished ones to its exporter.
+type Tracer struct {
+	exporter Exporter
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+var global atomic.Pointer[Tracer]
+
+// SetGlobal installs the tracer used by Start. Passing nil disables tracing.
+func SetGlobal(t *Tracer) {
+	global.Store(t)
+}
+
+func Enabled() bool {
+	return global.Load() != nil
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// SpanFromContext returns the active span, or nil when there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+type startConfig struct {
+	kind SpanKind
+}
+
+type StartOption func(*startConfig)
+
+func WithKind(kind SpanKind) StartOption {
+	return func(c *startConfig) { c.kind = kind }
+}
+
+// Start begins a span as a child of the span in ctx, or of the remote parent
+// extracted from the incoming request. While tracing is disabled ctx is
+// returned untouched together with a no-op nil span.
+func Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	tracer := global.Load()
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	return tracer.Start(ctx, name, opts...)
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	cfg := startConfig{kind: SpanKindInternal}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	span := &Span{
+		tracer: t,
+		name:   name,
+		kind:   cfg.kind,
+		start:  time.Now(),
+		attrs:  make(map[string]interface{}),
+	}
+
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.context = SpanContext{TraceID: parent.context.TraceID, Sampled: parent.context.Sampled}
+		span.parent = parent.context.SpanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		span.context = SpanContext{TraceID: remote.TraceID, Sampled: remote.Sampled}
+		span.parent = remote.SpanID
+	} else {
+		span.context = SpanContext{TraceID: newTraceID(), Sampled: true}
+	}
+	span.context.SpanID = newSpanID()
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Extract reads the traceparent header and stores it in ctx as the remote
+// parent of the next span started.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Inject writes the active span as a traceparent header for outgoing requests.
+func Inject(ctx context.Context, header http.Header) {
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set(TraceparentHeader, span.context.Traceparent())
+	}
+}
@@ -0,0 +1,165 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (re *recordingExporter) ExportSpan(span SpanData) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.spans = append(re.spans, span)
+}
+
+func (re *recordingExporter) Shutdown(context.Context) error { return nil }
+
+func TestParseTraceparent(t *testing.T) {
+	asserts := assert.New(t)
+
+	tests := []struct {
+		name    string
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{name: "Traceparent - Sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", valid: true, sampled: true},
+		{name: "Traceparent - Not sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", valid: true, sampled: false},
+		{name: "Traceparent - Future version", value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", valid: true, sampled: true},
+		{name: "Traceparent - Zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", valid: false},
+		{name: "Traceparent - Bad length", value: "00-4bf92f3577b34da6-00f067aa0ba902b7-01", valid: false},
+		{name: "Traceparent - Invalid version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", valid: false},
+		{name: "Traceparent - Empty", value: "", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceparent(tt.value)
+			asserts.Equal(tt.valid, ok)
+			if tt.valid {
+				asserts.Equal(tt.sampled, sc.Sampled)
+				asserts.Equal("4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+			}
+		})
+	}
+}
+
+func TestStart_Disabled_ReturnsSameContext(t *testing.T) {
+	asserts := assert.New(t)
+	SetGlobal(nil)
+	ctx := context.Background()
+
+	spanCtx, span := Start(ctx, "noop")
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("ignored"))
+	span.End()
+
+	asserts.Nil(span)
+	asserts.Equal(ctx, spanCtx)
+}
+
+func TestStart_ContinuesRemoteTrace(t *testing.T) {
+	asserts := assert.New(t)
+	exporter := &recordingExporter{}
+	SetGlobal(NewTracer(exporter))
+	defer SetGlobal(nil)
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := Extract(context.Background(), header)
+
+	ctx, root := Start(ctx, "root", WithKind(SpanKindServer))
+	_, child := Start(ctx, "child")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	root.End()
+	root.End()
+
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+
+	require.Len(t, exporter.spans, 2)
+	asserts.Equal("child", exporter.spans[0].Name)
+	asserts.Equal(StatusError, exporter.spans[0].StatusCode)
+	asserts.Equal(exporter.spans[1].SpanID, exporter.spans[0].ParentSpanID)
+	asserts.Equal("00f067aa0ba902b7", exporter.spans[1].ParentSpanID)
+	asserts.Equal("4bf92f3577b34da6a3ce929d0e0e4736", exporter.spans[0].TraceID)
+	asserts.Equal(root.SpanContext().Traceparent(), outgoing.Get(TraceparentHeader))
+}
+
+func TestStart_NotSampled_IsNotExported(t *testing.T) {
+	asserts := assert.New(t)
+	exporter := &recordingExporter{}
+	SetGlobal(NewTracer(exporter))
+	defer SetGlobal(nil)
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	_, span := Start(Extract(context.Background(), header), "root")
+	span.End()
+
+	asserts.Empty(exporter.spans)
+}
+
+func TestSpan_End_IgnoresLaterChanges(t *testing.T) {
+	asserts := assert.New(t)
+	exporter := &recordingExporter{}
+
+	_, span := NewTracer(exporter).Start(context.Background(), "root")
+	span.SetAttribute("task.id", "1")
+	span.End()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		span.SetAttribute("late", true)
+		span.RecordError(errors.New("late"))
+	}()
+	encoded, err := json.Marshal(exporter.spans[0])
+	<-done
+
+	require.NoError(t, err)
+	asserts.NotContains(string(encoded), "late")
+	asserts.Equal(map[string]interface{}{"task.id": "1"}, exporter.spans[0].Attributes)
+	asserts.Equal(StatusUnset, exporter.spans[0].StatusCode)
+}
+
+func TestOTLPExporter_SendsBatchOnShutdown(t *testing.T) {
+	asserts := assert.New(t)
+	received := make(chan map[string]interface{}, 1)
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asserts.Equal("/v1/traces", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		payload := map[string]interface{}{}
+		_ = json.Unmarshal(body, &payload)
+		received <- payload
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(collector.URL, "todo-test")
+	_, span := NewTracer(exporter).Start(context.Background(), "operation")
+	span.End()
+
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	payload := <-received
+	resourceSpans := payload["resourceSpans"].([]interface{})
+	scopeSpans := resourceSpans[0].(map[string]interface{})["scopeSpans"].([]interface{})
+	spans := scopeSpans[0].(map[string]interface{})["spans"].([]interface{})
+	asserts.Equal("operation", spans[0].(map[string]interface{})["name"])
+}
@@ -1,12 +1,9 @@
 package utils
 
-import (
-	"math/rand"
-	"time"
-)
-
-var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+import "math/rand"
 
+// RandomNumber uses the top level source of math/rand, which is safe for
+// concurrent use unlike a rand.Rand.
 func RandomNumber(min, max int) int {
-	return rng.Intn(max-min+1) + min
+	return rand.Intn(max-min+1) + min
 }
@@ -0,0 +1,301 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DateLayout is the layout of the dates given to the after and before rules.
+const DateLayout = "2006-01-02"
+
+// FieldError tells why a field of the request is invalid.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Errors lists every invalid field of a request.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	reasons := make([]string, len(e))
+	for i, fieldError := range e {
+		reasons[i] = fieldError.Field + " " + fieldError.Reason
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// rule checks a field and returns the reason it is invalid, or "". It may
+// normalize the value in place.
+type rule func(value reflect.Value) string
+
+type field struct {
+	index int
+	name  string
+	rules []rule
+}
+
+var cache sync.Map // reflect.Type -> []field
+
+// Struct validates the fields of the struct v points to against their
+// `validate` tags, reporting every violation at once. Rules are separated by
+// commas and run in order:
+//
+//	trim           removes the surrounding whitespace
+//	required       not empty
+//	min=N, max=N   length in characters of strings, value of numbers
+//	singleline     no control characters
+//	printable      no control characters but newlines and tabs
+//	oneof=a b c    one of the listed values
+//	after=DATE     dates after DATE (2006-01-02)
+//	before=DATE    dates before DATE
+//
+// Empty optional values skip every rule but required. Fields are named after
+// their json tag. Invalid tags panic, they are programming errors.
+func Struct(v any) []FieldError {
+	value := reflect.ValueOf(v).Elem()
+
+	var errs []FieldError
+	for _, f := range fields(value.Type()) {
+		fieldValue := value.Field(f.index)
+		for _, check := range f.rules {
+			if reason := check(fieldValue); reason != "" {
+				errs = append(errs, FieldError{Field: f.name, Reason: reason})
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// DecodeJSON unmarshals data into v like json.Unmarshal, but rejects unknown
+// fields, reported as Errors, and anything following the JSON value.
+func DecodeJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		if fieldErr, ok := unknownField(err); ok {
+			return Errors{fieldErr}
+		}
+		return err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+// unknownFieldPrefix starts the errors of encoding/json for unknown fields,
+// which have no type of their own. TestDecodeJSON_Unknown_Field_Wording pins
+// it.
+const unknownFieldPrefix = "json: unknown field "
+
+// unknownField reads the name of the unknown field err is about. A field
+// without a name is reported on the body.
+func unknownField(err error) (FieldError, bool) {
+	name, ok := strings.CutPrefix(err.Error(), unknownFieldPrefix)
+	if !ok {
+		return FieldError{}, false
+	}
+	if unquoted, unquoteErr := strconv.Unquote(name); unquoteErr == nil {
+		name = unquoted
+	}
+	if name == "" {
+		return FieldError{Field: "body", Reason: "has a field without a name"}, true
+	}
+	return FieldError{Field: name, Reason: "is not allowed"}, true
+}
+
+func fields(t reflect.Type) []field {
+	if cached, ok := cache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var parsed []field
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		tag, ok := structField.Tag.Lookup("validate")
+		if !ok {
+			continue
+		}
+
+		name, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+		if name == "" {
+			name = structField.Name
+		}
+
+		f := field{index: i, name: name}
+		for _, spec := range strings.Split(tag, ",") {
+			f.rules = append(f.rules, parseRule(structField, spec))
+		}
+		parsed = append(parsed, f)
+	}
+
+	cache.Store(t, parsed)
+	return parsed
+}
+
+func parseRule(structField reflect.StructField, spec string) rule {
+	name, arg, _ := strings.Cut(strings.TrimSpace(spec), "=")
+
+	switch name {
+	case "trim":
+		return trim
+	case "required":
+		return required
+	case "min", "max":
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			panic(fmt.Sprintf("validation: %s of %s needs a number", name, structField.Name))
+		}
+		return optional(length(name == "min", limit))
+	case "singleline":
+		return optional(characters(false))
+	case "printable":
+		return optional(characters(true))
+	case "oneof":
+		return optional(oneOf(strings.Fields(arg)))
+	case "after", "before":
+		date, err := time.Parse(DateLayout, arg)
+		if err != nil {
+			panic(fmt.Sprintf("validation: %s of %s needs a %s date", name, structField.Name, DateLayout))
+		}
+		return optional(dateRange(name == "after", date))
+	}
+
+	panic(fmt.Sprintf("validation: unknown rule %q on %s", name, structField.Name))
+}
+
+// indirect follows pointers, reporting false for nil ones.
+func indirect(value reflect.Value) (reflect.Value, bool) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return value, false
+		}
+		value = value.Elem()
+	}
+	return value, true
+}
+
+func optional(check rule) rule {
+	return func(value reflect.Value) string {
+		if value, ok := indirect(value); !ok || value.IsZero() {
+			return ""
+		}
+		return check(value)
+	}
+}
+
+func trim(value reflect.Value) string {
+	if value, ok := indirect(value); ok && value.Kind() == reflect.String && value.CanSet() {
+		value.SetString(strings.TrimSpace(value.String()))
+	}
+	return ""
+}
+
+func required(value reflect.Value) string {
+	if value, ok := indirect(value); !ok || value.IsZero() {
+		return "is required"
+	}
+	return ""
+}
+
+func length(minimum bool, limit int) rule {
+	return func(value reflect.Value) string {
+		value, _ = indirect(value)
+
+		var n int
+		unit := " characters"
+		switch value.Kind() {
+		case reflect.String:
+			n = utf8.RuneCountInString(value.String())
+		case reflect.Slice, reflect.Map:
+			n = value.Len()
+			unit = " items"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = int(value.Int())
+			unit = ""
+		default:
+			return ""
+		}
+
+		switch {
+		case minimum && n < limit:
+			return "must be at least " + strconv.Itoa(limit) + unit
+		case !minimum && n > limit:
+			return "must be at most " + strconv.Itoa(limit) + unit
+		}
+		return ""
+	}
+}
+
+func characters(multiline bool) rule {
+	return func(value reflect.Value) string {
+		value, _ = indirect(value)
+		if value.Kind() != reflect.String {
+			return ""
+		}
+
+		text := value.String()
+		if !utf8.ValidString(text) {
+			return "must be valid UTF-8"
+		}
+		for _, r := range text {
+			if multiline && (r == '\n' || r == '\r' || r == '\t') {
+				continue
+			}
+			if unicode.IsControl(r) {
+				if multiline {
+					return "must not contain control characters other than newlines and tabs"
+				}
+				return "must not contain control characters or line breaks"
+			}
+		}
+		return ""
+	}
+}
+
+func oneOf(allowed []string) rule {
+	return func(value reflect.Value) string {
+		value, _ = indirect(value)
+		if value.Kind() != reflect.String {
+			return ""
+		}
+
+		for _, a := range allowed {
+			if value.String() == a {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(allowed, ", ")
+	}
+}
+
+func dateRange(after bool, limit time.Time) rule {
+	return func(value reflect.Value) string {
+		value, _ = indirect(value)
+		date, ok := value.Interface().(time.Time)
+		if !ok {
+			return ""
+		}
+
+		switch {
+		case after && !date.After(limit):
+			return "must be after " + limit.Format(DateLayout)
+		case !after && !date.Before(limit):
+			return "must be before " + limit.Format(DateLayout)
+		}
+		return ""
+	}
+}
@@ -0,0 +1,107 @@
+package validation
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type request struct {
+	Title    string     `json:"title" validate:"trim,required,max=5,singleline"`
+	Notes    string     `json:"notes,omitempty" validate:"trim,min=2,printable"`
+	Priority string     `json:"priority" validate:"oneof=low medium high"`
+	DueDate  *time.Time `json:"due_date" validate:"after=2000-01-01,before=2100-01-01"`
+	Tags     []string   `json:"tags" validate:"max=2"`
+	Ignored  string
+}
+
+func date(value string) *time.Time {
+	parsed, _ := time.Parse(DateLayout, value)
+	return &parsed
+}
+
+func TestStruct(t *testing.T) {
+	asserts := assert.New(t)
+
+	tests := []struct {
+		name     string
+		request  request
+		expected []FieldError
+	}{
+		{name: "Struct - Valid", request: request{Title: " abc ", Notes: "line\nline", Priority: "low", DueDate: date("2030-05-01"), Tags: []string{"a"}}},
+		{name: "Struct - Empty optional fields", request: request{Title: "abc"}},
+		{name: "Struct - Required", request: request{Title: "  "}, expected: []FieldError{{Field: "title", Reason: "is required"}}},
+		{name: "Struct - Lengths", request: request{Title: "abcdef", Notes: "a", Tags: []string{"a", "b", "c"}}, expected: []FieldError{
+			{Field: "title", Reason: "must be at most 5 characters"},
+			{Field: "notes", Reason: "must be at least 2 characters"},
+			{Field: "tags", Reason: "must be at most 2 items"},
+		}},
+		{name: "Struct - Characters are counted, not bytes", request: request{Title: "ñandú"}},
+		{name: "Struct - Control characters", request: request{Title: "a\nb", Notes: "a\x00b"}, expected: []FieldError{
+			{Field: "title", Reason: "must not contain control characters or line breaks"},
+			{Field: "notes", Reason: "must not contain control characters other than newlines and tabs"},
+		}},
+		{name: "Struct - Enum", request: request{Title: "abc", Priority: "urgent"}, expected: []FieldError{{Field: "priority", Reason: "must be one of low, medium, high"}}},
+		{name: "Struct - Date too early", request: request{Title: "abc", DueDate: date("1999-12-31")}, expected: []FieldError{{Field: "due_date", Reason: "must be after 2000-01-01"}}},
+		{name: "Struct - Date too late", request: request{Title: "abc", DueDate: date("2100-01-01")}, expected: []FieldError{{Field: "due_date", Reason: "must be before 2100-01-01"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asserts.Equal(tt.expected, Struct(&tt.request))
+		})
+	}
+}
+
+func TestStruct_Trims(t *testing.T) {
+	asserts := assert.New(t)
+	req := request{Title: "  abc\t", Notes: " notes "}
+
+	asserts.Empty(Struct(&req))
+	asserts.Equal("abc", req.Title)
+	asserts.Equal("notes", req.Notes)
+}
+
+func TestStruct_Invalid_Tag(t *testing.T) {
+	asserts := assert.New(t)
+	type invalid struct {
+		Title string `validate:"shiny"`
+	}
+
+	asserts.Panics(func() { Struct(&invalid{}) })
+}
+
+func TestDecodeJSON(t *testing.T) {
+	asserts := assert.New(t)
+
+	var req request
+	asserts.Nil(DecodeJSON([]byte(`{"title": "abc"}  `), &req))
+	asserts.Equal("abc", req.Title)
+
+	err := DecodeJSON([]byte(`{"title": "abc", "done": true}`), &request{})
+	asserts.Equal(Errors{{Field: "done", Reason: "is not allowed"}}, err)
+
+	asserts.NotNil(DecodeJSON([]byte(`{"title": "abc"} {}`), &request{}))
+	asserts.NotNil(DecodeJSON([]byte(`{"title": "abc"}]`), &request{}))
+	asserts.NotNil(DecodeJSON([]byte(`{"title": 1}`), &request{}))
+
+	err = DecodeJSON([]byte(`{"title": "abc", "": 1}`), &request{})
+	asserts.Equal(Errors{{Field: "body", Reason: "has a field without a name"}}, err)
+}
+
+func TestDecodeJSON_Unknown_Field_Wording(t *testing.T) {
+	asserts := assert.New(t)
+
+	// DecodeJSON reads the field name from the text of the error
+	for data, expected := range map[string]string{
+		`{"done": true}`: unknownFieldPrefix + `"done"`,
+		`{"": 1}`:        unknownFieldPrefix + `""`,
+	} {
+		decoder := json.NewDecoder(strings.NewReader(data))
+		decoder.DisallowUnknownFields()
+		asserts.EqualError(decoder.Decode(&request{}), expected)
+	}
+}